@@ -0,0 +1,194 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// anomalyBuckets number of fixed buckets the rolling window of an AnomalyDetector is split into.
+const anomalyBuckets = 10
+
+// Alert raised by an AnomalyDetector when the failure rate leaves its bounds.
+type Alert struct {
+	Time time.Time `json:"time"`
+	// Window duration over which the rates were computed.
+	Window      time.Duration `json:"window"`
+	Total       int64         `json:"total"`
+	Failures    int64         `json:"failures"`
+	FailureRate float64       `json:"failure_rate"`
+}
+
+// AnomalyDetector observer watching the rolling failure rate of verifications, any verification
+// error counting as a failure, and alerting when it leaves the configured bounds. It catches e.g.
+// broken frontend deployments suddenly sending invalid tokens. An alert is raised when the rate
+// leaves the bounds, the next one only once it went back within them.
+type AnomalyDetector struct {
+	// Window duration over which the failure rate is computed.
+	Window time.Duration
+	// MinSamples minimum number of verifications within Window before alerting.
+	MinSamples int64
+	// MinFailureRate failure rates strictly below raise an alert, disabled when zero.
+	MinFailureRate float64
+	// MaxFailureRate failure rates strictly above raise an alert.
+	MaxFailureRate float64
+	// OnAlert called synchronously with the alert.
+	OnAlert func(alert Alert)
+
+	now      func() time.Time
+	mu       sync.Mutex
+	buckets  [anomalyBuckets]anomalyBucket
+	alerting bool
+}
+
+type anomalyBucket struct {
+	index           int64
+	total, failures int64
+}
+
+// NewAnomalyDetector new AnomalyDetector calling onAlert when the failure rate over window exceeds maxFailureRate
+func NewAnomalyDetector(window time.Duration, maxFailureRate float64, onAlert func(alert Alert)) (*AnomalyDetector, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("anomaly detector window must be positive")
+	}
+	if maxFailureRate < 0 || maxFailureRate > 1 {
+		return nil, fmt.Errorf("maximum failure rate must be between 0 and 1, got '%f'", maxFailureRate)
+	}
+	if onAlert == nil {
+		return nil, fmt.Errorf("anomaly detector alert callback cannot be nil")
+	}
+	return &AnomalyDetector{Window: window, MaxFailureRate: maxFailureRate, OnAlert: onAlert, now: time.Now}, nil
+}
+
+// Observe records the outcome of verification events, other events are ignored.
+func (d *AnomalyDetector) Observe(event Event) {
+	if event.Kind != EventVerification {
+		return
+	}
+	alert, raise := d.record(event.Err != nil)
+	if raise {
+		d.OnAlert(alert)
+	}
+}
+
+// record counts the verification and returns the alert to raise, if any.
+func (d *AnomalyDetector) record(failed bool) (Alert, bool) {
+	now := d.clock()
+	width := int64(d.Window / anomalyBuckets)
+	if width == 0 {
+		width = 1
+	}
+	index := now.UnixNano() / width
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bucket := &d.buckets[index%anomalyBuckets]
+	if bucket.index != index {
+		*bucket = anomalyBucket{index: index}
+	}
+	bucket.total++
+	if failed {
+		bucket.failures++
+	}
+
+	alert := Alert{Time: now, Window: d.Window}
+	for _, b := range d.buckets {
+		if index-b.index < anomalyBuckets {
+			alert.Total += b.total
+			alert.Failures += b.failures
+		}
+	}
+	if alert.Total < d.MinSamples || alert.Total == 0 {
+		return alert, false
+	}
+	alert.FailureRate = float64(alert.Failures) / float64(alert.Total)
+
+	anomalous := alert.FailureRate > d.MaxFailureRate || alert.FailureRate < d.MinFailureRate
+	raise := anomalous && !d.alerting
+	d.alerting = anomalous
+	return alert, raise
+}
+
+func (d *AnomalyDetector) clock() time.Time {
+	if d.now == nil {
+		return time.Now()
+	}
+	return d.now()
+}
+
+// Webhook posts alerts as JSON to URL, it can be used as AnomalyDetector.OnAlert.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+	// OnError when set is called with the errors met while posting alerts.
+	OnError func(err error)
+
+	mu       sync.Mutex
+	inflight sync.WaitGroup
+	shutdown bool
+}
+
+// Alert posts the alert from its own goroutine so verifications aren't slowed down. Alerts raised
+// after Shutdown are dropped and reported to OnError.
+func (w *Webhook) Alert(alert Alert) {
+	w.mu.Lock()
+	if w.shutdown {
+		w.mu.Unlock()
+		if w.OnError != nil {
+			w.OnError(fmt.Errorf("alert webhook shut down"))
+		}
+		return
+	}
+	w.inflight.Add(1)
+	w.mu.Unlock()
+
+	go func() {
+		defer w.inflight.Done()
+		if err := w.post(alert); err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	}()
+}
+
+// Shutdown stops accepting alerts and waits for the ones being posted, or for the context to be done.
+func (w *Webhook) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	w.shutdown = true
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Webhook) post(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("couldn't post alert: '%s'", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert webhook replied '%s'", resp.Status)
+	}
+	return nil
+}
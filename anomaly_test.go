@@ -0,0 +1,135 @@
+package recaptcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AnomalySuite struct{}
+
+var _ = Suite(&AnomalySuite{})
+
+func (s *AnomalySuite) TestNewAnomalyDetector(c *C) {
+	onAlert := func(Alert) {}
+	_, err := NewAnomalyDetector(0, 0.5, onAlert)
+	c.Check(err, ErrorMatches, "anomaly detector window must be positive")
+	_, err = NewAnomalyDetector(time.Minute, 1.5, onAlert)
+	c.Check(err, ErrorMatches, "maximum failure rate must be between 0 and 1, got '1.500000'")
+	_, err = NewAnomalyDetector(time.Minute, 0.5, nil)
+	c.Check(err, ErrorMatches, "anomaly detector alert callback cannot be nil")
+}
+
+func (s *AnomalySuite) TestAlertsOnceWhenFailureRateExceeded(c *C) {
+	now := time.Unix(600, 0)
+	var alerts []Alert
+	detector, err := NewAnomalyDetector(time.Minute, 0.5, func(alert Alert) { alerts = append(alerts, alert) })
+	c.Assert(err, IsNil)
+	detector.MinSamples = 4
+	detector.now = func() time.Time { return now }
+
+	failed := Event{Kind: EventVerification, Err: errors.New("invalid-input-response")}
+	for i := 0; i < 3; i++ {
+		detector.Observe(failed)
+	}
+	c.Check(alerts, HasLen, 0)
+
+	detector.Observe(Event{Kind: EventVerification})
+	c.Assert(alerts, HasLen, 1)
+	c.Check(alerts[0].Total, Equals, int64(4))
+	c.Check(alerts[0].Failures, Equals, int64(3))
+	c.Check(alerts[0].FailureRate, Equals, 0.75)
+	detector.Observe(failed)
+	c.Check(alerts, HasLen, 1)
+
+	// lockouts and other events are ignored
+	detector.Observe(Event{Kind: EventLockout, Err: errors.New("locked")})
+	c.Check(alerts, HasLen, 1)
+
+	// the failures fall out of the window, a new burst alerts again
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		detector.Observe(Event{Kind: EventVerification})
+	}
+	c.Check(alerts, HasLen, 1)
+	for i := 0; i < 5; i++ {
+		detector.Observe(failed)
+	}
+	c.Assert(alerts, HasLen, 2)
+	c.Check(alerts[1].Total, Equals, int64(9))
+}
+
+func (s *AnomalySuite) TestAlertsWhenFailureRateTooLow(c *C) {
+	alerts := 0
+	detector, err := NewAnomalyDetector(time.Minute, 1, func(Alert) { alerts++ })
+	c.Assert(err, IsNil)
+	detector.MinFailureRate = 0.01
+	detector.Observe(Event{Kind: EventVerification})
+	c.Check(alerts, Equals, 1)
+}
+
+func (s *AnomalySuite) TestWebhook(c *C) {
+	alerts := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		json.NewDecoder(r.Body).Decode(&alert)
+		alerts <- alert
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{URL: server.URL}
+	webhook.Alert(Alert{Total: 10, Failures: 9, FailureRate: 0.9})
+	select {
+	case alert := <-alerts:
+		c.Check(alert.Failures, Equals, int64(9))
+	case <-time.After(time.Second):
+		c.Fatal("webhook not called")
+	}
+}
+
+func (s *AnomalySuite) TestWebhookError(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 1)
+	webhook := &Webhook{URL: server.URL, OnError: func(err error) { errs <- err }}
+	webhook.Alert(Alert{})
+	select {
+	case err := <-errs:
+		c.Check(err, ErrorMatches, "alert webhook replied '500 Internal Server Error'")
+	case <-time.After(time.Second):
+		c.Fatal("error not reported")
+	}
+}
+
+func (s *AnomalySuite) TestWebhookShutdown(c *C) {
+	release := make(chan struct{})
+	posted := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 1)
+	webhook := &Webhook{URL: server.URL, OnError: func(err error) { errs <- err }}
+	webhook.Alert(Alert{})
+	<-posted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Check(webhook.Shutdown(ctx), Equals, context.DeadlineExceeded)
+
+	webhook.Alert(Alert{})
+	c.Check(<-errs, ErrorMatches, "alert webhook shut down")
+
+	close(release)
+	c.Check(webhook.Shutdown(context.Background()), IsNil)
+}
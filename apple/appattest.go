@@ -0,0 +1,265 @@
+// Package apple verifies Apple App Attest attestations and assertions as well as DeviceCheck tokens,
+// so iOS backends can combine platform attestation with captcha scores in the same decisions.
+package apple
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// appAttestRootCA Apple App Attestation Root CA, from https://www.apple.com/certificateauthority/private/
+const appAttestRootCA = `-----BEGIN CERTIFICATE-----
+MIICITCCAaegAwIBAgIQC/O+DvHN0uD7jG5yH2IXmDAKBggqhkjOPQQDAzBSMSYw
+JAYDVQQDDB1BcHBsZSBBcHAgQXR0ZXN0YXRpb24gUm9vdCBDQTETMBEGA1UECgwK
+QXBwbGUgSW5jLjETMBEGA1UECAwKQ2FsaWZvcm5pYTAeFw0yMDAzMTgxODMyNTNa
+Fw00NTAzMTUwMDAwMDBaMFIxJjAkBgNVBAMMHUFwcGxlIEFwcCBBdHRlc3RhdGlv
+biBSb290IENBMRMwEQYDVQQKDApBcHBsZSBJbmMuMRMwEQYDVQQIDApDYWxpZm9y
+bmlhMHYwEAYHKoZIzj0CAQYFK4EEACIDYgAERTHhmLW07ATaFQIEVwTtT4dyctdh
+NbJhFs/Ii2FdCgAHGbpphY3+d8qjuDngIN3WVhQUBHAoMeQ/cLiP1sOUtgjqK9au
+Yen1mMEvRq9Sk3Jm5X8U62H+xTD3FE9TgS41o0IwQDAPBgNVHRMBAf8EBTADAQH/
+MB0GA1UdDgQWBBSskRBTM72+aEH/pwyp5frq5eWKoTAOBgNVHQ8BAf8EBAMCAQYw
+CgYIKoZIzj0EAwMDaAAwZQIwQgFGnByvsiVbpTKwSga0kP0e8EeDS4+sQmTvb7vn
+53O5+FRXgeLhpJ06ysC5PrOyAjEAp5U4xDgEgllF7En3VcE3iexZZtKeYnpqtijV
+oyFraWVIyd/dganmrduC1bmTBGwD
+-----END CERTIFICATE-----`
+
+// nonceExtension OID of the credential certificate extension holding the attestation nonce
+var nonceExtension = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}
+
+var (
+	productionAAGUID  = []byte("appattest\x00\x00\x00\x00\x00\x00\x00")
+	developmentAAGUID = []byte("appattestdevelop")
+)
+
+// Key attested App Attest key, to persist after the attestation and to pass to VerifyAssertion.
+type Key struct {
+	// ID base64 encoded key identifier generated by the app.
+	ID        string
+	PublicKey *ecdsa.PublicKey
+	// Counter number of assertions made with the key, updated by VerifyAssertion.
+	Counter uint32
+	// Receipt App Attest receipt, used to get a fraud risk metric from Apple.
+	Receipt []byte
+}
+
+// AppAttest verifies App Attest attestations and assertions of an app.
+type AppAttest struct {
+	TeamID   string
+	BundleID string
+	// Development accept keys attested in the development environment.
+	Development bool
+	// Roots certificate pool holding the App Attestation root CA.
+	Roots *x509.CertPool
+
+	now func() time.Time
+}
+
+// NewAppAttest new AppAttest for the app with bundleID developed by teamID
+func NewAppAttest(teamID, bundleID string) (*AppAttest, error) {
+	if teamID == "" || bundleID == "" {
+		return nil, fmt.Errorf("app attest team ID and bundle ID cannot be blank")
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(appAttestRootCA))
+	return &AppAttest{TeamID: teamID, BundleID: bundleID, Roots: roots, now: time.Now}, nil
+}
+
+type attestationObject struct {
+	Fmt     string `cbor:"fmt"`
+	AttStmt struct {
+		X5c     [][]byte `cbor:"x5c"`
+		Receipt []byte   `cbor:"receipt"`
+	} `cbor:"attStmt"`
+	AuthData []byte `cbor:"authData"`
+}
+
+type assertionObject struct {
+	Signature         []byte `cbor:"signature"`
+	AuthenticatorData []byte `cbor:"authenticatorData"`
+}
+
+// authenticatorData fields shared by attestations and assertions
+type authenticatorData struct {
+	rpIDHash []byte
+	counter  uint32
+	// attested credential data, attestations only
+	aaguid       []byte
+	credentialID []byte
+}
+
+func parseAuthenticatorData(data []byte, attested bool) (authenticatorData, error) {
+	if len(data) < 37 {
+		return authenticatorData{}, fmt.Errorf("authenticator data too short")
+	}
+	auth := authenticatorData{rpIDHash: data[:32], counter: binary.BigEndian.Uint32(data[33:37])}
+	if !attested {
+		return auth, nil
+	}
+	if len(data) < 55 {
+		return authenticatorData{}, fmt.Errorf("attested credential data too short")
+	}
+	auth.aaguid = data[37:53]
+	length := int(binary.BigEndian.Uint16(data[53:55]))
+	if len(data) < 55+length {
+		return authenticatorData{}, fmt.Errorf("attested credential data too short")
+	}
+	auth.credentialID = data[55 : 55+length]
+	return auth, nil
+}
+
+// VerifyAttestation verifies the attestation of the key keyID, made by the app for the one-time
+// challenge sent by the server, and returns the attested key.
+func (a *AppAttest) VerifyAttestation(keyID string, attestation, challenge []byte) (*Key, error) {
+	id, err := base64.StdEncoding.DecodeString(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key ID '%s'", keyID)
+	}
+	var object attestationObject
+	if err := cbor.Unmarshal(attestation, &object); err != nil {
+		return nil, fmt.Errorf("invalid attestation: '%s'", err)
+	}
+	if object.Fmt != "apple-appattest" {
+		return nil, fmt.Errorf("invalid attestation format '%s', while expecting 'apple-appattest'", object.Fmt)
+	}
+	if len(object.AttStmt.X5c) == 0 {
+		return nil, fmt.Errorf("missing attestation certificates")
+	}
+
+	certs := make([]*x509.Certificate, len(object.AttStmt.X5c))
+	for i, der := range object.AttStmt.X5c {
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, fmt.Errorf("invalid attestation certificate: '%s'", err)
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         a.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   a.clock(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted attestation certificate: '%s'", err)
+	}
+
+	clientDataHash := sha256.Sum256(challenge)
+	nonce := sha256.Sum256(append(append([]byte{}, object.AuthData...), clientDataHash[:]...))
+	certNonce, err := attestationNonce(certs[0])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(certNonce, nonce[:]) {
+		return nil, fmt.Errorf("attestation nonce mismatch")
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok || publicKey.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("attestation public key isn't a P-256 key")
+	}
+	publicKeyHash := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+	if !bytes.Equal(publicKeyHash[:], id) {
+		return nil, fmt.Errorf("attested public key doesn't match key ID '%s'", keyID)
+	}
+
+	auth, err := parseAuthenticatorData(object.AuthData, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkAppID(auth); err != nil {
+		return nil, err
+	}
+	if auth.counter != 0 {
+		return nil, fmt.Errorf("attestation counter '%d', while expecting '0'", auth.counter)
+	}
+	if !bytes.Equal(auth.aaguid, productionAAGUID) && !(a.Development && bytes.Equal(auth.aaguid, developmentAAGUID)) {
+		return nil, fmt.Errorf("invalid attestation environment '%s'", bytes.TrimRight(auth.aaguid, "\x00"))
+	}
+	if !bytes.Equal(auth.credentialID, id) {
+		return nil, fmt.Errorf("attested credential ID doesn't match key ID '%s'", keyID)
+	}
+	return &Key{ID: keyID, PublicKey: publicKey, Receipt: object.AttStmt.Receipt}, nil
+}
+
+// VerifyAssertion verifies the assertion made by the app with the attested key for clientData, the
+// request data embedding the one-time challenge to check by the caller. The key counter is updated
+// and must be persisted on success.
+func (a *AppAttest) VerifyAssertion(key *Key, assertion, clientData []byte) error {
+	var object assertionObject
+	if err := cbor.Unmarshal(assertion, &object); err != nil {
+		return fmt.Errorf("invalid assertion: '%s'", err)
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	nonce := sha256.Sum256(append(append([]byte{}, object.AuthenticatorData...), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	if !ecdsa.VerifyASN1(key.PublicKey, digest[:], object.Signature) {
+		return fmt.Errorf("invalid assertion signature")
+	}
+
+	auth, err := parseAuthenticatorData(object.AuthenticatorData, false)
+	if err != nil {
+		return err
+	}
+	if err := a.checkAppID(auth); err != nil {
+		return err
+	}
+	if auth.counter <= key.Counter {
+		return fmt.Errorf("assertion counter '%d', while expecting more than '%d'", auth.counter, key.Counter)
+	}
+	key.Counter = auth.counter
+	return nil
+}
+
+// AssertionResponse same as VerifyAssertion but returns a verification response, successful with a
+// score of 1, so assertions can be combined with captcha results in decisions.
+func (a *AppAttest) AssertionResponse(key *Key, assertion, clientData []byte) (recaptcha.Response, error) {
+	if err := a.VerifyAssertion(key, assertion, clientData); err != nil {
+		return recaptcha.Response{}, err
+	}
+	return recaptcha.Response{Success: true, Score: 1, ChallengeTS: a.clock()}, nil
+}
+
+func (a *AppAttest) checkAppID(auth authenticatorData) error {
+	appID := sha256.Sum256([]byte(a.TeamID + "." + a.BundleID))
+	if !bytes.Equal(auth.rpIDHash, appID[:]) {
+		return fmt.Errorf("relying party ID doesn't match app ID '%s.%s'", a.TeamID, a.BundleID)
+	}
+	return nil
+}
+
+// attestationNonce returns the nonce of the credential certificate extension.
+func attestationNonce(cert *x509.Certificate) ([]byte, error) {
+	for _, extension := range cert.Extensions {
+		if !extension.Id.Equal(nonceExtension) {
+			continue
+		}
+		var value struct {
+			Nonce []byte `asn1:"tag:1,explicit"`
+		}
+		if _, err := asn1.Unmarshal(extension.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid attestation nonce extension: '%s'", err)
+		}
+		return value.Nonce, nil
+	}
+	return nil, fmt.Errorf("missing attestation nonce extension")
+}
+
+func (a *AppAttest) clock() time.Time {
+	if a.now == nil {
+		return time.Now()
+	}
+	return a.now()
+}
@@ -0,0 +1,236 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type AppleSuite struct {
+	appAttest *AppAttest
+	rootKey   *ecdsa.PrivateKey
+	root      *x509.Certificate
+	key       *ecdsa.PrivateKey
+	keyID     []byte
+}
+
+var _ = Suite(&AppleSuite{})
+
+var testTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (s *AppleSuite) SetUpTest(c *C) {
+	var err error
+	s.rootKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test App Attestation Root CA"},
+		NotBefore:             testTime.Add(-time.Hour),
+		NotAfter:              testTime.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &s.rootKey.PublicKey, s.rootKey)
+	c.Assert(err, IsNil)
+	s.root, err = x509.ParseCertificate(der)
+	c.Assert(err, IsNil)
+
+	s.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	hash := sha256.Sum256(elliptic.Marshal(elliptic.P256(), s.key.X, s.key.Y))
+	s.keyID = hash[:]
+
+	s.appAttest, err = NewAppAttest("TEAMID1234", "com.example.app")
+	c.Assert(err, IsNil)
+	s.appAttest.Roots = x509.NewCertPool()
+	s.appAttest.Roots.AddCert(s.root)
+	s.appAttest.now = func() time.Time { return testTime }
+}
+
+func (s *AppleSuite) authData(appID string, counter uint32, aaguid []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(appID))
+	data := append(rpIDHash[:], 0x40)
+	data = binary.BigEndian.AppendUint32(data, counter)
+	if aaguid != nil {
+		data = append(data, aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(s.keyID)))
+		data = append(data, s.keyID...)
+	}
+	return data
+}
+
+// attestation returns an attestation of the test key for the challenge, signed by the test root.
+func (s *AppleSuite) attestation(c *C, authData, challenge []byte) []byte {
+	clientDataHash := sha256.Sum256(challenge)
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	extension, err := asn1.Marshal(struct {
+		Nonce []byte `asn1:"tag:1,explicit"`
+	}{nonce[:]})
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		Subject:         pkix.Name{CommonName: "credential"},
+		NotBefore:       testTime.Add(-time.Hour),
+		NotAfter:        testTime.Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: nonceExtension, Value: extension}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.root, &s.key.PublicKey, s.rootKey)
+	c.Assert(err, IsNil)
+
+	var object attestationObject
+	object.Fmt = "apple-appattest"
+	object.AttStmt.X5c = [][]byte{der}
+	object.AttStmt.Receipt = []byte("receipt")
+	object.AuthData = authData
+	encoded, err := cbor.Marshal(object)
+	c.Assert(err, IsNil)
+	return encoded
+}
+
+func (s *AppleSuite) assertion(c *C, authData, clientData []byte) []byte {
+	clientDataHash := sha256.Sum256(clientData)
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	c.Assert(err, IsNil)
+	encoded, err := cbor.Marshal(assertionObject{Signature: signature, AuthenticatorData: authData})
+	c.Assert(err, IsNil)
+	return encoded
+}
+
+func (s *AppleSuite) TestNewAppAttest(c *C) {
+	_, err := NewAppAttest("", "com.example.app")
+	c.Check(err, ErrorMatches, "app attest team ID and bundle ID cannot be blank")
+
+	appAttest, err := NewAppAttest("TEAMID1234", "com.example.app")
+	c.Assert(err, IsNil)
+	c.Check(appAttest.Roots.Subjects(), HasLen, 1)
+}
+
+func (s *AppleSuite) TestAttestationAndAssertion(c *C) {
+	keyID := base64.StdEncoding.EncodeToString(s.keyID)
+	challenge := []byte("server challenge")
+	attestation := s.attestation(c, s.authData("TEAMID1234.com.example.app", 0, productionAAGUID), challenge)
+
+	key, err := s.appAttest.VerifyAttestation(keyID, attestation, challenge)
+	c.Assert(err, IsNil)
+	c.Check(key.ID, Equals, keyID)
+	c.Check(key.PublicKey.Equal(&s.key.PublicKey), Equals, true)
+	c.Check(string(key.Receipt), Equals, "receipt")
+
+	_, err = s.appAttest.VerifyAttestation(keyID, attestation, []byte("other challenge"))
+	c.Check(err, ErrorMatches, "attestation nonce mismatch")
+
+	clientData := []byte(`{"challenge": "assertion challenge"}`)
+	c.Check(s.appAttest.VerifyAssertion(key, s.assertion(c, s.authData("TEAMID1234.com.example.app", 1, nil), clientData), clientData), IsNil)
+	c.Check(key.Counter, Equals, uint32(1))
+
+	replayed := s.assertion(c, s.authData("TEAMID1234.com.example.app", 1, nil), clientData)
+	c.Check(s.appAttest.VerifyAssertion(key, replayed, clientData), ErrorMatches, "assertion counter '1', while expecting more than '1'")
+
+	assertion := s.assertion(c, s.authData("TEAMID1234.com.example.app", 2, nil), clientData)
+	c.Check(s.appAttest.VerifyAssertion(key, assertion, []byte("tampered")), ErrorMatches, "invalid assertion signature")
+
+	other := s.assertion(c, s.authData("TEAMID1234.com.example.other", 2, nil), clientData)
+	c.Check(s.appAttest.VerifyAssertion(key, other, clientData), ErrorMatches, "relying party ID doesn't match app ID 'TEAMID1234.com.example.app'")
+
+	response, err := s.appAttest.AssertionResponse(key, assertion, clientData)
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(response.Score, Equals, float32(1))
+}
+
+func (s *AppleSuite) TestAttestationChecks(c *C) {
+	keyID := base64.StdEncoding.EncodeToString(s.keyID)
+	challenge := []byte("server challenge")
+
+	development := s.attestation(c, s.authData("TEAMID1234.com.example.app", 0, developmentAAGUID), challenge)
+	_, err := s.appAttest.VerifyAttestation(keyID, development, challenge)
+	c.Check(err, ErrorMatches, "invalid attestation environment 'appattestdevelop'")
+	s.appAttest.Development = true
+	_, err = s.appAttest.VerifyAttestation(keyID, development, challenge)
+	c.Check(err, IsNil)
+
+	counted := s.attestation(c, s.authData("TEAMID1234.com.example.app", 3, productionAAGUID), challenge)
+	_, err = s.appAttest.VerifyAttestation(keyID, counted, challenge)
+	c.Check(err, ErrorMatches, "attestation counter '3', while expecting '0'")
+
+	attestation := s.attestation(c, s.authData("TEAMID1234.com.example.app", 0, productionAAGUID), challenge)
+	otherID := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	_, err = s.appAttest.VerifyAttestation(otherID, attestation, challenge)
+	c.Check(err, ErrorMatches, "attested public key doesn't match key ID.*")
+
+	s.appAttest.Roots = x509.NewCertPool()
+	_, err = s.appAttest.VerifyAttestation(keyID, attestation, challenge)
+	c.Check(err, ErrorMatches, "untrusted attestation certificate.*")
+
+	_, err = s.appAttest.VerifyAttestation(keyID, []byte("garbage"), challenge)
+	c.Check(err, ErrorMatches, "invalid attestation.*")
+}
+
+func (s *AppleSuite) TestDeviceCheck(c *C) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		c.Assert(parts, HasLen, 3)
+		header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		c.Check(string(header), Equals, `{"alg":"ES256","kid":"KEYID12345"}`)
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		c.Assert(signature, HasLen, 64)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		c.Check(ecdsa.Verify(&key.PublicKey, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])), Equals, true)
+
+		var body map[string]interface{}
+		c.Check(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		if body["device_token"] != "valid" {
+			http.Error(w, "Missing or badly formatted authorization token", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	_, err = NewDeviceCheck("TEAMID1234", "KEYID12345", nil, time.Second)
+	c.Check(err, ErrorMatches, "device check private key cannot be nil")
+	deviceCheck, err := NewDeviceCheck("TEAMID1234", "KEYID12345", key, time.Second)
+	c.Assert(err, IsNil)
+	deviceCheck.link = server.URL
+
+	response, err := deviceCheck.VerifyWithOptionsResponse("valid", recaptcha.VerifyOption{})
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(deviceCheck.Verify("invalid"), ErrorMatches, "device check endpoint replied '400 Bad Request': Missing or badly formatted authorization token")
+}
+
+func (s *AppleSuite) TestParsePrivateKey(c *C) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	c.Assert(err, IsNil)
+	parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	c.Assert(err, IsNil)
+	c.Check(parsed.Equal(key), Equals, true)
+
+	_, err = ParsePrivateKey([]byte("garbage"))
+	c.Check(err, ErrorMatches, "invalid PEM private key")
+}
@@ -0,0 +1,158 @@
+package apple
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+const (
+	deviceCheckLink            = "https://api.devicecheck.apple.com/v1/validate_device_token"
+	developmentDeviceCheckLink = "https://api.development.devicecheck.apple.com/v1/validate_device_token"
+)
+
+// DeviceCheck recaptcha.Verifier validating DeviceCheck device tokens with Apple's API, successful
+// verifications have a score of 1.
+type DeviceCheck struct {
+	TeamID string
+	// KeyID identifier of the DeviceCheck private key.
+	KeyID      string
+	PrivateKey *ecdsa.PrivateKey
+	// Development validate tokens of the development environment.
+	Development bool
+	Client      *http.Client
+
+	link string
+	now  func() time.Time
+}
+
+var _ recaptcha.Verifier = (*DeviceCheck)(nil)
+
+// NewDeviceCheck new DeviceCheck authenticating with the private key keyID of teamID
+func NewDeviceCheck(teamID, keyID string, privateKey *ecdsa.PrivateKey, timeout time.Duration) (*DeviceCheck, error) {
+	if teamID == "" || keyID == "" {
+		return nil, fmt.Errorf("device check team ID and key ID cannot be blank")
+	}
+	if privateKey == nil {
+		return nil, fmt.Errorf("device check private key cannot be nil")
+	}
+	return &DeviceCheck{TeamID: teamID, KeyID: keyID, PrivateKey: privateKey, Client: &http.Client{Timeout: timeout}, now: time.Now}, nil
+}
+
+// ParsePrivateKey parses the PEM encoded PKCS #8 private key (.p8 file) downloaded from Apple.
+func ParsePrivateKey(pemBytes []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: '%s'", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key isn't an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// Verify returns `nil` if Apple considers the device token valid
+func (d *DeviceCheck) Verify(deviceToken string) error {
+	_, err := d.VerifyWithOptionsResponse(deviceToken, recaptcha.VerifyOption{})
+	return err
+}
+
+// VerifyWithOptions same as Verify, options are ignored
+func (d *DeviceCheck) VerifyWithOptions(deviceToken string, options recaptcha.VerifyOption) error {
+	_, err := d.VerifyWithOptionsResponse(deviceToken, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns a verification response
+func (d *DeviceCheck) VerifyWithOptionsResponse(deviceToken string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	now := d.clock()
+	transactionID := make([]byte, 16)
+	if _, err := rand.Read(transactionID); err != nil {
+		return recaptcha.Response{}, fmt.Errorf("couldn't generate transaction ID: '%s'", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"device_token":   deviceToken,
+		"transaction_id": hex.EncodeToString(transactionID),
+		"timestamp":      now.UnixNano() / int64(time.Millisecond),
+	})
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+	token, err := d.token(now)
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return recaptcha.Response{}, fmt.Errorf("error posting to device check endpoint: '%s'", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return recaptcha.Response{}, fmt.Errorf("device check endpoint replied '%s': %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	return recaptcha.Response{Success: true, Score: 1, ChallengeTS: now}, nil
+}
+
+// token returns the ES256 signed JWT authenticating the request.
+func (d *DeviceCheck) token(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": d.KeyID})
+	claims, _ := json.Marshal(map[string]interface{}{"iss": d.TeamID, "iat": now.Unix()})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, d.PrivateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("couldn't sign device check token: '%s'", err)
+	}
+	// JWS ECDSA signatures are the fixed size concatenation of r and s
+	signature := append(padded(r, 32), padded(s, 32)...)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func padded(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func (d *DeviceCheck) endpoint() string {
+	switch {
+	case d.link != "":
+		return d.link
+	case d.Development:
+		return developmentDeviceCheckLink
+	}
+	return deviceCheckLink
+}
+
+func (d *DeviceCheck) clock() time.Time {
+	if d.now == nil {
+		return time.Now()
+	}
+	return d.now()
+}
@@ -0,0 +1,108 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrQueueFull the AsyncVerifier queue is full, the verification wasn't queued.
+	ErrQueueFull = errors.New("async verification queue full")
+	// ErrVerifierShutdown the AsyncVerifier was shut down, the verification wasn't queued.
+	ErrVerifierShutdown = errors.New("async verifier shut down")
+)
+
+type asyncJob struct {
+	challengeResponse string
+	options           VerifyOption
+	callback          func(VerifyResult)
+}
+
+// AsyncVerifier verifies challenge responses out-of-band with a pool of workers, so high-throughput
+// endpoints can accept the requests immediately and act on the verification results in callbacks.
+// The queue is bounded: verifications are rejected with ErrQueueFull rather than piling up while
+// recaptcha is slow.
+type AsyncVerifier struct {
+	Verifier Verifier
+
+	mu     sync.RWMutex
+	queue  chan asyncJob
+	closed bool
+	wg     sync.WaitGroup
+}
+
+var _ Shutdowner = (*AsyncVerifier)(nil)
+
+// NewAsyncVerifier new AsyncVerifier running workers goroutines verifying with verifier, queueing at
+// most queueSize pending verifications
+func NewAsyncVerifier(verifier Verifier, workers, queueSize int) (*AsyncVerifier, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("async verifier cannot be nil")
+	}
+	if workers <= 0 {
+		return nil, fmt.Errorf("async verifier workers must be positive")
+	}
+	if queueSize < 0 {
+		return nil, fmt.Errorf("async verifier queue size cannot be negative")
+	}
+	a := &AsyncVerifier{Verifier: verifier, queue: make(chan asyncJob, queueSize)}
+	a.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go a.work()
+	}
+	return a, nil
+}
+
+// VerifyAsync queues the verification of the challenge response, callback being called with its
+// result from a worker goroutine. The verification isn't bound to the context of the request being
+// handled, which is usually done before it runs. It returns ErrQueueFull when the queue is full and
+// ErrVerifierShutdown after Shutdown, the callback isn't called then.
+func (a *AsyncVerifier) VerifyAsync(challengeResponse string, options VerifyOption, callback func(VerifyResult)) error {
+	if callback == nil {
+		return fmt.Errorf("async verification callback cannot be nil")
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrVerifierShutdown
+	}
+	select {
+	case a.queue <- asyncJob{challengeResponse: challengeResponse, options: options, callback: callback}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting verifications and waits for the queued ones to be verified and their
+// callbacks to return, giving up when the context is done.
+func (a *AsyncVerifier) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncVerifier) work() {
+	defer a.wg.Done()
+	for job := range a.queue {
+		response, err := VerifyContext(context.Background(), a.Verifier, job.challengeResponse, job.options)
+		job.callback(VerifyResult{Response: response, Err: err})
+	}
+}
@@ -0,0 +1,68 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AsyncVerifierSuite struct{}
+
+var _ = Suite(&AsyncVerifierSuite{})
+
+func (s *AsyncVerifierSuite) TestNewAsyncVerifier(c *C) {
+	verifier := mockVerifier(func(string, VerifyOption) error { return nil })
+	_, err := NewAsyncVerifier(nil, 1, 1)
+	c.Check(err, ErrorMatches, "async verifier cannot be nil")
+	_, err = NewAsyncVerifier(verifier, 0, 1)
+	c.Check(err, ErrorMatches, "async verifier workers must be positive")
+	_, err = NewAsyncVerifier(verifier, 1, -1)
+	c.Check(err, ErrorMatches, "async verifier queue size cannot be negative")
+}
+
+func (s *AsyncVerifierSuite) TestVerifyAsync(c *C) {
+	release := make(chan struct{})
+	verifier := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		<-release
+		if challengeResponse != "valid" {
+			return fmt.Errorf("invalid challenge solution")
+		}
+		return nil
+	})
+	async, err := NewAsyncVerifier(verifier, 1, 2)
+	c.Assert(err, IsNil)
+
+	var mu sync.Mutex
+	results := map[string]error{}
+	callback := func(token string) func(VerifyResult) {
+		return func(result VerifyResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			results[token] = result.Err
+		}
+	}
+	c.Check(async.VerifyAsync("valid", VerifyOption{}, nil), ErrorMatches, "async verification callback cannot be nil")
+	c.Assert(async.VerifyAsync("valid", VerifyOption{}, callback("valid")), IsNil)
+	// wait for the worker to pick the first verification
+	for len(async.queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(async.VerifyAsync("invalid", VerifyOption{}, callback("invalid")), IsNil)
+	c.Assert(async.VerifyAsync("other", VerifyOption{}, callback("other")), IsNil)
+	c.Check(async.VerifyAsync("dropped", VerifyOption{}, callback("dropped")), Equals, ErrQueueFull)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Check(async.Shutdown(ctx), Equals, context.DeadlineExceeded)
+	c.Check(async.VerifyAsync("late", VerifyOption{}, callback("late")), Equals, ErrVerifierShutdown)
+
+	close(release)
+	c.Check(async.Shutdown(context.Background()), IsNil)
+	c.Check(results, HasLen, 3)
+	c.Check(results["valid"], IsNil)
+	c.Check(results["invalid"], ErrorMatches, "invalid challenge solution")
+	c.Check(results["other"], NotNil)
+}
@@ -0,0 +1,173 @@
+// Package awslambda runs recaptcha verifications in AWS Lambda functions behind API Gateway, either
+// as a REQUEST custom authorizer or wrapping a Lambda proxy handler.
+package awslambda
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// ErrUnauthorized returned by Authorizer when the challenge response is missing, API Gateway replies
+// 401 to the client for this exact error message.
+var ErrUnauthorized = errors.New("Unauthorized")
+
+// ProxyHandler Lambda proxy integration handler, as passed to lambda.Start.
+type ProxyHandler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Authorizer API Gateway REQUEST authorizer reading the challenge response from a header. Allowed and
+// quarantined requests get an Allow policy, others a Deny policy, API Gateway then replying 403. The
+// score, action and decision are passed to the integration in the authorizer context.
+type Authorizer struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the request source IP when blank.
+	Options recaptcha.VerifyOption
+	// Header request header holding the challenge response, recaptcha.DefaultTokenHeader when blank.
+	Header string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are denied.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+}
+
+// NewAuthorizer new Authorizer verifying requests with verifier using options
+func NewAuthorizer(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Authorizer {
+	return &Authorizer{Verifier: verifier, Options: options}
+}
+
+// Handle verifies the challenge response of the request and returns the policy for its method ARN.
+func (a *Authorizer) Handle(ctx context.Context, request events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+	token := header(request.Headers, a.header())
+	if token == "" {
+		return events.APIGatewayCustomAuthorizerResponse{}, ErrUnauthorized
+	}
+	options := a.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = request.RequestContext.Identity.SourceIP
+	}
+	response, err := recaptcha.VerifyContext(ctx, a.Verifier, token, options)
+	outcome := recaptcha.DecideWith(ctx, a.Decider, a.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+
+	effect := "Deny"
+	if outcome.Decision == recaptcha.Allow || outcome.Decision == recaptcha.Quarantine {
+		effect = "Allow"
+	}
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: "recaptcha",
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version:   "2012-10-17",
+			Statement: []events.IAMPolicyStatement{{Action: []string{"execute-api:Invoke"}, Effect: effect, Resource: []string{request.MethodArn}}},
+		},
+		Context: map[string]interface{}{
+			"score":    response.Score,
+			"action":   response.Action,
+			"decision": outcome.Decision.String(),
+		},
+	}, nil
+}
+
+func (a *Authorizer) header() string {
+	if a.Header == "" {
+		return recaptcha.DefaultTokenHeader
+	}
+	return a.Header
+}
+
+// Proxy verifies the requests of a Lambda proxy integration before calling the wrapped handler. The
+// challenge response is read from the Header header, or the Field value of form encoded bodies.
+type Proxy struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the request source IP when blank.
+	Options recaptcha.VerifyOption
+	// Header request header holding the challenge response, recaptcha.DefaultTokenHeader when blank.
+	Header string
+	// Field form field holding the challenge response, recaptcha.DefaultResponseField when blank.
+	Field string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+}
+
+// NewProxy new Proxy verifying requests with verifier using options
+func NewProxy(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Proxy {
+	return &Proxy{Verifier: verifier, Options: options}
+}
+
+// Wrap returns a handler calling next for allowed and quarantined requests only, replying 403 to
+// the others. The decision is added to the request headers as recaptcha.DecisionHeader.
+func (p *Proxy) Wrap(next ProxyHandler) ProxyHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		options := p.Options
+		if options.RemoteIP == "" {
+			options.RemoteIP = request.RequestContext.Identity.SourceIP
+		}
+		response, err := recaptcha.VerifyContext(ctx, p.Verifier, p.token(request), options)
+		outcome := recaptcha.DecideWith(ctx, p.Decider, p.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+		if outcome.Decision != recaptcha.Allow && outcome.Decision != recaptcha.Quarantine {
+			return events.APIGatewayProxyResponse{
+				StatusCode: http.StatusForbidden,
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8", recaptcha.DecisionHeader: outcome.Decision.String()},
+				Body:       http.StatusText(http.StatusForbidden),
+			}, nil
+		}
+
+		headers := make(map[string]string, len(request.Headers)+1)
+		for name, value := range request.Headers {
+			headers[name] = value
+		}
+		headers[recaptcha.DecisionHeader] = outcome.Decision.String()
+		request.Headers = headers
+		return next(ctx, request)
+	}
+}
+
+func (p *Proxy) token(request events.APIGatewayProxyRequest) string {
+	name := p.Header
+	if name == "" {
+		name = recaptcha.DefaultTokenHeader
+	}
+	if token := header(request.Headers, name); token != "" {
+		return token
+	}
+	if !strings.HasPrefix(header(request.Headers, "Content-Type"), "application/x-www-form-urlencoded") {
+		return ""
+	}
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return ""
+		}
+		body = string(decoded)
+	}
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return ""
+	}
+	field := p.Field
+	if field == "" {
+		field = recaptcha.DefaultResponseField
+	}
+	return values.Get(field)
+}
+
+// header returns the value of the header name, API Gateway preserving the case sent by clients.
+func header(headers map[string]string, name string) string {
+	if value, ok := headers[name]; ok {
+		return value
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
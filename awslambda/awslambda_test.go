@@ -0,0 +1,109 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type LambdaSuite struct{}
+
+var _ = Suite(&LambdaSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct {
+	options recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = options
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6, Action: "login"}, nil
+}
+
+func authorizerRequest(token string) events.APIGatewayCustomAuthorizerRequestTypeRequest {
+	request := events.APIGatewayCustomAuthorizerRequestTypeRequest{
+		MethodArn: "arn:aws:execute-api:us-east-1:123456789012:api/prod/POST/login",
+		Headers:   map[string]string{"x-recaptcha-token": token},
+	}
+	request.RequestContext.Identity.SourceIP = "203.0.113.1"
+	return request
+}
+
+func (s *LambdaSuite) TestAuthorizer(c *C) {
+	verifier := &mockVerifier{}
+	authorizer := NewAuthorizer(verifier, recaptcha.VerifyOption{Action: "login"})
+
+	response, err := authorizer.Handle(context.Background(), authorizerRequest("valid"))
+	c.Assert(err, IsNil)
+	c.Check(response.PolicyDocument.Statement, DeepEquals, []events.IAMPolicyStatement{{
+		Action:   []string{"execute-api:Invoke"},
+		Effect:   "Allow",
+		Resource: []string{"arn:aws:execute-api:us-east-1:123456789012:api/prod/POST/login"},
+	}})
+	c.Check(response.Context, DeepEquals, map[string]interface{}{"score": float32(0.6), "action": "login", "decision": "allow"})
+	c.Check(verifier.options.RemoteIP, Equals, "203.0.113.1")
+
+	response, err = authorizer.Handle(context.Background(), authorizerRequest("invalid"))
+	c.Assert(err, IsNil)
+	c.Check(response.PolicyDocument.Statement[0].Effect, Equals, "Deny")
+	c.Check(response.Context["decision"], Equals, "block")
+
+	authorizer.Policy = &recaptcha.Policy{ChallengeBelow: 0.7}
+	response, err = authorizer.Handle(context.Background(), authorizerRequest("valid"))
+	c.Assert(err, IsNil)
+	c.Check(response.PolicyDocument.Statement[0].Effect, Equals, "Deny")
+	c.Check(response.Context["decision"], Equals, "challenge")
+
+	_, err = authorizer.Handle(context.Background(), authorizerRequest(""))
+	c.Check(err, Equals, ErrUnauthorized)
+}
+
+func (s *LambdaSuite) TestProxy(c *C) {
+	var called events.APIGatewayProxyRequest
+	handler := NewProxy(&mockVerifier{}, recaptcha.VerifyOption{}).Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		called = request
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"content-type": "application/x-www-form-urlencoded"},
+		Body:    "g-recaptcha-response=valid&email=user%40example.com",
+	})
+	c.Assert(err, IsNil)
+	c.Check(response.StatusCode, Equals, http.StatusOK)
+	c.Check(called.Headers[recaptcha.DecisionHeader], Equals, "allow")
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{
+		Headers:         map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:            base64.StdEncoding.EncodeToString([]byte("g-recaptcha-response=valid")),
+		IsBase64Encoded: true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(response.StatusCode, Equals, http.StatusOK)
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Recaptcha-Token": "invalid"}})
+	c.Assert(err, IsNil)
+	c.Check(response.StatusCode, Equals, http.StatusForbidden)
+	c.Check(response.Headers[recaptcha.DecisionHeader], Equals, "block")
+}
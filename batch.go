@@ -0,0 +1,178 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Job stored verification processed by a BatchRunner.
+type Job struct {
+	// ID unique identifier of the job, used for checkpointing.
+	ID                string
+	ChallengeResponse string
+	Options           VerifyOption
+}
+
+// JobIterator yields the jobs processed by a BatchRunner, always in the same order so that an
+// interrupted run can resume after its checkpoint.
+type JobIterator interface {
+	// Next returns the next job, ok is false once all jobs were returned.
+	Next() (job Job, ok bool, err error)
+}
+
+// JobOutcome result of a processed job.
+type JobOutcome struct {
+	Job      Job
+	Response Response
+	Err      error
+	// Outcome decision taken by BatchRunner.Decider or Policy, or derived from Err when neither is set.
+	Outcome Outcome
+}
+
+// JobSink receives the outcome of every processed job.
+type JobSink interface {
+	Write(outcome JobOutcome) error
+}
+
+// Checkpointer persists the ID of the last processed job so an interrupted run can be resumed.
+type Checkpointer interface {
+	// Load returns the ID of the last processed job, blank when starting from scratch.
+	Load() (string, error)
+	Save(id string) error
+}
+
+// BatchRunner verifies stored jobs offline, e.g. for retroactive analysis after running in shadow mode,
+// rate limiting the verification requests and checkpointing its progress.
+type BatchRunner struct {
+	Verifier Verifier
+	// Policy when set is used to decide the outcome of every job.
+	Policy *Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider Decider
+	// Rate maximum verifications per second, unlimited when zero.
+	Rate float64
+	// Checkpoint when set resumes after the last processed job and saves the progress.
+	Checkpoint Checkpointer
+	// CheckpointEvery number of jobs processed between two checkpoint saves, every job when zero.
+	CheckpointEvery int
+}
+
+// NewBatchRunner new BatchRunner verifying jobs with verifier, at most rate per second when non-zero.
+func NewBatchRunner(verifier Verifier, rate float64) *BatchRunner {
+	return &BatchRunner{Verifier: verifier, Rate: rate}
+}
+
+// Run processes every job of the iterator, writing their outcomes to the sink, until the iterator is
+// exhausted, the context is done or the iterator, sink or checkpointer fails. It returns the number of
+// jobs processed by this run.
+func (b *BatchRunner) Run(ctx context.Context, jobs JobIterator, sink JobSink) (int, error) {
+	var resumeAfter string
+	if b.Checkpoint != nil {
+		var err error
+		if resumeAfter, err = b.Checkpoint.Load(); err != nil {
+			return 0, fmt.Errorf("couldn't load checkpoint: '%s'", err)
+		}
+	}
+
+	var tick <-chan time.Time
+	if b.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / b.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	processed, unsaved, lastID := 0, 0, ""
+	// save persists the progress made since the last checkpoint save
+	save := func() error {
+		if b.Checkpoint == nil || unsaved == 0 {
+			return nil
+		}
+		if err := b.Checkpoint.Save(lastID); err != nil {
+			return fmt.Errorf("couldn't save checkpoint: '%s'", err)
+		}
+		unsaved = 0
+		return nil
+	}
+
+	for {
+		job, ok, err := jobs.Next()
+		if err != nil {
+			return processed, firstError(fmt.Errorf("couldn't read next job: '%s'", err), save())
+		}
+		if !ok {
+			break
+		}
+		if resumeAfter != "" {
+			if job.ID == resumeAfter {
+				resumeAfter = ""
+			}
+			continue
+		}
+
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return processed, firstError(ctx.Err(), save())
+			}
+		} else if ctx.Err() != nil {
+			return processed, firstError(ctx.Err(), save())
+		}
+
+		response, err := VerifyContext(ctx, b.Verifier, job.ChallengeResponse, job.Options)
+		outcome := JobOutcome{Job: job, Response: response, Err: err, Outcome: DecideWith(ctx, b.Decider, b.Policy, VerifyResult{Response: response, Err: err}, job.Options)}
+		if err := sink.Write(outcome); err != nil {
+			return processed, firstError(fmt.Errorf("couldn't write outcome of job '%s': '%s'", job.ID, err), save())
+		}
+		processed, unsaved, lastID = processed+1, unsaved+1, job.ID
+
+		if unsaved >= b.checkpointEvery() {
+			if err := save(); err != nil {
+				return processed, err
+			}
+		}
+	}
+
+	if resumeAfter != "" {
+		return processed, fmt.Errorf("checkpoint job '%s' not found", resumeAfter)
+	}
+	return processed, save()
+}
+
+func (b *BatchRunner) checkpointEvery() int {
+	if b.CheckpointEvery <= 0 {
+		return 1
+	}
+	return b.CheckpointEvery
+}
+
+// firstError returns the first non nil error, all of them being evaluated by the caller.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileCheckpoint Checkpointer storing the last processed job ID in a file.
+type FileCheckpoint string
+
+// Load returns the job ID stored in the file, blank when the file doesn't exist.
+func (f FileCheckpoint) Load() (string, error) {
+	content, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(content)), err
+}
+
+// Save overwrites the file with the job ID.
+func (f FileCheckpoint) Save(id string) error {
+	return ioutil.WriteFile(string(f), []byte(id+"\n"), 0644)
+}
@@ -0,0 +1,128 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type BatchSuite struct{}
+
+var _ = Suite(&BatchSuite{})
+
+type sliceJobs struct {
+	jobs []Job
+	next int
+}
+
+func (s *sliceJobs) Next() (Job, bool, error) {
+	if s.next >= len(s.jobs) {
+		return Job{}, false, nil
+	}
+	s.next++
+	return s.jobs[s.next-1], true, nil
+}
+
+type sliceSink struct {
+	outcomes []JobOutcome
+	failAt   int
+}
+
+func (s *sliceSink) Write(outcome JobOutcome) error {
+	if s.failAt > 0 && len(s.outcomes)+1 == s.failAt {
+		return errors.New("sink full")
+	}
+	s.outcomes = append(s.outcomes, outcome)
+	return nil
+}
+
+type memoryCheckpoint struct {
+	id    string
+	saves int
+}
+
+func (m *memoryCheckpoint) Load() (string, error) { return m.id, nil }
+func (m *memoryCheckpoint) Save(id string) error {
+	m.id = id
+	m.saves++
+	return nil
+}
+
+func batchJobs() *sliceJobs {
+	return &sliceJobs{jobs: []Job{
+		{ID: "1", ChallengeResponse: "valid"},
+		{ID: "2", ChallengeResponse: "invalid"},
+		{ID: "3", ChallengeResponse: "valid"},
+	}}
+}
+
+func batchVerifier() mockVerifier {
+	return func(challengeResponse string, options VerifyOption) error {
+		if challengeResponse != "valid" {
+			return &Error{msg: "invalid challenge solution"}
+		}
+		return nil
+	}
+}
+
+func (s *BatchSuite) TestRun(c *C) {
+	sink := &sliceSink{}
+	processed, err := NewBatchRunner(batchVerifier(), 0).Run(context.Background(), batchJobs(), sink)
+	c.Assert(err, IsNil)
+	c.Check(processed, Equals, 3)
+	c.Assert(sink.outcomes, HasLen, 3)
+	c.Check(sink.outcomes[0].Outcome.Decision, Equals, Allow)
+	c.Check(sink.outcomes[1].Err, ErrorMatches, "invalid challenge solution")
+	c.Check(sink.outcomes[1].Outcome.Decision, Equals, Block)
+}
+
+func (s *BatchSuite) TestRunCheckpoint(c *C) {
+	checkpoint := &memoryCheckpoint{}
+	runner := NewBatchRunner(batchVerifier(), 1000)
+	runner.Checkpoint = checkpoint
+	runner.CheckpointEvery = 2
+
+	processed, err := runner.Run(context.Background(), batchJobs(), &sliceSink{failAt: 3})
+	c.Check(err, ErrorMatches, "couldn't write outcome of job '3': 'sink full'")
+	c.Check(processed, Equals, 2)
+	c.Check(checkpoint.id, Equals, "2")
+
+	sink := &sliceSink{}
+	processed, err = runner.Run(context.Background(), batchJobs(), sink)
+	c.Assert(err, IsNil)
+	c.Check(processed, Equals, 1)
+	c.Check(sink.outcomes[0].Job.ID, Equals, "3")
+	c.Check(checkpoint.id, Equals, "3")
+	c.Check(checkpoint.saves, Equals, 2)
+
+	checkpoint.id = "unknown"
+	_, err = runner.Run(context.Background(), batchJobs(), sink)
+	c.Check(err, ErrorMatches, "checkpoint job 'unknown' not found")
+}
+
+func (s *BatchSuite) TestRunCancelled(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	processed, err := NewBatchRunner(batchVerifier(), 0).Run(ctx, batchJobs(), &sliceSink{})
+	c.Check(err, Equals, context.Canceled)
+	c.Check(processed, Equals, 0)
+}
+
+func (s *BatchSuite) TestFileCheckpoint(c *C) {
+	dir, err := ioutil.TempDir("", "recaptcha")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	checkpoint := FileCheckpoint(filepath.Join(dir, "checkpoint"))
+
+	id, err := checkpoint.Load()
+	c.Assert(err, IsNil)
+	c.Check(id, Equals, "")
+	c.Assert(checkpoint.Save("42"), IsNil)
+	id, err = checkpoint.Load()
+	c.Assert(err, IsNil)
+	c.Check(id, Equals, "42")
+}
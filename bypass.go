@@ -0,0 +1,34 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithSkipFunc lets the requests of VerifyFromRequest for which skip returns true through without
+// verification, e.g. authenticated admins, feature-flagged cohorts or verified crawlers. Skipped
+// verifications are reported to the Observers as an EventBypass and flagged in VerifyResult.Skipped.
+func (r *ReCAPTCHA) WithSkipFunc(skip func(*http.Request) bool) *ReCAPTCHA {
+	r.skip = skip
+	return r
+}
+
+// skipReason returns why the request is let through without verification, blank when it must be
+// verified: its remote IP belongs to one of networks or skip returns true.
+func skipReason(req *http.Request, networks []*net.IPNet, skip func(*http.Request) bool, options VerifyOption) string {
+	if network := containingNetwork(networks, options.RemoteIP); network != nil {
+		return fmt.Sprintf("remote IP '%s' in bypass network '%s'", options.RemoteIP, network)
+	}
+	if skip != nil && skip(req) {
+		return "verification skipped by the skip func"
+	}
+	return ""
+}
+
+// bypassEvent returns the EventBypass of a request let through without verification for reason.
+func bypassEvent(options VerifyOption, reason string) Event {
+	event := newEvent(EventBypass, options, Response{}, nil, Outcome{Decision: Allow, Reasons: []string{reason}})
+	event.Action = options.Action
+	return event
+}
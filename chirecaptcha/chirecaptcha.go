@@ -0,0 +1,87 @@
+// Package chirecaptcha adapts recaptcha.Middleware to chi routers, each route or route group using its
+// own verification options while sharing the same verifier and middleware settings.
+package chirecaptcha
+
+import (
+	"fmt"
+	"net/http"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Middleware chi middleware provider, With returning the middleware of a route or route group.
+type Middleware struct {
+	// Base middleware verifying the requests, its Options are completed with the route ones.
+	Base *recaptcha.Middleware
+}
+
+// New new Middleware verifying the requests with base
+func New(base *recaptcha.Middleware) (*Middleware, error) {
+	if base == nil {
+		return nil, fmt.Errorf("base middleware cannot be nil")
+	}
+	return &Middleware{Base: base}, nil
+}
+
+// With returns the middleware verifying the requests with the Base options overridden by the non
+// zero fields of options, to pass to router.Use, router.With or in a router.Group. The context
+// key/value pairs are merged.
+func (m *Middleware) With(options recaptcha.VerifyOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middleware := *m.Base
+			middleware.Options = merge(middleware.Options, options)
+			middleware.Handler(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+func merge(base, route recaptcha.VerifyOption) recaptcha.VerifyOption {
+	if route.Threshold != 0 {
+		base.Threshold = route.Threshold
+	}
+	if route.Action != "" {
+		base.Action = route.Action
+	}
+	if route.Hostname != "" {
+		base.Hostname = route.Hostname
+	}
+	if len(route.Hostnames) > 0 {
+		base.Hostnames = route.Hostnames
+	}
+	if route.ApkPackageName != "" {
+		base.ApkPackageName = route.ApkPackageName
+	}
+	if route.ResponseTime != 0 {
+		base.ResponseTime = route.ResponseTime
+	}
+	if route.RemoteIP != "" {
+		base.RemoteIP = route.RemoteIP
+	}
+	if route.MinChallengeAge != 0 {
+		base.MinChallengeAge = route.MinChallengeAge
+	}
+	if route.MaxChallengeAge != 0 {
+		base.MaxChallengeAge = route.MaxChallengeAge
+	}
+	if route.CData != "" {
+		base.CData = route.CData
+	}
+	if route.IdempotencyKey != "" {
+		base.IdempotencyKey = route.IdempotencyKey
+	}
+	if route.Timeout != 0 {
+		base.Timeout = route.Timeout
+	}
+	if len(route.Context) > 0 {
+		context := make(map[string]string, len(base.Context)+len(route.Context))
+		for k, v := range base.Context {
+			context[k] = v
+		}
+		for k, v := range route.Context {
+			context[k] = v
+		}
+		base.Context = context
+	}
+	return base
+}
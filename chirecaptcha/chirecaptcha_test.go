@@ -0,0 +1,88 @@
+package chirecaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ChiSuite struct{}
+
+var _ = Suite(&ChiSuite{})
+
+// mockVerifier records the options of the verifications, failing the "invalid" challenge response.
+type mockVerifier struct {
+	options []recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = append(m.options, options)
+	if challengeResponse == "invalid" {
+		return recaptcha.Response{}, &recaptcha.Error{}
+	}
+	return recaptcha.Response{Success: true, Score: 0.9}, nil
+}
+
+func post(router http.Handler, path, challengeResponse string) int {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(url.Values{recaptcha.DefaultResponseField: {challengeResponse}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func (s *ChiSuite) TestNew(c *C) {
+	_, err := New(nil)
+	c.Check(err, ErrorMatches, "base middleware cannot be nil")
+}
+
+func (s *ChiSuite) TestWith(c *C) {
+	verifier := &mockVerifier{}
+	base := recaptcha.NewMiddleware(verifier, recaptcha.VerifyOption{Threshold: 0.5, Hostname: "example.com", Context: map[string]string{"app": "shop"}})
+	middleware, err := New(base)
+	c.Assert(err, IsNil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.With(recaptcha.VerifyOption{Action: "login", Threshold: 0.7, Context: map[string]string{"form": "login"}}))
+		r.Post("/login", ok)
+	})
+	router.With(middleware.With(recaptcha.VerifyOption{Action: "signup", Hostname: "accounts.example.com"})).Post("/signup", ok)
+	router.Post("/search", ok)
+
+	c.Check(post(router, "/login", "valid"), Equals, http.StatusOK)
+	c.Check(post(router, "/signup", "valid"), Equals, http.StatusOK)
+	c.Assert(verifier.options, HasLen, 2)
+	c.Check(verifier.options[0].Action, Equals, "login")
+	c.Check(verifier.options[0].Threshold, Equals, float32(0.7))
+	c.Check(verifier.options[0].Hostname, Equals, "example.com")
+	c.Check(verifier.options[0].Context, DeepEquals, map[string]string{"app": "shop", "form": "login"})
+	c.Check(verifier.options[1].Action, Equals, "signup")
+	c.Check(verifier.options[1].Threshold, Equals, float32(0.5))
+	c.Check(verifier.options[1].Hostname, Equals, "accounts.example.com")
+	c.Check(base.Options.Action, Equals, "")
+	c.Check(base.Options.Context, DeepEquals, map[string]string{"app": "shop"})
+
+	c.Check(post(router, "/login", "invalid"), Equals, http.StatusForbidden)
+	c.Check(post(router, "/search", "invalid"), Equals, http.StatusOK)
+	c.Check(verifier.options, HasLen, 3)
+}
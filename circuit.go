@@ -0,0 +1,128 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState state of a CircuitBreaker.
+type CircuitState int8
+
+const (
+	// CircuitClosed verification requests are sent.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen verification requests are short-circuited until the cooldown elapses.
+	CircuitOpen
+	// CircuitHalfOpen a single trial request is sent, closing the circuit when it succeeds.
+	CircuitHalfOpen
+)
+
+var circuitStates = [...]string{"closed", "open", "half-open"}
+
+func (s CircuitState) String() string {
+	if int(s) < 0 || int(s) >= len(circuitStates) {
+		return fmt.Sprintf("CircuitState(%d)", s)
+	}
+	return circuitStates[s]
+}
+
+// CircuitBreaker stops sending verification requests after consecutive request failures (connection
+// errors, timeouts, invalid responses), so requests fail fast with ErrCircuitOpen instead of waiting
+// for the Timeout while recaptcha is down. The action profile FailurePolicy applies to the
+// short-circuited verifications as to any request failure.
+type CircuitBreaker struct {
+	// Failures consecutive request failures opening the circuit.
+	Failures int
+	// Cooldown duration the circuit stays open before a trial request is let through.
+	Cooldown time.Duration
+	// OnStateChange when set is called on every state change, e.g. to log or alert.
+	OnStateChange func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+	now      func() time.Time
+}
+
+// NewCircuitBreaker new CircuitBreaker opening after failures consecutive request failures for cooldown
+func NewCircuitBreaker(failures int, cooldown time.Duration) (*CircuitBreaker, error) {
+	if failures <= 0 {
+		return nil, fmt.Errorf("circuit breaker failures must be positive")
+	}
+	if cooldown <= 0 {
+		return nil, fmt.Errorf("circuit breaker cooldown must be positive")
+	}
+	return &CircuitBreaker{Failures: failures, Cooldown: cooldown, now: time.Now}, nil
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow reports whether a request may be sent, moving an open circuit whose cooldown elapsed to
+// half-open for a single trial request.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.clock().Sub(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+		b.trial = true
+		return true
+	case CircuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// record records the outcome of an allowed request, requests canceled by the caller being ignored.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		b.failures = 0
+		b.setState(CircuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.Failures {
+		b.openedAt = b.clock()
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, state)
+	}
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
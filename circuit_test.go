@@ -0,0 +1,78 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type CircuitBreakerSuite struct{}
+
+var _ = Suite(&CircuitBreakerSuite{})
+
+func (s *CircuitBreakerSuite) TestNewCircuitBreaker(c *C) {
+	_, err := NewCircuitBreaker(0, time.Second)
+	c.Check(err, ErrorMatches, "circuit breaker failures must be positive")
+	_, err = NewCircuitBreaker(3, 0)
+	c.Check(err, ErrorMatches, "circuit breaker cooldown must be positive")
+}
+
+func (s *CircuitBreakerSuite) TestStates(c *C) {
+	now := time.Now()
+	breaker, err := NewCircuitBreaker(2, time.Minute)
+	c.Assert(err, IsNil)
+	breaker.now = func() time.Time { return now }
+	var changes []string
+	breaker.OnStateChange = func(from, to CircuitState) { changes = append(changes, from.String()+">"+to.String()) }
+	ctx := context.Background()
+	failure := fmt.Errorf("connection refused")
+
+	c.Check(breaker.allow(), Equals, true)
+	breaker.record(ctx, failure)
+	c.Check(breaker.State(), Equals, CircuitClosed)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	breaker.record(canceled, failure)
+	c.Check(breaker.State(), Equals, CircuitClosed)
+	breaker.record(ctx, failure)
+	c.Check(breaker.State(), Equals, CircuitOpen)
+	c.Check(breaker.allow(), Equals, false)
+
+	now = now.Add(time.Minute)
+	c.Check(breaker.allow(), Equals, true)
+	c.Check(breaker.State(), Equals, CircuitHalfOpen)
+	c.Check(breaker.allow(), Equals, false)
+	breaker.record(ctx, failure)
+	c.Check(breaker.State(), Equals, CircuitOpen)
+
+	now = now.Add(time.Minute)
+	c.Check(breaker.allow(), Equals, true)
+	breaker.record(ctx, nil)
+	c.Check(breaker.State(), Equals, CircuitClosed)
+	c.Check(changes, DeepEquals, []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"})
+}
+
+func (s *CircuitBreakerSuite) TestVerify(c *C) {
+	breaker, _ := NewCircuitBreaker(2, time.Minute)
+	captcha := ReCAPTCHA{client: &mockInvalidClient{}, Breaker: breaker}
+
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json.*")
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json.*")
+	err := captcha.Verify("token")
+	c.Check(err, ErrorMatches, "recaptcha circuit open, verification request short-circuited")
+	c.Check(errors.Is(err, ErrCircuitOpen), Equals, true)
+	var verr *Error
+	c.Assert(errors.As(err, &verr), Equals, true)
+	c.Check(verr.RequestError, Equals, true)
+
+	captcha.RegisterActionProfile("login", ActionProfile{FailurePolicy: FailOpen})
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Action: "login"}), IsNil)
+
+	captcha.client = &mockSuccessClientNoOptions{}
+	breaker.now = func() time.Time { return time.Now().Add(time.Minute) }
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(breaker.State(), Equals, CircuitClosed)
+}
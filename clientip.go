@@ -0,0 +1,177 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultClientIPHeaders forwarding headers read by ClientIPResolver when none are configured. The
+// RFC 7239 Forwarded header is left out: most proxies only append to X-Forwarded-For and pass the
+// Forwarded header of the clients through, so it is only read when listed in Headers.
+var DefaultClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// ClientIPResolver derives the client IP of requests going through reverse proxies or load
+// balancers, whose remote address is the one of the last proxy. The forwarding headers are only
+// trusted when set by TrustedProxies, clients can set them too.
+type ClientIPResolver struct {
+	// TrustedProxies networks of the proxies whose forwarding headers are trusted.
+	TrustedProxies []*net.IPNet
+	// Headers forwarding headers read in order, DefaultClientIPHeaders when empty, only list the
+	// headers the trusted proxies set or overwrite. The last addresses of the RFC 7239 Forwarded
+	// "for" parameters and of X-Forwarded-For, appended by the trusted proxies, are skipped, the
+	// first untrusted one being the client IP. An obfuscated identifier ("_hidden", "unknown") there
+	// moves on to the next header. The other headers hold a single address.
+	Headers []string
+}
+
+// NewClientIPResolver new ClientIPResolver trusting the forwarding headers set by trustedProxies,
+// CIDRs such as "10.0.0.0/8" or single addresses.
+func NewClientIPResolver(trustedProxies ...string) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{}
+	for _, proxy := range trustedProxies {
+		network, ok := parseNetwork(proxy)
+		if !ok {
+			return nil, fmt.Errorf("invalid trusted proxy '%s', while expecting an IP address or a CIDR", proxy)
+		}
+		resolver.TrustedProxies = append(resolver.TrustedProxies, network)
+	}
+	return resolver, nil
+}
+
+// ParseNetworks parses CIDRs such as "10.0.0.0/8" or single addresses, e.g. for BypassNetworks.
+func ParseNetworks(networks ...string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, value := range networks {
+		network, ok := parseNetwork(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid network '%s', while expecting an IP address or a CIDR", value)
+		}
+		parsed = append(parsed, network)
+	}
+	return parsed, nil
+}
+
+// parseNetwork parses a CIDR, a single address being a network of its own.
+func parseNetwork(value string) (*net.IPNet, bool) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, false
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, true
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err == nil
+}
+
+// containingNetwork returns the first of networks containing address, nil when none does.
+func containingNetwork(networks []*net.IPNet, address string) *net.IPNet {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return network
+		}
+	}
+	return nil
+}
+
+// ClientIP returns the client IP of the request, its remote address when it doesn't come from a
+// trusted proxy or the forwarding headers are missing. A nil resolver returns the remote address.
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	peer := remoteIP(r)
+	if c == nil || !c.trusted(peer) {
+		return peer
+	}
+	headers := c.Headers
+	if len(headers) == 0 {
+		headers = DefaultClientIPHeaders
+	}
+	for _, header := range headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		switch http.CanonicalHeaderKey(header) {
+		case "Forwarded":
+			if ip := c.forwardedFor(forwardedNodes(values)); ip != "" {
+				return ip
+			}
+			continue
+		case "X-Forwarded-For":
+			if ip := c.forwardedFor(strings.Split(strings.Join(values, ","), ",")); ip != "" {
+				return ip
+			}
+			continue
+		}
+		if ip := parseIP(values[len(values)-1]); ip != "" {
+			return ip
+		}
+	}
+	return peer
+}
+
+// forwardedFor returns the rightmost address not belonging to a trusted proxy, or the leftmost one
+// when they all do.
+func (c *ClientIPResolver) forwardedFor(addresses []string) string {
+	var ip string
+	for i := len(addresses) - 1; i >= 0; i-- {
+		if ip = parseIP(addresses[i]); ip == "" {
+			// a malformed address can't be trusted further
+			return ""
+		}
+		if !c.trusted(ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+func (c *ClientIPResolver) trusted(address string) bool {
+	return containingNetwork(c.TrustedProxies, address) != nil
+}
+
+// forwardedNodes returns the addresses of the "for" parameters of the RFC 7239 Forwarded header
+// values, in order, without their port. The elements without "for" are skipped.
+func forwardedNodes(values []string) []string {
+	var nodes []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, node, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(name, "for") {
+					continue
+				}
+				node = strings.Trim(node, `"`)
+				if strings.HasPrefix(node, "[") {
+					// IPv6, optionally followed by a port
+					node = strings.TrimPrefix(node, "[")
+					if end := strings.Index(node, "]"); end >= 0 {
+						node = node[:end]
+					}
+				} else if host, _, err := net.SplitHostPort(node); err == nil {
+					node = host
+				}
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
+
+// parseIP returns the normalized IP address of s, blank when invalid.
+func parseIP(s string) string {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
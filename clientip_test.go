@@ -0,0 +1,68 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+type ClientIPSuite struct{}
+
+var _ = Suite(&ClientIPSuite{})
+
+func (s *ClientIPSuite) TestClientIP(c *C) {
+	_, err := NewClientIPResolver("10.0.0.0/33")
+	c.Check(err, ErrorMatches, "invalid trusted proxy '10.0.0.0/33', while expecting an IP address or a CIDR")
+
+	resolver, err := NewClientIPResolver("10.0.0.0/8", "192.168.1.1", "fd00::/8")
+	c.Assert(err, IsNil)
+	request := func(remoteAddr string, headers ...string) *http.Request {
+		r := httptest.NewRequest("POST", "/login", nil)
+		r.RemoteAddr = remoteAddr
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Add(headers[i], headers[i+1])
+		}
+		return r
+	}
+
+	// headers of untrusted peers are ignored
+	c.Check(resolver.ClientIP(request("203.0.113.9:1234", "X-Forwarded-For", "198.51.100.1")), Equals, "203.0.113.9")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234")), Equals, "10.1.2.3")
+	// the addresses appended by trusted proxies are skipped, not the spoofed ones
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "1.2.3.4, 198.51.100.1, 192.168.1.1")), Equals, "198.51.100.1")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "1.2.3.4", "X-Forwarded-For", "198.51.100.1")), Equals, "198.51.100.1")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "10.0.0.2, 10.0.0.3")), Equals, "10.0.0.2")
+	c.Check(resolver.ClientIP(request("[fd00::1]:1234", "X-Forwarded-For", "2001:db8::1")), Equals, "2001:db8::1")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Real-IP", "198.51.100.2")), Equals, "198.51.100.2")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "garbage", "X-Real-IP", "198.51.100.2")), Equals, "198.51.100.2")
+
+	// the Forwarded header of the clients, passed through by the proxies only appending to
+	// X-Forwarded-For, is ignored by default
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "Forwarded", "for=192.168.1.9", "X-Forwarded-For", "198.51.100.1")), Equals, "198.51.100.1")
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "Forwarded", "for=198.51.100.9")), Equals, "10.1.2.3")
+
+	resolver.Headers = []string{"CF-Connecting-IP"}
+	c.Check(resolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "198.51.100.1", "CF-Connecting-IP", "198.51.100.3")), Equals, "198.51.100.3")
+
+	var nilResolver *ClientIPResolver
+	c.Check(nilResolver.ClientIP(request("10.1.2.3:1234", "X-Forwarded-For", "198.51.100.1")), Equals, "10.1.2.3")
+}
+
+func (s *ClientIPSuite) TestForwarded(c *C) {
+	c.Check(forwardedNodes([]string{`for=192.0.2.60;proto=http;by=203.0.113.43`, `For="[2001:db8:cafe::17]:4711", for=198.51.100.7:8080`, `proto=https`, `for=_hidden, for=unknown`}), DeepEquals,
+		[]string{"192.0.2.60", "2001:db8:cafe::17", "198.51.100.7", "_hidden", "unknown"})
+
+	resolver, err := NewClientIPResolver("10.0.0.0/8")
+	c.Assert(err, IsNil)
+	resolver.Headers = []string{"Forwarded", "X-Forwarded-For"}
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.RemoteAddr = "10.1.2.3:1234"
+	r.Header.Set("Forwarded", `for=1.2.3.4, for="[2001:db8::1]:4711";proto=https, for=10.0.0.7`)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	c.Check(resolver.ClientIP(r), Equals, "2001:db8::1")
+
+	// an obfuscated client moves on to the next header
+	r.Header.Set("Forwarded", `for=_hidden;by=10.0.0.7`)
+	c.Check(resolver.ClientIP(r), Equals, "198.51.100.1")
+}
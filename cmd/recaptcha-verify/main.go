@@ -0,0 +1,129 @@
+// Command recaptcha-verify verifies a challenge response and prints the verification result as JSON,
+// e.g. to debug tokens captured from frontends or in shell-based smoke tests.
+//
+// Usage:
+//
+//	recaptcha-verify [flags] [token]
+//
+// The token is read from stdin when not passed as argument, the secret from the RECAPTCHA_SECRET
+// environment variable when the -secret flag isn't set. The exit code is 0 when the verification
+// succeeded, 1 when it failed and 2 on invalid usage.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// result verification result printed as JSON.
+type result struct {
+	Success    bool                `json:"success"`
+	Error      string              `json:"error,omitempty"`
+	Reason     string              `json:"reason,omitempty"`
+	ErrorCodes []string            `json:"error_codes,omitempty"`
+	Response   *recaptcha.Response `json:"response,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run verifies the token passed in args or stdin and returns the exit code.
+func run(args []string, getenv func(string) string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("recaptcha-verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	secret := flags.String("secret", "", "recaptcha secret, RECAPTCHA_SECRET when blank")
+	version := flags.String("version", "v3", "recaptcha version, v2 or v3")
+	provider := flags.String("provider", "recaptcha", "name of the captcha provider")
+	endpoint := flags.String("endpoint", "", "verification endpoint, the provider one when blank")
+	timeout := flags.Duration("timeout", 10*time.Second, "verification request timeout")
+	var options recaptcha.VerifyOption
+	flags.StringVar(&options.Action, "action", "", "expected V3 action")
+	flags.StringVar(&options.Hostname, "hostname", "", "expected hostname")
+	flags.StringVar(&options.ApkPackageName, "apk-package-name", "", "expected Android package name")
+	flags.StringVar(&options.RemoteIP, "remote-ip", "", "IP address of the client")
+	threshold := flags.Float64("threshold", 0, "minimum V3 score, 0.5 when zero")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	options.Threshold = float32(*threshold)
+
+	if *secret == "" {
+		*secret = getenv("RECAPTCHA_SECRET")
+	}
+	token, err := readToken(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	var v recaptcha.VERSION
+	switch *version {
+	case "v2":
+		v = recaptcha.V2
+	case "v3":
+		v = recaptcha.V3
+	default:
+		fmt.Fprintf(stderr, "invalid version '%s', while expecting 'v2' or 'v3'\n", *version)
+		return 2
+	}
+	captcha, err := recaptcha.NewReCAPTCHAWithProviderName(*secret, v, *timeout, *provider)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *endpoint != "" {
+		captcha.ReCAPTCHALink = *endpoint
+	}
+
+	response, err := captcha.VerifyWithOptionsResponse(token, options)
+	out := result{Success: err == nil}
+	if err != nil {
+		out.Error = err.Error()
+		out.Reason = recaptcha.ErrorReason(err)
+		var recaptchaErr *recaptcha.Error
+		if errors.As(err, &recaptchaErr) {
+			out.ErrorCodes = recaptchaErr.ErrorCodes
+		}
+	}
+	if err == nil || !errors.Is(err, recaptcha.ErrRequestFailed) {
+		out.Response = &response
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if !out.Success {
+		return 1
+	}
+	return 0
+}
+
+// readToken returns the token passed as argument, or read from stdin when none.
+func readToken(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("too many arguments, while expecting a single token")
+	}
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	b, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the token from stdin: '%s'", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token cannot be blank")
+	}
+	return token, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type VerifySuite struct{}
+
+var _ = Suite(&VerifySuite{})
+
+func (s *VerifySuite) TestRun(c *C) {
+	var form []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form = []string{r.FormValue("secret"), r.FormValue("response"), r.FormValue("remoteip")}
+		if r.FormValue("response") != "valid" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "score": 0.7, "action": "login", "hostname": "example.com"}`))
+	}))
+	defer server.Close()
+	getenv := func(name string) string {
+		if name == "RECAPTCHA_SECRET" {
+			return "env-secret"
+		}
+		return ""
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-endpoint", server.URL, "-action", "login", "-remote-ip", "10.0.0.1", "valid"}, getenv, strings.NewReader(""), &stdout, &stderr)
+	c.Check(code, Equals, 0)
+	c.Check(form, DeepEquals, []string{"env-secret", "valid", "10.0.0.1"})
+	var out result
+	c.Assert(json.Unmarshal(stdout.Bytes(), &out), IsNil)
+	c.Check(out.Success, Equals, true)
+	c.Check(out.Response.Score, Equals, float32(0.7))
+
+	stdout.Reset()
+	code = run([]string{"-endpoint", server.URL, "-version", "v2", "-secret", "flag-secret"}, getenv, strings.NewReader("invalid\n"), &stdout, &stderr)
+	c.Check(code, Equals, 1)
+	c.Check(form[:2], DeepEquals, []string{"flag-secret", "invalid"})
+	out = result{}
+	c.Assert(json.Unmarshal(stdout.Bytes(), &out), IsNil)
+	c.Check(out.Success, Equals, false)
+	c.Check(out.Reason, Equals, "remote_errors")
+	c.Check(out.ErrorCodes, DeepEquals, []string{"invalid-input-response"})
+
+	c.Check(run([]string{"-version", "v4", "valid"}, getenv, nil, &stdout, &stderr), Equals, 2)
+	c.Check(run([]string{"valid"}, func(string) string { return "" }, nil, &stdout, &stderr), Equals, 2)
+	c.Check(run(nil, getenv, strings.NewReader(" \n"), &stdout, &stderr), Equals, 2)
+	c.Check(stderr.String(), Matches, `(?s).*recaptcha secret cannot be blank\ntoken cannot be blank\n`)
+}
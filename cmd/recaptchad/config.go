@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// duration time.Duration decoded from JSON strings such as "10s"
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, while expecting a string such as \"10s\"", b)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+type policyConfig struct {
+	ChallengeBelow  float32 `json:"challenge_below"`
+	BlockBelow      float32 `json:"block_below"`
+	QuarantineBelow float32 `json:"quarantine_below"`
+}
+
+// config daemon configuration, loaded from a JSON file and overridden by environment variables.
+type config struct {
+	Listen string `json:"listen"`
+	// Secret recaptcha secret, better set with the RECAPTCHA_SECRET environment variable.
+	Secret  string `json:"secret"`
+	Version string `json:"version"`
+	// Provider name of the registered captcha provider, recaptcha when blank.
+	Provider string   `json:"provider"`
+	Timeout  duration `json:"timeout"`
+	// Threshold, Hostname and ApkPackageName default verification options, requests may override them.
+	Threshold      float32 `json:"threshold"`
+	Hostname       string  `json:"hostname"`
+	ApkPackageName string  `json:"apk_package_name"`
+	// Policy maps results to decisions, failed verifications are blocked when unset.
+	Policy *policyConfig `json:"policy"`
+	// ShutdownTimeout time given to in-flight requests on shutdown.
+	ShutdownTimeout duration `json:"shutdown_timeout"`
+}
+
+var envOverrides = map[string]func(c *config, value string){
+	"RECAPTCHA_SECRET":   func(c *config, value string) { c.Secret = value },
+	"RECAPTCHA_LISTEN":   func(c *config, value string) { c.Listen = value },
+	"RECAPTCHA_VERSION":  func(c *config, value string) { c.Version = value },
+	"RECAPTCHA_PROVIDER": func(c *config, value string) { c.Provider = value },
+}
+
+// loadConfig loads the configuration file at path, if not blank, then applies the environment overrides.
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	c := config{Listen: ":8080", Version: "v3", Timeout: duration(10 * time.Second), ShutdownTimeout: duration(10 * time.Second)}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return config{}, err
+		}
+		if err := json.Unmarshal(b, &c); err != nil {
+			return config{}, fmt.Errorf("invalid config file '%s': '%s'", path, err)
+		}
+	}
+	for name, override := range envOverrides {
+		if value := getenv(name); value != "" {
+			override(&c, value)
+		}
+	}
+	if _, err := c.version(); err != nil {
+		return config{}, err
+	}
+	if _, ok := recaptcha.LookupProvider(c.provider()); !ok {
+		return config{}, fmt.Errorf("unknown captcha provider '%s', while expecting one of %v", c.Provider, recaptcha.ProviderNames())
+	}
+	if c.Secret == "" {
+		return config{}, fmt.Errorf("recaptcha secret cannot be blank, set RECAPTCHA_SECRET")
+	}
+	return c, nil
+}
+
+func (c config) version() (recaptcha.VERSION, error) {
+	switch c.Version {
+	case "v2":
+		return recaptcha.V2, nil
+	case "v3":
+		return recaptcha.V3, nil
+	}
+	return recaptcha.V3, fmt.Errorf("invalid version '%s', while expecting 'v2' or 'v3'", c.Version)
+}
+
+func (c config) provider() string {
+	if c.Provider == "" {
+		return "recaptcha"
+	}
+	return c.Provider
+}
+
+func (c config) options() recaptcha.VerifyOption {
+	return recaptcha.VerifyOption{Threshold: c.Threshold, Hostname: c.Hostname, ApkPackageName: c.ApkPackageName}
+}
+
+func (c config) policy() *recaptcha.Policy {
+	if c.Policy == nil {
+		return nil
+	}
+	return &recaptcha.Policy{ChallengeBelow: c.Policy.ChallengeBelow, BlockBelow: c.Policy.BlockBelow, QuarantineBelow: c.Policy.QuarantineBelow}
+}
@@ -0,0 +1,64 @@
+// Command recaptchad runs a recaptcha verification service, so applications verify challenge
+// responses through a REST API instead of each holding the recaptcha secret.
+//
+// Endpoints:
+//
+//	POST /verify   verifies {"response": "...", "action": "...", "remote_ip": "..."}
+//	GET  /healthz  liveness probe
+//	GET  /readyz   readiness probe, failing while shutting down
+//	GET  /metrics  Prometheus metrics
+//
+// The configuration is read from the JSON file passed with -config, RECAPTCHA_SECRET,
+// RECAPTCHA_LISTEN, RECAPTCHA_VERSION and RECAPTCHA_PROVIDER environment variables override it.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path of the JSON configuration file")
+	flag.Parse()
+
+	c, err := loadConfig(*configPath, os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	version, _ := c.version()
+	captcha, err := recaptcha.NewReCAPTCHAWithProviderName(c.Secret, version, time.Duration(c.Timeout), c.provider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := newServer(&captcha, c.options(), c.policy())
+	httpServer := &http.Server{Addr: c.Listen, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		atomic.StoreInt32(&s.draining, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.ShutdownTimeout))
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("shutdown: %s", err)
+		}
+	}()
+
+	log.Printf("recaptchad listening on %s", c.Listen)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-drained
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+	"gopkg.in/ezzarghili/recaptcha-go.v4/recaptchaclient"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type RecaptchadSuite struct{}
+
+var _ = Suite(&RecaptchadSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct {
+	options recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = options
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6, Action: options.Action}, nil
+}
+
+func noEnv(string) string { return "" }
+
+func (s *RecaptchadSuite) TestLoadConfig(c *C) {
+	path := filepath.Join(c.MkDir(), "recaptchad.json")
+	c.Assert(ioutil.WriteFile(path, []byte(`{"listen": ":9090", "version": "v2", "timeout": "3s", "hostname": "example.com", "policy": {"challenge_below": 0.7, "block_below": 0.3}}`), 0600), IsNil)
+
+	_, err := loadConfig(path, noEnv)
+	c.Check(err, ErrorMatches, "recaptcha secret cannot be blank, set RECAPTCHA_SECRET")
+
+	conf, err := loadConfig(path, func(name string) string {
+		return map[string]string{"RECAPTCHA_SECRET": "secret", "RECAPTCHA_LISTEN": ":7070"}[name]
+	})
+	c.Assert(err, IsNil)
+	c.Check(conf.Listen, Equals, ":7070")
+	c.Check(conf.Secret, Equals, "secret")
+	c.Check(time.Duration(conf.Timeout), Equals, 3*time.Second)
+	c.Check(conf.options().Hostname, Equals, "example.com")
+	c.Check(*conf.policy(), DeepEquals, recaptcha.Policy{ChallengeBelow: 0.7, BlockBelow: 0.3})
+	version, _ := conf.version()
+	c.Check(version, Equals, recaptcha.V2)
+
+	c.Assert(ioutil.WriteFile(path, []byte(`{"secret": "secret", "version": "v4"}`), 0600), IsNil)
+	_, err = loadConfig(path, noEnv)
+	c.Check(err, ErrorMatches, "invalid version 'v4', while expecting 'v2' or 'v3'")
+
+	c.Assert(ioutil.WriteFile(path, []byte(`{"secret": "secret", "provider": "turnstile"}`), 0600), IsNil)
+	conf, err = loadConfig(path, noEnv)
+	c.Assert(err, IsNil)
+	c.Check(conf.provider(), Equals, "turnstile")
+	_, err = loadConfig(path, func(name string) string {
+		return map[string]string{"RECAPTCHA_PROVIDER": "other"}[name]
+	})
+	c.Check(err, ErrorMatches, "unknown captcha provider 'other', while expecting one of \\[hcaptcha recaptcha turnstile\\]")
+
+	c.Assert(ioutil.WriteFile(path, []byte(`{"secret": "secret", "timeout": 3}`), 0600), IsNil)
+	_, err = loadConfig(path, noEnv)
+	c.Check(err, ErrorMatches, "invalid config file .*invalid duration 3.*")
+
+	_, err = loadConfig(filepath.Join(c.MkDir(), "missing.json"), noEnv)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+func (s *RecaptchadSuite) TestVerify(c *C) {
+	verifier := &mockVerifier{}
+	handler := newServer(verifier, recaptcha.VerifyOption{Threshold: 0.5, Hostname: "example.com"}, nil).handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"response": "valid", "action": "login", "remote_ip": "10.0.0.1"}`)))
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	var body recaptchaclient.VerifyResponse
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), IsNil)
+	c.Check(body.Success, Equals, true)
+	c.Check(body.Decision, Equals, "allow")
+	c.Check(body.Result.Action, Equals, "login")
+	c.Check(verifier.options, DeepEquals, recaptcha.VerifyOption{Threshold: 0.5, Hostname: "example.com", Action: "login", RemoteIP: "10.0.0.1"})
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"response": "invalid"}`)))
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), IsNil)
+	c.Check(body.Success, Equals, false)
+	c.Check(body.Decision, Equals, "block")
+	c.Check(body.Error, Equals, "remote error codes: [invalid-input-response]")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{}`)))
+	c.Check(rec.Code, Equals, http.StatusBadRequest)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/verify", nil))
+	c.Check(rec.Code, Equals, http.StatusMethodNotAllowed)
+}
+
+func (s *RecaptchadSuite) TestPolicy(c *C) {
+	handler := newServer(&mockVerifier{}, recaptcha.VerifyOption{}, &recaptcha.Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}).handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"response": "valid"}`)))
+	var body recaptchaclient.VerifyResponse
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), IsNil)
+	c.Check(body.Decision, Equals, "challenge")
+	c.Check(body.Reasons, DeepEquals, []string{"score '0.600000' below challenge threshold '0.700000'"})
+}
+
+func (s *RecaptchadSuite) TestHealthAndMetrics(c *C) {
+	srv := newServer(&mockVerifier{}, recaptcha.VerifyOption{}, nil)
+	handler := srv.handler()
+	for _, response := range []string{"valid", "valid", "invalid"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"response": "`+response+`"}`)))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	c.Check(rec.Body.String(), Matches, `(?s).*recaptchad_verifications_total\{decision="allow"\} 2\nrecaptchad_verifications_total\{decision="block"\} 1\n.*recaptchad_verification_duration_seconds_count 3\n`)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	c.Check(rec.Code, Equals, http.StatusOK)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	c.Check(rec.Code, Equals, http.StatusOK)
+	srv.draining = 1
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	c.Check(rec.Code, Equals, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+	"gopkg.in/ezzarghili/recaptcha-go.v4/recaptchaclient"
+)
+
+type server struct {
+	verifier recaptcha.Verifier
+	options  recaptcha.VerifyOption
+	policy   *recaptcha.Policy
+	metrics  *metrics
+	// draining set on shutdown so load balancers stop routing requests.
+	draining int32
+}
+
+func newServer(verifier recaptcha.Verifier, options recaptcha.VerifyOption, policy *recaptcha.Policy) *server {
+	return &server{verifier: verifier, options: options, policy: policy, metrics: newMetrics()}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/verify", s.verify)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { fmt.Fprintln(w, "ok") })
+	mux.HandleFunc("/readyz", s.ready)
+	mux.Handle("/metrics", s.metrics)
+	return mux
+}
+
+func (s *server) verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	var req recaptchaclient.VerifyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body json: '%s'", err), http.StatusBadRequest)
+		return
+	}
+	if req.Response == "" {
+		http.Error(w, "challenge response cannot be blank", http.StatusBadRequest)
+		return
+	}
+
+	options := s.options
+	options.Action, options.RemoteIP, options.Context = req.Action, req.RemoteIP, req.Context
+	if req.Threshold != 0 {
+		options.Threshold = req.Threshold
+	}
+	if req.Hostname != "" {
+		options.Hostname = req.Hostname
+	}
+	if req.ApkPackageName != "" {
+		options.ApkPackageName = req.ApkPackageName
+	}
+
+	start := time.Now()
+	response, err := recaptcha.VerifyContext(r.Context(), s.verifier, req.Response, options)
+	result := recaptcha.VerifyResult{Response: response, Err: err}
+	outcome := recaptcha.DecideWith(r.Context(), nil, s.policy, result, options)
+	s.metrics.observe(outcome.Decision, time.Since(start))
+
+	body := recaptchaclient.VerifyResponse{Success: err == nil, Decision: outcome.Decision.String(), Reasons: outcome.Reasons, Result: response}
+	if err != nil {
+		body.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *server) ready(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.draining) != 0 {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// metrics verification counters and latency exposed in the Prometheus text format.
+type metrics struct {
+	mu        sync.Mutex
+	decisions map[recaptcha.Decision]uint64
+	count     uint64
+	seconds   float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{decisions: map[recaptcha.Decision]uint64{}}
+}
+
+func (m *metrics) observe(decision recaptcha.Decision, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.decisions[decision]++
+	m.count++
+	m.seconds += elapsed.Seconds()
+}
+
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	lines := make([]string, 0, len(m.decisions))
+	for decision, count := range m.decisions {
+		lines = append(lines, fmt.Sprintf("recaptchad_verifications_total{decision=%q} %d", decision.String(), count))
+	}
+	count, seconds := m.count, m.seconds
+	m.mu.Unlock()
+	sort.Strings(lines)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP recaptchad_verifications_total Verifications by decision.")
+	fmt.Fprintln(w, "# TYPE recaptchad_verifications_total counter")
+	if len(lines) > 0 {
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+	}
+	fmt.Fprintln(w, "# HELP recaptchad_verification_duration_seconds Time spent verifying challenge responses.")
+	fmt.Fprintln(w, "# TYPE recaptchad_verification_duration_seconds summary")
+	fmt.Fprintf(w, "recaptchad_verification_duration_seconds_sum %g\n", seconds)
+	fmt.Fprintf(w, "recaptchad_verification_duration_seconds_count %d\n", count)
+}
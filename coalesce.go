@@ -0,0 +1,66 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// flightCall verification request shared by the concurrent verifications of a challenge response.
+type flightCall struct {
+	done   chan struct{}
+	result Response
+	body   []byte
+	err    error
+}
+
+// flightGroup coalesces the concurrent verification requests of the same challenge response.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flights shared by every ReCAPTCHA, the keys including the endpoint and the secret.
+var flights = flightGroup{calls: map[string]*flightCall{}}
+
+// do calls fetch once for the concurrent calls with the same key, every caller waiting for its result
+// until its own context is done. fetch runs with a context whose cancellation is ignored, so a caller
+// giving up doesn't fail the others.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(ctx context.Context) (Response, []byte, error)) (Response, []byte, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go func() {
+			call.result, call.body, call.err = fetch(context.WithoutCancel(ctx))
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result, call.body, call.err
+	case <-ctx.Done():
+		return Response{}, nil, &Error{
+			msg:          fmt.Sprintf("error posting to recaptcha endpoint: '%s'", ctx.Err()),
+			RequestError: true,
+			kind:         kindRequestFailed,
+		}
+	}
+}
+
+// fetchCoalesced same as fetch but shares the verification request with the concurrent verifications
+// of the same challenge response, see Coalesce.
+func (r *ReCAPTCHA) fetchCoalesced(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, []byte, error) {
+	// the request fields sent by the providers, the other options are only checked on the response
+	key := strings.Join([]string{r.ReCAPTCHALink, recaptcha.Secret, recaptcha.Response, recaptcha.RemoteIP, options.IdempotencyKey}, "\x00")
+	return flights.do(ctx, key, func(ctx context.Context) (Response, []byte, error) {
+		return r.fetchOnce(ctx, recaptcha, options)
+	})
+}
@@ -0,0 +1,75 @@
+package recaptcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type CoalesceSuite struct{}
+
+var _ = Suite(&CoalesceSuite{})
+
+func (s *CoalesceSuite) TestCoalesce(c *C) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 {
+			w.Write([]byte(`{"success": false, "error-codes": ["timeout-or-duplicate"]}`))
+			return
+		}
+		<-release
+		w.Write([]byte(`{"success": true, "score": 0.6, "action": "login"}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("my secret", V3, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	captcha.Coalesce = true
+
+	// a caller giving up doesn't fail the others
+	canceled, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, options := range []VerifyOption{{Action: "login"}, {Action: "login", Threshold: 0.7}, {Action: "login"}} {
+		ctx := context.Background()
+		if i == 2 {
+			ctx = canceled
+		}
+		wg.Add(1)
+		go func(i int, ctx context.Context, options VerifyOption) {
+			defer wg.Done()
+			errs[i] = captcha.VerifyWithOptionsContext(ctx, "mycode", options)
+		}(i, ctx, options)
+	}
+	for {
+		flights.mu.Lock()
+		pending := len(flights.calls)
+		flights.mu.Unlock()
+		if pending > 0 && atomic.LoadInt32(&requests) == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	c.Check(atomic.LoadInt32(&requests), Equals, int32(1))
+	c.Check(errs[0], IsNil)
+	c.Check(errs[1], ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+	c.Check(errs[2], ErrorMatches, "error posting to recaptcha endpoint: 'context canceled'")
+
+	// sequential verifications aren't coalesced
+	c.Check(captcha.Verify("mycode"), NotNil)
+	c.Check(atomic.LoadInt32(&requests), Equals, int32(2))
+	c.Check(flights.calls, HasLen, 0)
+}
@@ -0,0 +1,142 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Decision tri-state outcome of a verification
+type Decision int8
+
+const (
+	// Allow let the request through.
+	Allow Decision = iota
+	// Challenge ask the client for additional proof, e.g. a V2 challenge.
+	Challenge
+	// Block reject the request.
+	Block
+	// Quarantine let the request through but flag it for downstream review, e.g. manual moderation
+	// or delayed publishing.
+	Quarantine
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Challenge:
+		return "challenge"
+	case Block:
+		return "block"
+	case Quarantine:
+		return "quarantine"
+	}
+	return fmt.Sprintf("Decision(%d)", int8(d))
+}
+
+// ParseDecision returns the decision named s, as formatted by Decision.String.
+func ParseDecision(s string) (Decision, error) {
+	for _, d := range []Decision{Allow, Challenge, Block, Quarantine} {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return Block, fmt.Errorf("unknown decision '%s'", s)
+}
+
+// VerifyResult outcome of a single verification, the decoded response and the verification error if any.
+type VerifyResult struct {
+	Response Response
+	Err      error
+	// Skipped the request was let through without verification, see ReCAPTCHA.BypassNetworks and
+	// ReCAPTCHA.WithSkipFunc. Skipped results are allowed.
+	Skipped bool
+}
+
+// Policy maps V3 scores to decisions, verification failures other than low scores are always blocked.
+type Policy struct {
+	// ChallengeBelow scores strictly below are challenged.
+	ChallengeBelow float32
+	// BlockBelow scores strictly below are blocked, must not exceed ChallengeBelow.
+	BlockBelow float32
+	// QuarantineBelow scores strictly below that are neither blocked nor challenged are quarantined,
+	// must not be lower than ChallengeBelow.
+	QuarantineBelow float32
+}
+
+// Outcome decision taken for a verification result along with the reasons explaining it.
+type Outcome struct {
+	Decision Decision
+	Reasons  []string
+}
+
+// Decide maps a verification result to a decision according to the policy. Scores between BlockBelow
+// and ChallengeBelow, as well as scores rejected by the verifier threshold but not below BlockBelow,
+// are challenged.
+func Decide(result VerifyResult, policy Policy) Outcome {
+	if result.Skipped {
+		return Outcome{Decision: Allow, Reasons: []string{"verification skipped"}}
+	}
+	if result.Err != nil {
+		var recaptchaErr *Error
+		if !errors.As(result.Err, &recaptchaErr) || recaptchaErr.kind != kindLowScore {
+			return Outcome{Decision: Block, Reasons: []string{result.Err.Error()}}
+		}
+	}
+
+	score := result.Response.Score
+	switch {
+	case score < policy.BlockBelow:
+		return Outcome{Decision: Block, Reasons: []string{fmt.Sprintf("score '%f' below block threshold '%f'", score, policy.BlockBelow)}}
+	case score < policy.ChallengeBelow:
+		return Outcome{Decision: Challenge, Reasons: []string{fmt.Sprintf("score '%f' below challenge threshold '%f'", score, policy.ChallengeBelow)}}
+	case result.Err != nil:
+		return Outcome{Decision: Challenge, Reasons: []string{result.Err.Error()}}
+	case score < policy.QuarantineBelow:
+		return Outcome{Decision: Quarantine, Reasons: []string{fmt.Sprintf("score '%f' below quarantine threshold '%f'", score, policy.QuarantineBelow)}}
+	}
+	return Outcome{Decision: Allow}
+}
+
+// Decider decision backend mapping verification results and request metadata to outcomes, e.g. an
+// external policy engine. Decider errors are treated as Block decisions.
+type Decider interface {
+	Decide(ctx context.Context, result VerifyResult, options VerifyOption) (Outcome, error)
+}
+
+// DecideWith takes the decision with the decider when set, otherwise with the policy when set,
+// failed verifications being blocked when neither is.
+func DecideWith(ctx context.Context, decider Decider, policy *Policy, result VerifyResult, options VerifyOption) Outcome {
+	if decider == nil {
+		return decide(policy, result)
+	}
+	outcome, err := decider.Decide(ctx, result, options)
+	if err != nil {
+		return Outcome{Decision: Block, Reasons: []string{fmt.Sprintf("decider failed: '%s'", err)}}
+	}
+	return outcome
+}
+
+// decide applies the policy when set, otherwise failed verifications are blocked.
+func decide(policy *Policy, result VerifyResult) Outcome {
+	if policy != nil {
+		return Decide(result, *policy)
+	}
+	if result.Err != nil {
+		return Outcome{Decision: Block, Reasons: []string{result.Err.Error()}}
+	}
+	return Outcome{Decision: Allow}
+}
+
+// VerifyDecision verifies the challenge response and maps the result to a decision with the Policy
+// of the action profile, otherwise with the ReCAPTCHA Policy, failed verifications being blocked
+// when neither is set.
+func (r *ReCAPTCHA) VerifyDecision(ctx context.Context, challengeResponse string, options VerifyOption) (Outcome, Response) {
+	response, err := r.VerifyWithOptionsResponseContext(ctx, challengeResponse, options)
+	policy := r.Policy
+	if profile, ok := r.lookupProfile(options.Action); ok && profile.Policy != nil {
+		policy = profile.Policy
+	}
+	return decide(policy, VerifyResult{Response: response, Err: err}), response
+}
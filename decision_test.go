@@ -0,0 +1,202 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "gopkg.in/check.v1"
+)
+
+type DecisionSuite struct{}
+
+var _ = Suite(&DecisionSuite{})
+
+// mockResponseVerifier returns the same response and error for every verification.
+type mockResponseVerifier struct {
+	response Response
+	err      error
+}
+
+func (m *mockResponseVerifier) Verify(challengeResponse string) error {
+	return m.err
+}
+
+func (m *mockResponseVerifier) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	return m.err
+}
+
+func (m *mockResponseVerifier) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	return m.response, m.err
+}
+
+func (s *DecisionSuite) TestDecide(c *C) {
+	policy := Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+
+	outcome := Decide(VerifyResult{Response: Response{Success: true, Score: 0.9}}, policy)
+	c.Check(outcome, DeepEquals, Outcome{Decision: Allow})
+
+	outcome = Decide(VerifyResult{Response: Response{Success: true, Score: 0.5}}, policy)
+	c.Check(outcome.Decision, Equals, Challenge)
+	c.Check(outcome.Reasons, DeepEquals, []string{"score '0.500000' below challenge threshold '0.700000'"})
+
+	lowScore := &Error{msg: "received score '0.200000', while expecting minimum '0.500000'", kind: kindLowScore}
+	outcome = Decide(VerifyResult{Response: Response{Success: true, Score: 0.2}, Err: lowScore}, policy)
+	c.Check(outcome.Decision, Equals, Block)
+	c.Check(outcome.Reasons, DeepEquals, []string{"score '0.200000' below block threshold '0.300000'"})
+
+	outcome = Decide(VerifyResult{Response: Response{Success: true, Score: 0.8}, Err: lowScore}, policy)
+	c.Check(outcome.Decision, Equals, Challenge)
+	// wrapping verifiers keep the low scores challenged
+	outcome = Decide(VerifyResult{Response: Response{Success: true, Score: 0.8}, Err: fmt.Errorf("wrapped: %w", lowScore)}, policy)
+	c.Check(outcome.Decision, Equals, Challenge)
+
+	outcome = Decide(VerifyResult{Response: Response{Score: 0.9}, Err: &Error{msg: "invalid challenge solution"}}, policy)
+	c.Check(outcome, DeepEquals, Outcome{Decision: Block, Reasons: []string{"invalid challenge solution"}})
+}
+
+func (s *DecisionSuite) TestDecisionString(c *C) {
+	c.Check(Allow.String(), Equals, "allow")
+	c.Check(Challenge.String(), Equals, "challenge")
+	c.Check(Block.String(), Equals, "block")
+	c.Check(Decision(7).String(), Equals, "Decision(7)")
+}
+
+func (s *DecisionSuite) TestParseDecision(c *C) {
+	for _, d := range []Decision{Allow, Challenge, Block} {
+		parsed, err := ParseDecision(d.String())
+		c.Check(err, IsNil)
+		c.Check(parsed, Equals, d)
+	}
+	_, err := ParseDecision("maybe")
+	c.Check(err, ErrorMatches, "unknown decision 'maybe'")
+}
+
+// mockDecider returns the same outcome and error for every decision.
+type mockDecider struct {
+	outcome Outcome
+	err     error
+	options VerifyOption
+}
+
+func (m *mockDecider) Decide(ctx context.Context, result VerifyResult, options VerifyOption) (Outcome, error) {
+	m.options = options
+	return m.outcome, m.err
+}
+
+func (s *DecisionSuite) TestMiddlewareDecider(c *C) {
+	decider := &mockDecider{outcome: Outcome{Decision: Allow}}
+	middleware := NewMiddleware(failVerifier("invalid challenge solution"), VerifyOption{Action: "login"})
+	middleware.Decider = decider
+	handler := middleware.Handler(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Body.String(), Equals, "ok")
+	c.Check(decider.options.Action, Equals, "login")
+
+	decider.err = errors.New("policy unavailable")
+	middleware.Forbidden = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outcome, _ := OutcomeFromContext(r.Context())
+		c.Check(outcome.Reasons, DeepEquals, []string{"decider failed: 'policy unavailable'"})
+		w.WriteHeader(http.StatusForbidden)
+	})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+}
+
+func (s *DecisionSuite) TestMiddlewarePolicy(c *C) {
+	verifier := &mockResponseVerifier{response: Response{Success: true, Score: 0.5}}
+	middleware := NewMiddleware(verifier, VerifyOption{})
+	middleware.Policy = &Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	middleware.Challenge = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outcome, ok := OutcomeFromContext(r.Context())
+		c.Check(ok, Equals, true)
+		c.Check(outcome.Decision, Equals, Challenge)
+		w.Write([]byte("v2 widget"))
+	})
+	handler := middleware.Handler(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Body.String(), Equals, "v2 widget")
+
+	verifier.response.Score = 0.9
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Body.String(), Equals, "ok")
+
+	verifier.response.Score = 0.1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+}
+
+func (s *DecisionSuite) TestVerifyWithOptionsResponse(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	response, err := captcha.VerifyWithOptionsResponse("mycode", VerifyOption{Threshold: 0.7})
+	c.Check(err, ErrorMatches, "received score.*")
+	c.Check(response.Score, Equals, float32(0.6))
+	c.Check(response.Action, Equals, "login")
+	c.Check(Decide(VerifyResult{Response: response, Err: err}, Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}).Decision, Equals, Challenge)
+}
+
+func (s *DecisionSuite) TestVerifyDecision(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	options := VerifyOption{Action: "login", Threshold: 0.7}
+	outcome, response := captcha.VerifyDecision(context.Background(), "mycode", options)
+	c.Check(outcome.Decision, Equals, Block)
+	c.Check(response.Score, Equals, float32(0.6))
+
+	captcha.Policy = &Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	outcome, _ = captcha.VerifyDecision(context.Background(), "mycode", options)
+	c.Check(outcome.Decision, Equals, Challenge)
+	c.Check(outcome.Reasons, DeepEquals, []string{"score '0.600000' below challenge threshold '0.700000'"})
+
+	captcha.RegisterActionProfile("login", ActionProfile{Policy: &Policy{ChallengeBelow: 0.5, QuarantineBelow: 0.8}})
+	outcome, _ = captcha.VerifyDecision(context.Background(), "mycode", VerifyOption{Action: "login"})
+	c.Check(outcome.Decision, Equals, Quarantine)
+}
+
+func (s *DecisionSuite) TestQuarantine(c *C) {
+	policy := Policy{ChallengeBelow: 0.5, BlockBelow: 0.3, QuarantineBelow: 0.7}
+	outcome := Decide(VerifyResult{Response: Response{Score: 0.6}}, policy)
+	c.Check(outcome, DeepEquals, Outcome{Decision: Quarantine, Reasons: []string{"score '0.600000' below quarantine threshold '0.700000'"}})
+	c.Check(Decide(VerifyResult{Response: Response{Score: 0.4}}, policy).Decision, Equals, Challenge)
+	c.Check(Decide(VerifyResult{Response: Response{Score: 0.9}}, policy).Decision, Equals, Allow)
+	c.Check(Quarantine.String(), Equals, "quarantine")
+
+	verifier := &mockResponseVerifier{response: Response{Success: true, Score: 0.6}}
+	var events []Event
+	middleware := NewMiddleware(verifier, VerifyOption{})
+	middleware.Policy = &policy
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if QuarantinedFromContext(r.Context()) {
+			w.Write([]byte("held for review"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Body.String(), Equals, "held for review")
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Decision, Equals, Quarantine)
+
+	verifier.response.Score = 0.9
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"mycode"}}))
+	c.Check(w.Body.String(), Equals, "ok")
+}
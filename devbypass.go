@@ -0,0 +1,52 @@
+package recaptcha
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TestSecret Google's documented test secret, whose verifications always succeed, see
+// https://developers.google.com/recaptcha/docs/faq
+const TestSecret = "6LeIxAcTAAAAAGG-vFI1TnRWxMZNFuojJ4WifJWe"
+
+// NewTestReCAPTCHA new V2 ReCAPTCHA with the test secret and the development bypass, for local
+// development and CI: every challenge response passes without calling recaptcha.
+func NewTestReCAPTCHA() ReCAPTCHA {
+	captcha, _ := NewReCAPTCHA(TestSecret, V2, 10*time.Second)
+	captcha.WithDevBypass()
+	return captcha
+}
+
+// WithDevBypass accepts every challenge response without calling recaptcha while the secret is
+// TestSecret, the other secrets being verified as usual so a production secret is never bypassed.
+// The responses hold the expected hostname, APK package name and action, and a score of 1 for V3.
+// Each bypassed verification is logged at warn level when a logger is set.
+func (r *ReCAPTCHA) WithDevBypass() *ReCAPTCHA {
+	r.devBypass = true
+	return r
+}
+
+// bypass returns a response passing the checks of options.
+func (r *ReCAPTCHA) bypass(ctx context.Context, options VerifyOption) Response {
+	if r.logger != nil {
+		r.log(ctx, slog.LevelWarn, "recaptcha development bypass, challenge response accepted without verification", "action", options.Action)
+	}
+	response := Response{
+		Success:        true,
+		ChallengeTS:    time.Now().Add(-options.MinChallengeAge),
+		Hostname:       exampleHostname(options.Hostname),
+		ApkPackageName: options.ApkPackageName,
+		CData:          options.CData,
+	}
+	var profile ActionProfile
+	if r.Version == V3 {
+		response.Action = options.Action
+		response.Score = 1
+		profile = r.profile(options.Action)
+	}
+	if hostnames := r.hostnames(options, profile); response.Hostname == "" && len(hostnames) > 0 {
+		response.Hostname = exampleHostname(hostnames[0])
+	}
+	return response
+}
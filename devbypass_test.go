@@ -0,0 +1,49 @@
+package recaptcha
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DevBypassSuite struct{}
+
+var _ = Suite(&DevBypassSuite{})
+
+func (s *DevBypassSuite) TestDevBypass(c *C) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-secret"]}`))
+	}))
+	defer server.Close()
+
+	captcha := NewTestReCAPTCHA()
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Secret, Equals, TestSecret)
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Hostname: "example.com", MinChallengeAge: time.Second}), IsNil)
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Hostnames: []string{"*.example.com"}}), IsNil)
+
+	captcha.Version = V3
+	c.Assert(captcha.RegisterActionProfile("login", ActionProfile{Hostnames: []string{"example.com"}}), IsNil)
+	var logs bytes.Buffer
+	captcha.WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	response, err := captcha.VerifyWithOptionsResponse("token", VerifyOption{Action: "login", Threshold: 0.9})
+	c.Assert(err, IsNil)
+	c.Check(response.Score, Equals, float32(1))
+	c.Check(response.Hostname, Equals, "example.com")
+	c.Check(logs.String(), Matches, `.*level=WARN msg="recaptcha development bypass, challenge response accepted without verification" action=login\n`)
+	p := captcha.VerifyPooled("token", VerifyOption{Action: "login"})
+	c.Check(p.Err, IsNil)
+	p.Release()
+	c.Check(requests, Equals, 0)
+
+	// other secrets are verified
+	captcha.Secret = "production-secret"
+	c.Check(captcha.Verify("token"), NotNil)
+	c.Check(requests, Equals, 1)
+}
@@ -0,0 +1,57 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout verification request timeout of NewReCAPTCHAFromEnv when RECAPTCHA_TIMEOUT is unset.
+const DefaultTimeout = 10 * time.Second
+
+// NewReCAPTCHAFromEnv new ReCAPTCHA configured by the environment variables:
+//
+//	RECAPTCHA_SECRET    recaptcha secret, required
+//	RECAPTCHA_VERSION   v2 or v3, v3 by default
+//	RECAPTCHA_TIMEOUT   verification request timeout such as "5s", DefaultTimeout by default
+//	RECAPTCHA_ENDPOINT  absolute http(s) verification URL, Google's siteverify endpoint by default
+func NewReCAPTCHAFromEnv() (ReCAPTCHA, error) {
+	return newReCAPTCHAFromEnv(os.Getenv)
+}
+
+func newReCAPTCHAFromEnv(getenv func(string) string) (ReCAPTCHA, error) {
+	secret := getenv("RECAPTCHA_SECRET")
+	if secret == "" {
+		return ReCAPTCHA{}, fmt.Errorf("RECAPTCHA_SECRET cannot be blank")
+	}
+	version := V3
+	switch value := strings.ToLower(getenv("RECAPTCHA_VERSION")); value {
+	case "", "v3", "3":
+	case "v2", "2":
+		version = V2
+	default:
+		return ReCAPTCHA{}, fmt.Errorf("invalid RECAPTCHA_VERSION '%s', while expecting 'v2' or 'v3'", value)
+	}
+	timeout := DefaultTimeout
+	if value := getenv("RECAPTCHA_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return ReCAPTCHA{}, fmt.Errorf("invalid RECAPTCHA_TIMEOUT '%s', while expecting a positive duration such as '10s'", value)
+		}
+		timeout = parsed
+	}
+	captcha, err := NewReCAPTCHA(secret, version, timeout)
+	if err != nil {
+		return captcha, err
+	}
+	if endpoint := getenv("RECAPTCHA_ENDPOINT"); endpoint != "" {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ReCAPTCHA{}, fmt.Errorf("invalid RECAPTCHA_ENDPOINT '%s', while expecting an absolute http(s) URL", endpoint)
+		}
+		captcha.ReCAPTCHALink = endpoint
+	}
+	return captcha, nil
+}
@@ -0,0 +1,45 @@
+package recaptcha
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type EnvSuite struct{}
+
+var _ = Suite(&EnvSuite{})
+
+func (s *EnvSuite) TestNewReCAPTCHAFromEnv(c *C) {
+	env := map[string]string{"RECAPTCHA_SECRET": "secret"}
+	getenv := func(name string) string { return env[name] }
+
+	captcha, err := newReCAPTCHAFromEnv(getenv)
+	c.Assert(err, IsNil)
+	c.Check(captcha.Secret, Equals, "secret")
+	c.Check(captcha.Version, Equals, V3)
+	c.Check(captcha.Timeout, Equals, DefaultTimeout)
+	c.Check(captcha.ReCAPTCHALink, Equals, reCAPTCHALink)
+
+	env["RECAPTCHA_VERSION"] = "V2"
+	env["RECAPTCHA_TIMEOUT"] = "3s"
+	env["RECAPTCHA_ENDPOINT"] = "https://www.recaptcha.net/recaptcha/api/siteverify"
+	captcha, err = newReCAPTCHAFromEnv(getenv)
+	c.Assert(err, IsNil)
+	c.Check(captcha.Version, Equals, V2)
+	c.Check(captcha.Timeout, Equals, 3*time.Second)
+	c.Check(captcha.ReCAPTCHALink, Equals, "https://www.recaptcha.net/recaptcha/api/siteverify")
+
+	env["RECAPTCHA_ENDPOINT"] = "recaptcha.net"
+	_, err = newReCAPTCHAFromEnv(getenv)
+	c.Check(err, ErrorMatches, "invalid RECAPTCHA_ENDPOINT 'recaptcha.net', while expecting an absolute http\\(s\\) URL")
+	env["RECAPTCHA_TIMEOUT"] = "-1s"
+	_, err = newReCAPTCHAFromEnv(getenv)
+	c.Check(err, ErrorMatches, "invalid RECAPTCHA_TIMEOUT '-1s', while expecting a positive duration such as '10s'")
+	env["RECAPTCHA_VERSION"] = "v4"
+	_, err = newReCAPTCHAFromEnv(getenv)
+	c.Check(err, ErrorMatches, "invalid RECAPTCHA_VERSION 'v4', while expecting 'v2' or 'v3'")
+	delete(env, "RECAPTCHA_SECRET")
+	_, err = newReCAPTCHAFromEnv(getenv)
+	c.Check(err, ErrorMatches, "RECAPTCHA_SECRET cannot be blank")
+}
@@ -0,0 +1,114 @@
+package recaptcha
+
+import "errors"
+
+// Sentinel errors matched by the verification errors, branch on them with errors.Is while errors.As
+// gives access to the *Error details (ErrorCodes, ResponseBody...).
+var (
+	// ErrRequestFailed the verification request failed or its response couldn't be decoded.
+	ErrRequestFailed = errors.New("recaptcha request failed")
+	// ErrRemoteErrors recaptcha replied with error codes.
+	ErrRemoteErrors = errors.New("recaptcha remote errors")
+	// ErrInvalidSolution the challenge wasn't solved, or from another remote IP.
+	ErrInvalidSolution = errors.New("invalid challenge solution")
+	// ErrScoreTooLow the V3 score is below the threshold.
+	ErrScoreTooLow = errors.New("score too low")
+	// ErrActionMismatch the V3 action differs from the expected one.
+	ErrActionMismatch = errors.New("action mismatch")
+	// ErrInvalidAction the expected V3 action isn't a valid action name.
+	ErrInvalidAction = errors.New("invalid action")
+	// ErrCDataMismatch the Turnstile customer data differs from VerifyOption.CData.
+	ErrCDataMismatch = errors.New("cdata mismatch")
+	// ErrHostnameMismatch the hostname differs from the expected ones.
+	ErrHostnameMismatch = errors.New("hostname mismatch")
+	// ErrApkPackageNameMismatch the Android package name differs from the expected one.
+	ErrApkPackageNameMismatch = errors.New("apk package name mismatch")
+	// ErrResponseTimeExceeded the challenge took longer than VerifyOption.ResponseTime to solve.
+	ErrResponseTimeExceeded = errors.New("response time exceeded")
+	// ErrChallengeInFuture the challenge was solved in the future beyond the clock skew.
+	ErrChallengeInFuture = errors.New("challenge solved in the future")
+	// ErrChallengeTooFresh the challenge was solved more recently than VerifyOption.MinChallengeAge.
+	ErrChallengeTooFresh = errors.New("challenge too fresh")
+	// ErrChallengeTooOld the challenge was solved longer ago than the maximum age.
+	ErrChallengeTooOld = errors.New("challenge too old")
+	// ErrVersionMismatch the response doesn't match the configured API version, see StrictVersion.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrTokenReplayed the challenge response was already verified, see ReCAPTCHA.ReplayCache.
+	ErrTokenReplayed = errors.New("token replayed")
+	// ErrCircuitOpen the verification request was short-circuited, see ReCAPTCHA.Breaker.
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrActionNotAllowed the V3 action is blank or not in ReCAPTCHA.AllowedActions.
+	ErrActionNotAllowed = errors.New("action not allowed")
+	// ErrMissingChallengeTS the response has no challenge_ts, see ReCAPTCHA.RequireChallengeTS.
+	ErrMissingChallengeTS = errors.New("missing challenge timestamp")
+	// ErrUnexpectedResponse the response fields don't match the version and platform, see ReCAPTCHA.StrictResponse.
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+var kindErrors = map[errorKind]error{
+	kindRequestFailed:          ErrRequestFailed,
+	kindRemoteErrors:           ErrRemoteErrors,
+	kindInvalidSolution:        ErrInvalidSolution,
+	kindLowScore:               ErrScoreTooLow,
+	kindActionMismatch:         ErrActionMismatch,
+	kindInvalidAction:          ErrInvalidAction,
+	kindCDataMismatch:          ErrCDataMismatch,
+	kindHostnameMismatch:       ErrHostnameMismatch,
+	kindApkPackageNameMismatch: ErrApkPackageNameMismatch,
+	kindResponseTimeExceeded:   ErrResponseTimeExceeded,
+	kindFutureChallenge:        ErrChallengeInFuture,
+	kindChallengeTooFresh:      ErrChallengeTooFresh,
+	kindChallengeTooOld:        ErrChallengeTooOld,
+	kindVersionMismatch:        ErrVersionMismatch,
+	kindReplayedToken:          ErrTokenReplayed,
+	kindCircuitOpen:            ErrCircuitOpen,
+	kindActionNotAllowed:       ErrActionNotAllowed,
+	kindMissingChallengeTS:     ErrMissingChallengeTS,
+	kindUnexpectedResponse:     ErrUnexpectedResponse,
+}
+
+// Unwrap returns the sentinel error matching the failure, nil for the errors of the helpers (proofs,
+// tickets, form timestamps...). The errors of the ReCAPTCHA.ScoreEvaluator are joined to it.
+func (e *Error) Unwrap() error {
+	if e.cause != nil {
+		return errors.Join(kindErrors[e.kind], e.cause)
+	}
+	return kindErrors[e.kind]
+}
+
+var kindReasons = map[errorKind]string{
+	kindRequestFailed:          "request_failed",
+	kindRemoteErrors:           "remote_errors",
+	kindInvalidSolution:        "invalid_solution",
+	kindLowScore:               "score_too_low",
+	kindActionMismatch:         "action_mismatch",
+	kindInvalidAction:          "invalid_action",
+	kindCDataMismatch:          "cdata_mismatch",
+	kindHostnameMismatch:       "hostname_mismatch",
+	kindApkPackageNameMismatch: "apk_package_name_mismatch",
+	kindResponseTimeExceeded:   "response_time_exceeded",
+	kindFutureChallenge:        "challenge_in_future",
+	kindChallengeTooFresh:      "challenge_too_fresh",
+	kindChallengeTooOld:        "challenge_too_old",
+	kindVersionMismatch:        "version_mismatch",
+	kindReplayedToken:          "token_replayed",
+	kindCircuitOpen:            "circuit_open",
+	kindActionNotAllowed:       "action_not_allowed",
+	kindMissingChallengeTS:     "missing_challenge_ts",
+	kindUnexpectedResponse:     "unexpected_response",
+}
+
+// ErrorReason returns a short stable label of the verification failure, e.g. for metrics: "" when err
+// is nil, "score_too_low", "remote_errors"... matching the sentinel errors, "other" for the other errors.
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	var verr *Error
+	if errors.As(err, &verr) {
+		if reason, ok := kindReasons[verr.kind]; ok {
+			return reason
+		}
+	}
+	return "other"
+}
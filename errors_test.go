@@ -0,0 +1,61 @@
+package recaptcha
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ErrorsSuite struct{}
+
+var _ = Suite(&ErrorsSuite{})
+
+func (s *ErrorsSuite) TestSentinels(c *C) {
+	captcha := ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3}
+	for _, t := range []struct {
+		options  VerifyOption
+		sentinel error
+	}{
+		{VerifyOption{Threshold: 0.7}, ErrScoreTooLow},
+		{VerifyOption{Action: "signup"}, ErrActionMismatch},
+		{VerifyOption{Action: "sign up!"}, ErrInvalidAction},
+		{VerifyOption{Hostname: "other.com"}, ErrHostnameMismatch},
+		{VerifyOption{ApkPackageName: "com.example"}, ErrApkPackageNameMismatch},
+		{VerifyOption{ResponseTime: time.Second}, ErrResponseTimeExceeded},
+		{VerifyOption{MaxChallengeAge: time.Second}, ErrChallengeTooOld},
+	} {
+		err := captcha.VerifyWithOptions("mycode", t.options)
+		c.Check(errors.Is(err, t.sentinel), Equals, true, Commentf("%v: %v", t.options, err))
+		var recaptchaErr *Error
+		c.Check(errors.As(err, &recaptchaErr), Equals, true)
+	}
+
+	captcha = ReCAPTCHA{client: &mockFailedClientNoOptions{}}
+	err := captcha.Verify("mycode")
+	c.Check(errors.Is(err, ErrRemoteErrors), Equals, true)
+	c.Check(errors.Is(err, ErrInvalidSolution), Equals, false)
+
+	captcha = ReCAPTCHA{client: &mockInvalidSolutionClient{}}
+	c.Check(errors.Is(captcha.Verify("mycode"), ErrInvalidSolution), Equals, true)
+
+	captcha = ReCAPTCHA{client: &mockUnavailableClient{}}
+	c.Check(errors.Is(captcha.Verify("mycode"), ErrRequestFailed), Equals, true)
+	captcha = ReCAPTCHA{client: &mockInvalidClient{}}
+	c.Check(errors.Is(captcha.Verify("mycode"), ErrRequestFailed), Equals, true)
+
+	c.Check((&Error{msg: "invalid proof"}).Unwrap(), IsNil)
+}
+
+func (s *ErrorsSuite) TestErrorReason(c *C) {
+	captcha := ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3}
+	c.Check(ErrorReason(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"})), Equals, "")
+	c.Check(ErrorReason(captcha.VerifyWithOptions("mycode", VerifyOption{Threshold: 0.7})), Equals, "score_too_low")
+	c.Check(ErrorReason(fmt.Errorf("verifying: %w", captcha.VerifyWithOptions("mycode", VerifyOption{Action: "signup"}))), Equals, "action_mismatch")
+	c.Check(ErrorReason(&Error{msg: "invalid proof"}), Equals, "other")
+	c.Check(ErrorReason(fmt.Errorf("other")), Equals, "other")
+	for kind := range kindErrors {
+		c.Check(kindReasons[kind], Not(Equals), "", Commentf("kind %d", kind))
+	}
+}
@@ -0,0 +1,147 @@
+package recaptcha
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventKind type of the events passed to observers
+type EventKind int8
+
+const (
+	// EventVerification a challenge response was verified, Err is set when it failed.
+	EventVerification EventKind = iota
+	// EventLockout a request was rejected without verification because its remote IP failed too often.
+	EventLockout
+	// EventHoneypot a request was blocked without verification because it filled the honeypot field.
+	EventHoneypot
+	// EventFormTime a request was blocked without verification because of its form render timestamp.
+	EventFormTime
+	// EventVersionMismatch the verification responses don't match the configured API version, e.g. no
+	// score with V3 because of a V2 secret. Emitted once per verifier.
+	EventVersionMismatch
+	// EventFailOpen a failed verification request was accepted by the fail open policy, Err holds the
+	// request error.
+	EventFailOpen
+	// EventBypass a request was let through without verification, e.g. from one of the BypassNetworks.
+	// Action is the expected action and Reasons explain the bypass.
+	EventBypass
+)
+
+// FailOpenReason first of the Reasons of the EventVerification of a failed verification request
+// accepted by the fail open policy, see ReCAPTCHA.FailurePolicy.
+const FailOpenReason = "fail_open"
+
+// Event describes something observers may want to record: logs, metrics, audit trails...
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	RemoteIP string
+	// Action, Hostname and Score from the verification response, if any.
+	Action   string
+	Hostname string
+	Score    float32
+	Err      error
+	// Decision taken for the request, Block for lockouts, Allow for the verification request failures
+	// accepted by the fail open policy, see FailedOpen.
+	Decision Decision
+	// Reasons explaining the decision.
+	Reasons []string
+	// Context key/value pairs attached to the verification with VerifyOption.Context.
+	Context map[string]string
+	// Duration time spent verifying, verification request included, for the EventVerification of
+	// ReCAPTCHA.Observers. Zero for the other events and the verifications rejected without request.
+	Duration time.Duration
+}
+
+// FailedOpen reports whether the event is the verification of a failed request accepted by the fail
+// open policy, Err holding the request error and Reasons starting with FailOpenReason.
+func (e Event) FailedOpen() bool {
+	return e.Kind == EventVerification && e.Err != nil && len(e.Reasons) > 0 && e.Reasons[0] == FailOpenReason
+}
+
+func (k EventKind) String() string {
+	switch k {
+	case EventVerification:
+		return "verification"
+	case EventLockout:
+		return "lockout"
+	case EventHoneypot:
+		return "honeypot"
+	case EventFormTime:
+		return "form_time"
+	case EventVersionMismatch:
+		return "version_mismatch"
+	case EventFailOpen:
+		return "fail_open"
+	case EventBypass:
+		return "bypass"
+	}
+	return fmt.Sprintf("EventKind(%d)", int8(k))
+}
+
+// Observer receives the events emitted while verifying requests, it is called synchronously so
+// slow observers should hand events off to their own goroutine.
+type Observer interface {
+	Observe(event Event)
+}
+
+// ObserverFunc adapter allowing the use of ordinary functions as observers.
+type ObserverFunc func(event Event)
+
+// Observe calls f(event).
+func (f ObserverFunc) Observe(event Event) { f(event) }
+
+func newEvent(kind EventKind, options VerifyOption, response Response, err error, outcome Outcome) Event {
+	return Event{
+		Kind:     kind,
+		Time:     time.Now(),
+		RemoteIP: options.RemoteIP,
+		Action:   response.Action,
+		Hostname: response.Hostname,
+		Score:    response.Score,
+		Err:      err,
+		Decision: outcome.Decision,
+		Reasons:  outcome.Reasons,
+		Context:  options.Context,
+	}
+}
+
+// mergeContext returns a new map holding the pairs of both contexts, extra ones taking precedence.
+func mergeContext(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func notify(observers []Observer, event Event) {
+	for _, observer := range observers {
+		observer.Observe(event)
+	}
+}
+
+// WithScoreObserver adds an observer calling observe with the action, score and outcome of every
+// verification answered by recaptcha while the ReCAPTCHA is V3, e.g. to stream the scores into an
+// analytics pipeline and tune the thresholds. Verifications rejected before or without a response
+// (replayed tokens, failed requests...) carry no score and aren't reported.
+func (r *ReCAPTCHA) WithScoreObserver(observe func(action string, score float32, success bool)) *ReCAPTCHA {
+	version := r.Version
+	r.Observers = append(r.Observers, ObserverFunc(func(event Event) {
+		if event.Kind != EventVerification || version != V3 || event.FailedOpen() {
+			return
+		}
+		if verr, ok := event.Err.(*Error); ok {
+			switch verr.kind {
+			case kindRequestFailed, kindInvalidAction, kindReplayedToken, kindCircuitOpen:
+				return
+			}
+		}
+		observe(event.Action, event.Score, event.Err == nil)
+	}))
+	return r
+}
@@ -0,0 +1,164 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultProofTTL default validity of the proofs issued by TokenExchange
+const DefaultProofTTL = 10 * time.Minute
+
+// ProofHeader request header holding the proof checked by TokenExchange.Middleware
+const ProofHeader = "X-Recaptcha-Proof"
+
+// Proof issued by TokenExchange for a verified token, serialized as JSON.
+type Proof struct {
+	Proof     string `json:"proof"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// TokenExchange http.Handler exchanging a token verified once for a short-lived signed proof, letting
+// single-page apps present the proof to subsequent API calls instead of solving a new challenge.
+// The token is read from the Field form value or the "token" member of a JSON body. Proofs can be
+// presented any number of times until they expire, keep the TTL short.
+type TokenExchange struct {
+	Verifier Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options VerifyOption
+	// ClientIPResolver when set derives the remote IP of the exchanges and API calls from the
+	// forwarding headers of the trusted proxies instead of using the request remote address.
+	ClientIPResolver *ClientIPResolver
+	// Key secret used to sign the proofs.
+	Key []byte
+	// TTL validity of the proofs, DefaultProofTTL when zero.
+	TTL time.Duration
+	// BindRemoteIP only accept proofs presented from the remote IP they were issued to.
+	BindRemoteIP bool
+	// Field form field holding the token, DefaultResponseField when blank.
+	Field string
+	// Observers receive the verification events.
+	Observers []Observer
+
+	now func() time.Time
+}
+
+// NewTokenExchange new TokenExchange verifying tokens with verifier and signing proofs with key
+func NewTokenExchange(verifier Verifier, options VerifyOption, key []byte) (*TokenExchange, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("proof key cannot be blank")
+	}
+	return &TokenExchange{Verifier: verifier, Options: options, Key: key, now: time.Now}, nil
+}
+
+// ServeHTTP verifies the token of a POST request and replies with a JSON Proof, or a 403 when the
+// verification fails.
+func (e *TokenExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	token, err := e.token(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := e.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = e.ClientIPResolver.ClientIP(r)
+	}
+	response, err := VerifyContext(r.Context(), e.Verifier, token, options)
+	outcome := decide(nil, VerifyResult{Response: response, Err: err})
+	notify(e.Observers, newEvent(EventVerification, options, response, err, outcome))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.issue(options))
+}
+
+// Middleware wraps next, only calling it for requests presenting a valid proof in the ProofHeader header.
+func (e *TokenExchange) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := e.Check(r.Header.Get(ProofHeader), e.ClientIPResolver.ClientIP(r)); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Check returns `nil` if the proof is valid, not expired and, with BindRemoteIP, issued to remoteIP.
+func (e *TokenExchange) Check(proof, remoteIP string) error {
+	payload, ok := unseal(e.Key, purposeProof, proof)
+	if !ok {
+		return &Error{msg: "invalid proof"}
+	}
+	fields := strings.Split(payload, "|")
+	if len(fields) != 3 {
+		return &Error{msg: "invalid proof"}
+	}
+	expiry, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return &Error{msg: "invalid proof"}
+	}
+	if e.clock().Unix() > expiry {
+		return &Error{msg: "expired proof"}
+	}
+	if fields[1] != e.Options.Action {
+		return &Error{msg: fmt.Sprintf("proof issued for action '%s', while expecting '%s'", fields[1], e.Options.Action)}
+	}
+	if e.BindRemoteIP && fields[2] != remoteIP {
+		return &Error{msg: fmt.Sprintf("proof issued for remote IP '%s', while expecting '%s'", fields[2], remoteIP)}
+	}
+	return nil
+}
+
+// issue returns a proof made of "expiry|action|remoteip" and its signature. Proofs are reusable until
+// they expire, so unlike the StepUp tickets they carry no nonce.
+func (e *TokenExchange) issue(options VerifyOption) Proof {
+	expiry := e.clock().Add(e.ttl()).Unix()
+	payload := strings.Join([]string{strconv.FormatInt(expiry, 10), e.Options.Action, options.RemoteIP}, "|")
+	return Proof{Proof: seal(e.Key, purposeProof, payload), ExpiresAt: expiry}
+}
+
+// token reads the token from the JSON body or the form, bodies larger than maxTokenBodySize failing.
+func (e *TokenExchange) token(w http.ResponseWriter, r *http.Request) (string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTokenBodySize)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("invalid JSON body")
+		}
+		return body.Token, nil
+	}
+	field := e.Field
+	if field == "" {
+		field = DefaultResponseField
+	}
+	return r.FormValue(field), nil
+}
+
+func (e *TokenExchange) ttl() time.Duration {
+	if e.TTL == 0 {
+		return DefaultProofTTL
+	}
+	return e.TTL
+}
+
+func (e *TokenExchange) clock() time.Time {
+	if e.now == nil {
+		return time.Now()
+	}
+	return e.now()
+}
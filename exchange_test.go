@@ -0,0 +1,141 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TokenExchangeSuite struct{}
+
+var _ = Suite(&TokenExchangeSuite{})
+
+func (s *TokenExchangeSuite) TestNewTokenExchange(c *C) {
+	_, err := NewTokenExchange(passVerifier(), VerifyOption{}, nil)
+	c.Check(err, ErrorMatches, "proof key cannot be blank")
+}
+
+// exchange posts the token as JSON and decodes the proof.
+func exchange(c *C, handler http.Handler, token string) (*httptest.ResponseRecorder, Proof) {
+	r := httptest.NewRequest(http.MethodPost, "/exchange", strings.NewReader(`{"token": "`+token+`"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var proof Proof
+	if w.Code == http.StatusOK {
+		c.Assert(json.NewDecoder(w.Body).Decode(&proof), IsNil)
+	}
+	return w, proof
+}
+
+func (s *TokenExchangeSuite) TestExchange(c *C) {
+	now := time.Unix(1000, 0)
+	var tokens []string
+	verifier := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		tokens = append(tokens, challengeResponse)
+		c.Check(options.Action, Equals, "api")
+		c.Check(options.RemoteIP, Equals, "192.0.2.1")
+		if challengeResponse != "good" {
+			return &Error{msg: "invalid challenge solution"}
+		}
+		return nil
+	})
+	tokenExchange, err := NewTokenExchange(verifier, VerifyOption{Action: "api"}, []byte("key"))
+	c.Assert(err, IsNil)
+	tokenExchange.now = func() time.Time { return now }
+
+	w, proof := exchange(c, tokenExchange, "good")
+	c.Assert(w.Code, Equals, http.StatusOK)
+	c.Check(proof.ExpiresAt, Equals, int64(1600))
+
+	w, _ = exchange(c, tokenExchange, "bad")
+	c.Check(w.Code, Equals, http.StatusForbidden)
+
+	r := httptest.NewRequest(http.MethodPost, "/exchange", strings.NewReader(url.Values{"g-recaptcha-response": {"good"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	tokenExchange.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(tokens, DeepEquals, []string{"good", "bad", "good"})
+
+	w = httptest.NewRecorder()
+	tokenExchange.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exchange", nil))
+	c.Check(w.Code, Equals, http.StatusMethodNotAllowed)
+
+	api := tokenExchange.Middleware(okHandler)
+	call := func(proof string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api", nil)
+		r.Header.Set(ProofHeader, proof)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		return w.Code
+	}
+	c.Check(call(proof.Proof), Equals, http.StatusOK)
+	c.Check(call(""), Equals, http.StatusForbidden)
+	c.Check(call(proof.Proof+"x"), Equals, http.StatusForbidden)
+
+	// too large bodies are rejected without verification
+	w, _ = exchange(c, tokenExchange, strings.Repeat("a", maxTokenBodySize))
+	c.Check(w.Code, Equals, http.StatusBadRequest)
+	c.Check(tokens, HasLen, 3)
+
+	now = now.Add(11 * time.Minute)
+	c.Check(call(proof.Proof), Equals, http.StatusForbidden)
+	c.Check(tokenExchange.Check(proof.Proof, "192.0.2.1"), ErrorMatches, "expired proof")
+}
+
+func (s *TokenExchangeSuite) TestCheck(c *C) {
+	tokenExchange, _ := NewTokenExchange(passVerifier(), VerifyOption{Action: "api"}, []byte("key"))
+	proof := tokenExchange.issue(VerifyOption{RemoteIP: "192.0.2.1"})
+	c.Check(tokenExchange.Check(proof.Proof, "198.51.100.1"), IsNil)
+
+	tokenExchange.BindRemoteIP = true
+	c.Check(tokenExchange.Check(proof.Proof, "192.0.2.1"), IsNil)
+	c.Check(tokenExchange.Check(proof.Proof, "198.51.100.1"), ErrorMatches, "proof issued for remote IP '192.0.2.1', while expecting '198.51.100.1'")
+
+	other, _ := NewTokenExchange(passVerifier(), VerifyOption{Action: "checkout"}, []byte("key"))
+	c.Check(other.Check(proof.Proof, "192.0.2.1"), ErrorMatches, "proof issued for action 'api', while expecting 'checkout'")
+	other.Key = []byte("other key")
+	c.Check(other.Check(proof.Proof, "192.0.2.1"), ErrorMatches, "invalid proof")
+
+	// the step-up tickets, issued for low scores, aren't proofs
+	stepUp, _ := NewStepUp(lowScoreVerifier(), passVerifier(), []byte("key"))
+	ticket, err := stepUp.issue(VerifyOption{Action: "api", RemoteIP: "192.0.2.1"})
+	c.Assert(err, IsNil)
+	c.Check(tokenExchange.Check(ticket, "192.0.2.1"), ErrorMatches, "invalid proof")
+}
+
+func (s *TokenExchangeSuite) TestClientIPResolver(c *C) {
+	verifier := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		c.Check(options.RemoteIP, Equals, "198.51.100.1")
+		return nil
+	})
+	tokenExchange, _ := NewTokenExchange(verifier, VerifyOption{Action: "api"}, []byte("key"))
+	tokenExchange.BindRemoteIP = true
+	tokenExchange.ClientIPResolver, _ = NewClientIPResolver("192.0.2.0/24")
+
+	r := httptest.NewRequest(http.MethodPost, "/exchange", strings.NewReader(`{"token": "good"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := httptest.NewRecorder()
+	tokenExchange.ServeHTTP(w, r)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	var proof Proof
+	c.Assert(json.NewDecoder(w.Body).Decode(&proof), IsNil)
+
+	call := func(forwardedFor string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api", nil)
+		r.Header.Set(ProofHeader, proof.Proof)
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		tokenExchange.Middleware(okHandler).ServeHTTP(w, r)
+		return w.Code
+	}
+	c.Check(call("198.51.100.1"), Equals, http.StatusOK)
+	c.Check(call("198.51.100.2"), Equals, http.StatusForbidden)
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"sync"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// CSVWriter writes records to rotated CSV files, each starting with a header line.
+type CSVWriter struct {
+	mu      sync.Mutex
+	rotator *Rotator
+	file    *os.File
+	csv     *csv.Writer
+	err     error
+}
+
+// NewCSVWriter new CSVWriter creating files named prefix-<timestamp>.csv in dir
+func NewCSVWriter(dir, prefix string, rotation Rotation) *CSVWriter {
+	return &CSVWriter{rotator: NewRotator(dir, prefix, ".csv", rotation)}
+}
+
+// Observe writes the event, use Err to check for write failures.
+func (w *CSVWriter) Observe(event recaptcha.Event) {
+	w.WriteRecord(EventRecord(event))
+}
+
+// Write writes the outcome of a batch job.
+func (w *CSVWriter) Write(outcome recaptcha.JobOutcome) error {
+	return w.WriteRecord(OutcomeRecord(outcome))
+}
+
+// WriteRecord writes the record, rotating the file first when due. Records are flushed immediately.
+func (w *CSVWriter) WriteRecord(record Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.write(record); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+func (w *CSVWriter) write(record Record) error {
+	if w.rotator.Due() {
+		if err := w.close(); err != nil {
+			return err
+		}
+		file, err := os.Create(w.rotator.Next())
+		if err != nil {
+			return err
+		}
+		w.file, w.csv = file, csv.NewWriter(file)
+		w.rotator.Opened()
+		if err := w.csv.Write(header); err != nil {
+			return err
+		}
+	}
+	w.csv.Write([]string{
+		strconv.FormatInt(record.Time, 10), record.Kind, record.RemoteIP, record.Action, record.Hostname,
+		strconv.FormatFloat(float64(record.Score), 'f', -1, 32), record.Decision, record.Reasons, record.Error, record.Context,
+	})
+	w.csv.Flush()
+	w.rotator.Written()
+	return w.csv.Error()
+}
+
+// Err returns the last error met while writing.
+func (w *CSVWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close closes the current file.
+func (w *CSVWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+func (w *CSVWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	w.csv.Flush()
+	err := w.csv.Error()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.csv = nil, nil
+	return err
+}
@@ -0,0 +1,128 @@
+// Package export writes verification decisions to rotated CSV files, see the parquetexport package for
+// Parquet files, so they can be loaded into data warehouses without a streaming pipeline. Writers are
+// recaptcha.Observer implementations for the middleware events and recaptcha.JobSink implementations
+// for batch runs.
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Record flattened verification decision written by the writers.
+type Record struct {
+	Time     int64   `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Kind     string  `parquet:"name=kind, type=BYTE_ARRAY, convertedtype=UTF8"`
+	RemoteIP string  `parquet:"name=remote_ip, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Action   string  `parquet:"name=action, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Hostname string  `parquet:"name=hostname, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Score    float32 `parquet:"name=score, type=FLOAT"`
+	Decision string  `parquet:"name=decision, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Reasons  string  `parquet:"name=reasons, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Error    string  `parquet:"name=error, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Context  string  `parquet:"name=context, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// header CSV column names, in Record field order.
+var header = []string{"time", "kind", "remote_ip", "action", "hostname", "score", "decision", "reasons", "error", "context"}
+
+// EventRecord flattens a middleware event.
+func EventRecord(event recaptcha.Event) Record {
+	return Record{
+		Time:     event.Time.UnixNano() / int64(time.Millisecond),
+		Kind:     event.Kind.String(),
+		RemoteIP: event.RemoteIP,
+		Action:   event.Action,
+		Hostname: event.Hostname,
+		Score:    event.Score,
+		Decision: event.Decision.String(),
+		Reasons:  strings.Join(event.Reasons, "; "),
+		Error:    errorString(event.Err),
+		Context:  contextString(event.Context),
+	}
+}
+
+// OutcomeRecord flattens the outcome of a batch job, timestamped with the current time.
+func OutcomeRecord(outcome recaptcha.JobOutcome) Record {
+	return Record{
+		Time:     time.Now().UnixNano() / int64(time.Millisecond),
+		Kind:     recaptcha.EventVerification.String(),
+		RemoteIP: outcome.Job.Options.RemoteIP,
+		Action:   outcome.Response.Action,
+		Hostname: outcome.Response.Hostname,
+		Score:    outcome.Response.Score,
+		Decision: outcome.Outcome.Decision.String(),
+		Reasons:  strings.Join(outcome.Outcome.Reasons, "; "),
+		Error:    errorString(outcome.Err),
+		Context:  contextString(outcome.Job.Options.Context),
+	}
+}
+
+// Rotation decides when writers start a new file, never when zero.
+type Rotation struct {
+	// Interval maximum age of a file.
+	Interval time.Duration
+	// MaxRecords maximum number of records written to a file.
+	MaxRecords int
+}
+
+// Rotator tracks the current file of a writer and names the next one, it is not safe for concurrent use.
+type Rotator struct {
+	dir, prefix, ext string
+	rotation         Rotation
+	opened           time.Time
+	records          int
+	now              func() time.Time
+}
+
+// NewRotator new Rotator naming files prefix-<timestamp><ext> in dir
+func NewRotator(dir, prefix, ext string, rotation Rotation) *Rotator {
+	return &Rotator{dir: dir, prefix: prefix, ext: ext, rotation: rotation, now: time.Now}
+}
+
+// Due reports whether a new file must be started before writing the next record.
+func (r *Rotator) Due() bool {
+	if r.opened.IsZero() {
+		return true
+	}
+	return (r.rotation.Interval > 0 && r.now().Sub(r.opened) >= r.rotation.Interval) ||
+		(r.rotation.MaxRecords > 0 && r.records >= r.rotation.MaxRecords)
+}
+
+// Next returns the path of the next file, named after the current time. The rotation stays due until
+// Opened is called, so a file that couldn't be created is retried with the next record.
+func (r *Rotator) Next() string {
+	return filepath.Join(r.dir, fmt.Sprintf("%s-%s%s", r.prefix, r.now().UTC().Format("20060102T150405.000000000"), r.ext))
+}
+
+// Opened records that the file returned by Next was opened.
+func (r *Rotator) Opened() {
+	r.opened, r.records = r.now(), 0
+}
+
+// Written records that a record was written to the current file.
+func (r *Rotator) Written() {
+	r.records++
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// contextString formats the context as sorted "key=value" pairs separated by semicolons.
+func contextString(context map[string]string) string {
+	pairs := make([]string, 0, len(context))
+	for k, v := range context {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,163 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ExportSuite struct {
+	dir string
+}
+
+var _ = Suite(&ExportSuite{})
+
+var _ recaptcha.Observer = (*CSVWriter)(nil)
+var _ recaptcha.JobSink = (*CSVWriter)(nil)
+
+func (s *ExportSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *ExportSuite) readFiles(c *C) [][][]string {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*.csv"))
+	c.Assert(err, IsNil)
+	files := make([][][]string, 0, len(names))
+	for _, name := range names {
+		file, err := os.Open(name)
+		c.Assert(err, IsNil)
+		rows, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		c.Assert(err, IsNil)
+		files = append(files, rows)
+	}
+	return files
+}
+
+func (s *ExportSuite) TestEventRecord(c *C) {
+	record := EventRecord(recaptcha.Event{
+		Kind:     recaptcha.EventVerification,
+		Time:     time.Unix(1500000000, 0),
+		RemoteIP: "127.0.0.1",
+		Action:   "login",
+		Hostname: "localhost",
+		Score:    0.3,
+		Err:      errors.New("low score"),
+		Decision: recaptcha.Challenge,
+		Reasons:  []string{"first", "second"},
+		Context:  map[string]string{"tenant": "acme", "request_id": "42"},
+	})
+	c.Check(record, DeepEquals, Record{
+		Time:     1500000000000,
+		Kind:     "verification",
+		RemoteIP: "127.0.0.1",
+		Action:   "login",
+		Hostname: "localhost",
+		Score:    0.3,
+		Decision: "challenge",
+		Reasons:  "first; second",
+		Error:    "low score",
+		Context:  "request_id=42;tenant=acme",
+	})
+}
+
+func (s *ExportSuite) TestOutcomeRecord(c *C) {
+	record := OutcomeRecord(recaptcha.JobOutcome{
+		Job:      recaptcha.Job{ID: "1", Options: recaptcha.VerifyOption{RemoteIP: "127.0.0.1"}},
+		Response: recaptcha.Response{Action: "login", Score: 0.9},
+		Outcome:  recaptcha.Outcome{Decision: recaptcha.Allow},
+	})
+	c.Check(record.Kind, Equals, "verification")
+	c.Check(record.RemoteIP, Equals, "127.0.0.1")
+	c.Check(record.Action, Equals, "login")
+	c.Check(record.Decision, Equals, "allow")
+	c.Check(record.Error, Equals, "")
+	c.Check(record.Time > 0, Equals, true)
+}
+
+func (s *ExportSuite) TestCSVWriter(c *C) {
+	writer := NewCSVWriter(s.dir, "decisions", Rotation{})
+	c.Assert(writer.WriteRecord(Record{Time: 1, Kind: "verification", Action: "login", Score: 0.5, Decision: "allow"}), IsNil)
+	writer.Observe(recaptcha.Event{Kind: recaptcha.EventLockout, Time: time.Unix(0, 0), Decision: recaptcha.Block})
+	c.Assert(writer.Close(), IsNil)
+	c.Check(writer.Err(), IsNil)
+
+	files := s.readFiles(c)
+	c.Assert(files, HasLen, 1)
+	c.Check(files[0], DeepEquals, [][]string{
+		header,
+		{"1", "verification", "", "login", "", "0.5", "allow", "", "", ""},
+		{"0", "lockout", "", "", "", "0", "block", "", "", ""},
+	})
+}
+
+func (s *ExportSuite) TestCSVWriterRotatesOnMaxRecords(c *C) {
+	now := time.Unix(1500000000, 0)
+	writer := NewCSVWriter(s.dir, "decisions", Rotation{MaxRecords: 2})
+	writer.rotator.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	for i := 0; i < 5; i++ {
+		c.Assert(writer.WriteRecord(Record{Time: int64(i)}), IsNil)
+	}
+	c.Assert(writer.Close(), IsNil)
+
+	files := s.readFiles(c)
+	c.Assert(files, HasLen, 3)
+	c.Check(files[0], HasLen, 3)
+	c.Check(files[1], HasLen, 3)
+	c.Check(files[2], HasLen, 2)
+}
+
+func (s *ExportSuite) TestCSVWriterRotatesOnInterval(c *C) {
+	now := time.Unix(1500000000, 0)
+	writer := NewCSVWriter(s.dir, "decisions", Rotation{Interval: time.Hour})
+	writer.rotator.now = func() time.Time { return now }
+	c.Assert(writer.WriteRecord(Record{}), IsNil)
+	now = now.Add(30 * time.Minute)
+	c.Assert(writer.WriteRecord(Record{}), IsNil)
+	now = now.Add(30 * time.Minute)
+	c.Assert(writer.WriteRecord(Record{}), IsNil)
+	c.Assert(writer.Close(), IsNil)
+
+	files := s.readFiles(c)
+	c.Assert(files, HasLen, 2)
+	c.Check(files[0], HasLen, 3)
+	c.Check(files[1], HasLen, 2)
+}
+
+func (s *ExportSuite) TestCSVWriterKeepsLastError(c *C) {
+	writer := NewCSVWriter(filepath.Join(s.dir, "missing"), "decisions", Rotation{})
+	writer.Observe(recaptcha.Event{})
+	c.Check(writer.Err(), NotNil)
+
+	files, err := ioutil.ReadDir(s.dir)
+	c.Assert(err, IsNil)
+	c.Check(files, HasLen, 0)
+}
+
+func (s *ExportSuite) TestCSVWriterRetriesFailedCreate(c *C) {
+	dir := filepath.Join(s.dir, "later")
+	writer := NewCSVWriter(dir, "decisions", Rotation{})
+	c.Check(writer.WriteRecord(Record{}), NotNil)
+	// no file is open, the next record retries instead of writing to a nil writer
+	c.Check(writer.WriteRecord(Record{}), NotNil)
+
+	c.Assert(os.Mkdir(dir, 0755), IsNil)
+	c.Assert(writer.WriteRecord(Record{Time: 1}), IsNil)
+	c.Assert(writer.Close(), IsNil)
+	names, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 1)
+}
@@ -0,0 +1,96 @@
+// Package parquetexport writes verification decisions to rotated Parquet files, kept apart from the
+// export package so that CSV users don't depend on the Parquet libraries.
+package parquetexport
+
+import (
+	"sync"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+	"gopkg.in/ezzarghili/recaptcha-go.v4/export"
+)
+
+// ParquetWriter writes export records to rotated snappy compressed Parquet files. A Parquet file is only
+// readable once closed, by rotation or Close.
+type ParquetWriter struct {
+	mu      sync.Mutex
+	rotator *export.Rotator
+	file    source.ParquetFile
+	parquet *writer.ParquetWriter
+	err     error
+}
+
+// NewParquetWriter new ParquetWriter creating files named prefix-<timestamp>.parquet in dir
+func NewParquetWriter(dir, prefix string, rotation export.Rotation) *ParquetWriter {
+	return &ParquetWriter{rotator: export.NewRotator(dir, prefix, ".parquet", rotation)}
+}
+
+// Observe writes the event, use Err to check for write failures.
+func (w *ParquetWriter) Observe(event recaptcha.Event) {
+	w.WriteRecord(export.EventRecord(event))
+}
+
+// Write writes the outcome of a batch job.
+func (w *ParquetWriter) Write(outcome recaptcha.JobOutcome) error {
+	return w.WriteRecord(export.OutcomeRecord(outcome))
+}
+
+// WriteRecord writes the record, rotating the file first when due.
+func (w *ParquetWriter) WriteRecord(record export.Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.write(record); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+func (w *ParquetWriter) write(record export.Record) error {
+	if w.rotator.Due() {
+		if err := w.close(); err != nil {
+			return err
+		}
+		file, err := local.NewLocalFileWriter(w.rotator.Next())
+		if err != nil {
+			return err
+		}
+		pw, err := writer.NewParquetWriter(file, new(export.Record), 1)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.file, w.parquet = file, pw
+		w.rotator.Opened()
+	}
+	w.rotator.Written()
+	return w.parquet.Write(record)
+}
+
+// Err returns the last error met while writing.
+func (w *ParquetWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close writes the footer of the current file and closes it.
+func (w *ParquetWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+func (w *ParquetWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.parquet.WriteStop()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.parquet = nil, nil
+	return err
+}
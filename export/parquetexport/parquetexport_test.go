@@ -0,0 +1,92 @@
+package parquetexport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+	"gopkg.in/ezzarghili/recaptcha-go.v4/export"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ParquetSuite struct {
+	dir string
+}
+
+var _ = Suite(&ParquetSuite{})
+
+var _ recaptcha.Observer = (*ParquetWriter)(nil)
+var _ recaptcha.JobSink = (*ParquetWriter)(nil)
+
+func (s *ParquetSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *ParquetSuite) readFiles(c *C, dir string) [][]export.Record {
+	names, err := filepath.Glob(filepath.Join(dir, "*.parquet"))
+	c.Assert(err, IsNil)
+	files := make([][]export.Record, 0, len(names))
+	for _, name := range names {
+		file, err := local.NewLocalFileReader(name)
+		c.Assert(err, IsNil)
+		pr, err := reader.NewParquetReader(file, new(export.Record), 1)
+		c.Assert(err, IsNil)
+		records := make([]export.Record, pr.GetNumRows())
+		c.Assert(pr.Read(&records), IsNil)
+		pr.ReadStop()
+		file.Close()
+		files = append(files, records)
+	}
+	return files
+}
+
+func (s *ParquetSuite) TestParquetWriter(c *C) {
+	writer := NewParquetWriter(s.dir, "decisions", export.Rotation{})
+	writer.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Time: time.Unix(1500000000, 0), Action: "login", Score: 0.5})
+	c.Assert(writer.Write(recaptcha.JobOutcome{Outcome: recaptcha.Outcome{Decision: recaptcha.Block}}), IsNil)
+	c.Assert(writer.Err(), IsNil)
+	c.Assert(writer.Close(), IsNil)
+
+	files := s.readFiles(c, s.dir)
+	c.Assert(files, HasLen, 1)
+	c.Assert(files[0], HasLen, 2)
+	c.Check(files[0][0].Time, Equals, int64(1500000000000))
+	c.Check(files[0][0].Action, Equals, "login")
+	c.Check(files[0][0].Score, Equals, float32(0.5))
+	c.Check(files[0][1].Decision, Equals, "block")
+}
+
+func (s *ParquetSuite) TestParquetWriterRotatesOnMaxRecords(c *C) {
+	writer := NewParquetWriter(s.dir, "decisions", export.Rotation{MaxRecords: 2})
+	for i := 0; i < 3; i++ {
+		c.Assert(writer.WriteRecord(export.Record{Time: int64(i)}), IsNil)
+	}
+	c.Assert(writer.Close(), IsNil)
+
+	files := s.readFiles(c, s.dir)
+	c.Assert(files, HasLen, 2)
+	c.Check(len(files[0])+len(files[1]), Equals, 3)
+}
+
+func (s *ParquetSuite) TestParquetWriterRetriesFailedCreate(c *C) {
+	dir := filepath.Join(s.dir, "later")
+	writer := NewParquetWriter(dir, "decisions", export.Rotation{})
+	writer.Observe(recaptcha.Event{})
+	c.Check(writer.Err(), NotNil)
+	// no file is open, the next record retries instead of writing to a nil writer
+	c.Check(writer.WriteRecord(export.Record{}), NotNil)
+
+	c.Assert(os.Mkdir(dir, 0755), IsNil)
+	c.Assert(writer.WriteRecord(export.Record{Time: 1}), IsNil)
+	c.Assert(writer.Close(), IsNil)
+	files := s.readFiles(c, dir)
+	c.Assert(files, HasLen, 1)
+	c.Check(files[0], HasLen, 1)
+}
@@ -0,0 +1,155 @@
+// Package extauthz implements Envoy's external authorization gRPC service backed by a
+// recaptcha.Verifier, so service meshes enforce captcha decisions at the proxy for selected routes.
+//
+// Routes opt in or out with the ext_authz per-route filter configuration, their check settings
+// context extensions may set the expected action ("recaptcha_action") and minimum score
+// ("recaptcha_threshold") of the route.
+package extauthz
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Context extensions read from the route check settings.
+const (
+	ActionExtension    = "recaptcha_action"
+	ThresholdExtension = "recaptcha_threshold"
+)
+
+// Server Envoy AuthorizationServer verifying the challenge response of the request Header header.
+// Allowed and quarantined requests are forwarded upstream with the score and decision headers,
+// the others are denied with a 401 when the challenge response is missing or a 403.
+type Server struct {
+	authv3.UnimplementedAuthorizationServer
+
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the downstream address when blank.
+	Options recaptcha.VerifyOption
+	// Header request header holding the challenge response, recaptcha.DefaultTokenHeader when blank.
+	Header string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are denied.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// Observers receive the verification events.
+	Observers []recaptcha.Observer
+}
+
+var _ authv3.AuthorizationServer = (*Server)(nil)
+
+// NewServer new Server verifying requests with verifier using options
+func NewServer(verifier recaptcha.Verifier, options recaptcha.VerifyOption) (*Server, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("server verifier cannot be nil")
+	}
+	return &Server{Verifier: verifier, Options: options}, nil
+}
+
+// Check verifies the request and returns whether Envoy should forward it.
+func (s *Server) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	attributes := req.GetAttributes()
+	options, err := s.options(attributes)
+	if err != nil {
+		return nil, err
+	}
+	// Envoy lowercases the request header names
+	token := attributes.GetRequest().GetHttp().GetHeaders()[strings.ToLower(s.header())]
+	if token == "" {
+		err := fmt.Errorf("missing challenge response header '%s'", s.header())
+		s.notify(options, recaptcha.Response{}, err, recaptcha.Outcome{Decision: recaptcha.Block, Reasons: []string{err.Error()}})
+		return denied(code.Code_UNAUTHENTICATED, typev3.StatusCode_Unauthorized, recaptcha.Block), nil
+	}
+
+	response, err := recaptcha.VerifyContext(ctx, s.Verifier, token, options)
+	outcome := recaptcha.DecideWith(ctx, s.Decider, s.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+	s.notify(options, response, err, outcome)
+	if outcome.Decision != recaptcha.Allow && outcome.Decision != recaptcha.Quarantine {
+		return denied(code.Code_PERMISSION_DENIED, typev3.StatusCode_Forbidden, outcome.Decision), nil
+	}
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(code.Code_OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: &authv3.OkHttpResponse{
+			Headers: []*corev3.HeaderValueOption{
+				header(recaptcha.ScoreHeader, fmt.Sprintf("%.2f", response.Score)),
+				header(recaptcha.DecisionHeader, outcome.Decision.String()),
+			},
+		}},
+	}, nil
+}
+
+// options returns the verification options of the request, applying the route context extensions.
+func (s *Server) options(attributes *authv3.AttributeContext) (recaptcha.VerifyOption, error) {
+	options := s.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = attributes.GetSource().GetAddress().GetSocketAddress().GetAddress()
+	}
+	extensions := attributes.GetContextExtensions()
+	if action, ok := extensions[ActionExtension]; ok {
+		options.Action = action
+	}
+	if value, ok := extensions[ThresholdExtension]; ok {
+		threshold, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return options, grpcstatus.Errorf(codes.InvalidArgument, "invalid context extension %s '%s'", ThresholdExtension, value)
+		}
+		options.Threshold = float32(threshold)
+	}
+	return options, nil
+}
+
+func (s *Server) notify(options recaptcha.VerifyOption, response recaptcha.Response, err error, outcome recaptcha.Outcome) {
+	event := recaptcha.Event{
+		Kind:     recaptcha.EventVerification,
+		Time:     time.Now(),
+		RemoteIP: options.RemoteIP,
+		Action:   response.Action,
+		Hostname: response.Hostname,
+		Score:    response.Score,
+		Err:      err,
+		Decision: outcome.Decision,
+		Reasons:  outcome.Reasons,
+		Context:  options.Context,
+	}
+	for _, observer := range s.Observers {
+		observer.Observe(event)
+	}
+}
+
+func (s *Server) header() string {
+	if s.Header == "" {
+		return recaptcha.DefaultTokenHeader
+	}
+	return s.Header
+}
+
+func denied(rpcCode code.Code, httpCode typev3.StatusCode, decision recaptcha.Decision) *authv3.CheckResponse {
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(rpcCode)},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{DeniedResponse: &authv3.DeniedHttpResponse{
+			Status:  &typev3.HttpStatus{Code: httpCode},
+			Headers: []*corev3.HeaderValueOption{header(recaptcha.DecisionHeader, decision.String())},
+		}},
+	}
+}
+
+// header returns a header overwriting the one sent by the client, if any.
+func header(key, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header:       &corev3.HeaderValue{Key: key, Value: value},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
@@ -0,0 +1,113 @@
+package extauthz
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ExtAuthzSuite struct{}
+
+var _ = Suite(&ExtAuthzSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct {
+	options recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = options
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6}, nil
+}
+
+func checkRequest(headers, extensions map[string]string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{Attributes: &authv3.AttributeContext{
+		Source: &authv3.AttributeContext_Peer{Address: &corev3.Address{Address: &corev3.Address_SocketAddress{
+			SocketAddress: &corev3.SocketAddress{Address: "203.0.113.1"},
+		}}},
+		Request:           &authv3.AttributeContext_Request{Http: &authv3.AttributeContext_HttpRequest{Method: "POST", Headers: headers}},
+		ContextExtensions: extensions,
+	}}
+}
+
+func headers(options []*corev3.HeaderValueOption) map[string]string {
+	values := map[string]string{}
+	for _, option := range options {
+		values[option.Header.Key] = option.Header.Value
+	}
+	return values
+}
+
+func (s *ExtAuthzSuite) TestNewServer(c *C) {
+	_, err := NewServer(nil, recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "server verifier cannot be nil")
+}
+
+func (s *ExtAuthzSuite) TestCheck(c *C) {
+	verifier := &mockVerifier{}
+	server, err := NewServer(verifier, recaptcha.VerifyOption{Threshold: 0.5})
+	c.Assert(err, IsNil)
+
+	resp, err := server.Check(context.Background(), checkRequest(map[string]string{"x-recaptcha-token": "valid"}, map[string]string{ActionExtension: "login", ThresholdExtension: "0.3"}))
+	c.Assert(err, IsNil)
+	c.Check(resp.Status.Code, Equals, int32(code.Code_OK))
+	c.Check(headers(resp.GetOkResponse().Headers), DeepEquals, map[string]string{recaptcha.ScoreHeader: "0.60", recaptcha.DecisionHeader: "allow"})
+	c.Check(verifier.options, DeepEquals, recaptcha.VerifyOption{Threshold: 0.3, Action: "login", RemoteIP: "203.0.113.1"})
+
+	resp, err = server.Check(context.Background(), checkRequest(map[string]string{"x-recaptcha-token": "invalid"}, nil))
+	c.Assert(err, IsNil)
+	c.Check(resp.Status.Code, Equals, int32(code.Code_PERMISSION_DENIED))
+	c.Check(resp.GetDeniedResponse().Status.Code, Equals, typev3.StatusCode_Forbidden)
+	c.Check(headers(resp.GetDeniedResponse().Headers), DeepEquals, map[string]string{recaptcha.DecisionHeader: "block"})
+
+	var events []recaptcha.Event
+	server.Observers = []recaptcha.Observer{recaptcha.ObserverFunc(func(event recaptcha.Event) { events = append(events, event) })}
+	resp, err = server.Check(context.Background(), checkRequest(nil, nil))
+	c.Assert(err, IsNil)
+	c.Check(resp.Status.Code, Equals, int32(code.Code_UNAUTHENTICATED))
+	c.Check(resp.GetDeniedResponse().Status.Code, Equals, typev3.StatusCode_Unauthorized)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Err, ErrorMatches, "missing challenge response header 'X-Recaptcha-Token'")
+
+	_, err = server.Check(context.Background(), checkRequest(nil, map[string]string{ThresholdExtension: "high"}))
+	c.Check(grpcstatus.Code(err), Equals, codes.InvalidArgument)
+}
+
+func (s *ExtAuthzSuite) TestCheckPolicy(c *C) {
+	server, _ := NewServer(&mockVerifier{}, recaptcha.VerifyOption{})
+	server.Header = "X-Captcha"
+	server.Policy = &recaptcha.Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	resp, err := server.Check(context.Background(), checkRequest(map[string]string{"x-captcha": "valid"}, nil))
+	c.Assert(err, IsNil)
+	c.Check(resp.Status.Code, Equals, int32(code.Code_PERMISSION_DENIED))
+	c.Check(headers(resp.GetDeniedResponse().Headers)[recaptcha.DecisionHeader], Equals, "challenge")
+
+	server.Policy = &recaptcha.Policy{QuarantineBelow: 0.7}
+	resp, err = server.Check(context.Background(), checkRequest(map[string]string{"x-captcha": "valid"}, nil))
+	c.Assert(err, IsNil)
+	c.Check(headers(resp.GetOkResponse().Headers)[recaptcha.DecisionHeader], Equals, "quarantine")
+}
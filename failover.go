@@ -0,0 +1,166 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultFailoverCooldown time a failing endpoint is tried last by Failover when Cooldown is zero.
+const DefaultFailoverCooldown = 30 * time.Second
+
+// Failover ordered list of verification endpoints, e.g. google.com, recaptcha.net and an internal
+// proxy: the requests go to the first healthy endpoint and fail over to the next ones on connection
+// errors, timeouts and 5xx statuses. A failing endpoint is tried last during the cooldown.
+type Failover struct {
+	// AttemptTimeout time budget of the request to each endpoint, unbounded when zero so only the
+	// client timeout applies.
+	AttemptTimeout time.Duration
+	// Cooldown time a failing endpoint is tried last, DefaultFailoverCooldown when zero.
+	Cooldown time.Duration
+
+	endpoints []*url.URL
+	mu        sync.Mutex
+	health    []EndpointHealth
+	now       func() time.Time
+}
+
+// EndpointHealth request accounting of a Failover endpoint.
+type EndpointHealth struct {
+	Endpoint  string
+	Successes int64
+	Failures  int64
+	// DownUntil end of the cooldown of the endpoint, zero when healthy.
+	DownUntil time.Time
+}
+
+// NewFailover new Failover over the endpoints, full verification URLs such as
+// RecaptchaNetBaseURL + "/recaptcha/api/siteverify"
+func NewFailover(endpoints ...string) (*Failover, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("failover endpoints cannot be empty")
+	}
+	f := &Failover{now: time.Now}
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid failover endpoint '%s', while expecting an absolute http(s) URL", endpoint)
+		}
+		f.endpoints = append(f.endpoints, parsed)
+		f.health = append(f.health, EndpointHealth{Endpoint: endpoint})
+	}
+	return f, nil
+}
+
+// Health returns the request accounting of the endpoints, in order.
+func (f *Failover) Health() []EndpointHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]EndpointHealth(nil), f.health...)
+}
+
+// order returns the indexes of the endpoints to try: the healthy ones first, in order.
+func (f *Failover) order() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	order := make([]int, 0, len(f.health))
+	for i, health := range f.health {
+		if !now.Before(health.DownUntil) {
+			order = append(order, i)
+		}
+	}
+	for i, health := range f.health {
+		if now.Before(health.DownUntil) {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+func (f *Failover) record(i int, failed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !failed {
+		f.health[i].Successes++
+		f.health[i].DownUntil = time.Time{}
+		return
+	}
+	cooldown := f.Cooldown
+	if cooldown == 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	f.health[i].Failures++
+	f.health[i].DownUntil = f.now().Add(cooldown)
+}
+
+// cancelBody response body canceling the context of its request once closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// WithFailover Decorator sending the verification requests to the endpoints of failover instead of
+// ReCAPTCHA.ReCAPTCHALink. The last failure is returned when every endpoint failed.
+func WithFailover(failover *Failover) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(req.Body); err != nil {
+					return nil, err
+				}
+				req.Body.Close()
+			}
+
+			var resp *http.Response
+			var err error
+			for n, i := range failover.order() {
+				if n > 0 && resp != nil {
+					resp.Body.Close()
+				}
+				ctx, cancel := req.Context(), context.CancelFunc(func() {})
+				if failover.AttemptTimeout > 0 {
+					ctx, cancel = context.WithTimeout(ctx, failover.AttemptTimeout)
+				}
+				clone := req.Clone(ctx)
+				clone.URL = failover.endpoints[i]
+				clone.Host = ""
+				if body != nil {
+					clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
+				resp, err = next.RoundTrip(clone)
+				if req.Context().Err() != nil {
+					// the caller gave up, the endpoint isn't to blame
+					cancel()
+					return resp, err
+				}
+				failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+				failover.record(i, failed)
+				if !failed {
+					resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+					return resp, nil
+				}
+				if resp != nil {
+					resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+				} else {
+					cancel()
+				}
+			}
+			return resp, err
+		})
+	}
+}
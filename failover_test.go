@@ -0,0 +1,75 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FailoverSuite struct{}
+
+var _ = Suite(&FailoverSuite{})
+
+func (s *FailoverSuite) TestWithFailover(c *C) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer up.Close()
+
+	_, err := NewFailover()
+	c.Check(err, ErrorMatches, "failover endpoints cannot be empty")
+	_, err = NewFailover(up.URL, "/recaptcha/api/siteverify")
+	c.Check(err, ErrorMatches, "invalid failover endpoint '/recaptcha/api/siteverify', while expecting an absolute http\\(s\\) URL")
+
+	failover, err := NewFailover(down.URL, slow.URL, up.URL)
+	c.Assert(err, IsNil)
+	failover.AttemptTimeout = 50 * time.Millisecond
+	now := time.Now()
+	failover.now = func() time.Time { return now }
+	captcha, err := NewReCAPTCHA("secret", V2, 2*time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(captcha.Decorate(WithFailover(failover)), IsNil)
+
+	c.Check(captcha.Verify("token"), IsNil)
+	health := failover.Health()
+	c.Check(health[0].Failures, Equals, int64(1))
+	c.Check(health[1].Failures, Equals, int64(1))
+	c.Check(health[2].Successes, Equals, int64(1))
+	c.Check(health[0].DownUntil, Equals, now.Add(DefaultFailoverCooldown))
+
+	// the failing endpoints are tried last during their cooldown
+	c.Check(captcha.Verify("token"), IsNil)
+	health = failover.Health()
+	c.Check(health[0].Failures, Equals, int64(1))
+	c.Check(health[2].Successes, Equals, int64(2))
+
+	now = now.Add(DefaultFailoverCooldown)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(failover.Health()[0].Failures, Equals, int64(2))
+
+	// the last failure is returned when every endpoint failed
+	failover, err = NewFailover(slow.URL, down.URL)
+	c.Assert(err, IsNil)
+	failover.AttemptTimeout = 50 * time.Millisecond
+	captcha, err = NewReCAPTCHA("secret", V2, 2*time.Second)
+	c.Assert(err, IsNil)
+	c.Assert(captcha.Decorate(WithFailover(failover)), IsNil)
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json: .*")
+}
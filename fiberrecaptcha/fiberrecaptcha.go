@@ -0,0 +1,113 @@
+// Package fiberrecaptcha adapts recaptcha verification to the Fiber web framework, working on the
+// fasthttp request directly and setting the verification result in the context locals.
+package fiberrecaptcha
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// ResultKey context locals key of the Verification.
+const ResultKey = "recaptcha.result"
+
+// Verification verification result set in the context locals of every verified request.
+type Verification struct {
+	Response recaptcha.Response
+	Err      error
+	Outcome  recaptcha.Outcome
+}
+
+// Middleware Fiber middleware verifying the challenge response of the requests, only calling the next
+// handlers of allowed and quarantined requests. The challenge response is read from Header, then from
+// the Field of form or JSON bodies, then from the Query parameter.
+type Middleware struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options recaptcha.VerifyOption
+	// Header request header holding the challenge response, recaptcha.DefaultTokenHeader when blank.
+	Header string
+	// Field form field or JSON body property holding the challenge response,
+	// recaptcha.DefaultResponseField when blank.
+	Field string
+	// Query query parameter holding the challenge response, not read when blank.
+	Query string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// Denied handles the rejected requests, a 403 JSON error when nil. The result is available
+	// through ResultFromContext.
+	Denied fiber.Handler
+}
+
+// New new Middleware verifying requests with verifier using options
+func New(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Middleware {
+	return &Middleware{Verifier: verifier, Options: options}
+}
+
+// Handler returns the fiber.Handler verifying the requests, to pass to app.Use or a route.
+func (m *Middleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := m.Options
+		if options.RemoteIP == "" {
+			options.RemoteIP = c.IP()
+		}
+		ctx := c.UserContext()
+		response, err := recaptcha.VerifyContext(ctx, m.Verifier, m.token(c), options)
+		outcome := recaptcha.DecideWith(ctx, m.Decider, m.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+		c.Locals(ResultKey, Verification{Response: response, Err: err, Outcome: outcome})
+
+		if outcome.Decision != recaptcha.Allow && outcome.Decision != recaptcha.Quarantine {
+			if m.Denied != nil {
+				return m.Denied(c)
+			}
+			body := fiber.Map{"decision": outcome.Decision.String()}
+			if err != nil {
+				body["error"] = err.Error()
+			}
+			return c.Status(http.StatusForbidden).JSON(body)
+		}
+		return c.Next()
+	}
+}
+
+// ResultFromContext returns the verification result of a request verified by Middleware
+func ResultFromContext(c *fiber.Ctx) (Verification, bool) {
+	result, ok := c.Locals(ResultKey).(Verification)
+	return result, ok
+}
+
+func (m *Middleware) token(c *fiber.Ctx) string {
+	header := m.Header
+	if header == "" {
+		header = recaptcha.DefaultTokenHeader
+	}
+	if token := c.Get(header); token != "" {
+		return token
+	}
+
+	field := m.Field
+	if field == "" {
+		field = recaptcha.DefaultResponseField
+	}
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		var body map[string]json.RawMessage
+		var token string
+		// an invalid body only means there's no token in it
+		if json.Unmarshal(c.Body(), &body) == nil && json.Unmarshal(body[field], &token) == nil && token != "" {
+			return token
+		}
+	} else if token := c.FormValue(field); token != "" {
+		return token
+	}
+
+	if m.Query != "" {
+		return c.Query(m.Query)
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package fiberrecaptcha
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type FiberSuite struct{}
+
+var _ = Suite(&FiberSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct{}
+
+func (mockVerifier) Verify(challengeResponse string) error {
+	return mockVerifier{}.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := mockVerifier{}.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6}, nil
+}
+
+func send(c *C, app *fiber.App, r *http.Request) (int, string) {
+	resp, err := app.Test(r)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return resp.StatusCode, string(body)
+}
+
+func form(challengeResponse string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{recaptcha.DefaultResponseField: {challengeResponse}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func (s *FiberSuite) TestMiddleware(c *C) {
+	middleware := New(mockVerifier{}, recaptcha.VerifyOption{})
+	middleware.Query = "token"
+	app := fiber.New()
+	app.Post("/login", middleware.Handler(), func(ctx *fiber.Ctx) error {
+		result, ok := ResultFromContext(ctx)
+		c.Check(ok, Equals, true)
+		return ctx.SendString(fmt.Sprintf("%.1f %s", result.Response.Score, result.Outcome.Decision))
+	})
+
+	code, body := send(c, app, form("valid"))
+	c.Check(code, Equals, http.StatusOK)
+	c.Check(body, Equals, "0.6 allow")
+
+	code, body = send(c, app, form("invalid"))
+	c.Check(code, Equals, http.StatusForbidden)
+	c.Check(body, Equals, `{"decision":"block","error":"remote error codes: [invalid-input-response]"}`)
+
+	r := form("invalid")
+	r.Header.Set(recaptcha.DefaultTokenHeader, "valid")
+	code, _ = send(c, app, r)
+	c.Check(code, Equals, http.StatusOK)
+
+	r = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"g-recaptcha-response": "valid"}`))
+	r.Header.Set("Content-Type", "application/json")
+	code, _ = send(c, app, r)
+	c.Check(code, Equals, http.StatusOK)
+
+	code, _ = send(c, app, httptest.NewRequest(http.MethodPost, "/login?token=valid", nil))
+	c.Check(code, Equals, http.StatusOK)
+	code, _ = send(c, app, httptest.NewRequest(http.MethodPost, "/login", nil))
+	c.Check(code, Equals, http.StatusForbidden)
+
+	middleware.Policy = &recaptcha.Policy{ChallengeBelow: 0.7}
+	middleware.Denied = func(ctx *fiber.Ctx) error {
+		result, _ := ResultFromContext(ctx)
+		return ctx.Status(http.StatusUnauthorized).SendString(result.Outcome.Decision.String())
+	}
+	code, body = send(c, app, form("valid"))
+	c.Check(code, Equals, http.StatusUnauthorized)
+	c.Check(body, Equals, "challenge")
+}
@@ -0,0 +1,72 @@
+package recaptcha
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DefaultFormTimeField hidden form field holding the signed render timestamp checked by Middleware
+const DefaultFormTimeField = "recaptcha-form-time"
+
+// DefaultMaxFillTime age of the stamps rejected by FormTimer when MaxFillTime is zero. The stamps can
+// be submitted several times, bounding their age keeps a single stamp from being replayed forever.
+const DefaultMaxFillTime = time.Hour
+
+// FormTimer signs the time a form was served and rejects submissions completed faster than a human
+// could, a cheap bot signal complementing VerifyOption.ResponseTime.
+type FormTimer struct {
+	// Key secret used to sign the timestamps.
+	Key []byte
+	// MinFillTime submissions completed faster are rejected.
+	MinFillTime time.Duration
+	// MaxFillTime submissions of older forms are rejected, DefaultMaxFillTime when zero and unlimited
+	// when negative.
+	MaxFillTime time.Duration
+
+	now func() time.Time
+}
+
+// NewFormTimer new FormTimer rejecting forms filled faster than minFillTime, timestamps are signed with key.
+func NewFormTimer(key []byte, minFillTime time.Duration) (*FormTimer, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("form timer key cannot be blank")
+	}
+	return &FormTimer{Key: key, MinFillTime: minFillTime, now: time.Now}, nil
+}
+
+// Stamp returns the signed current time, render it in a hidden field of the served form.
+func (f *FormTimer) Stamp() string {
+	return seal(f.Key, purposeFormStamp, strconv.FormatInt(f.clock().UnixNano(), 10))
+}
+
+// Check returns `nil` if the stamp is valid and the form was filled within the allowed duration.
+func (f *FormTimer) Check(stamp string) error {
+	payload, ok := unseal(f.Key, purposeFormStamp, stamp)
+	if !ok {
+		return &Error{msg: "invalid form timestamp"}
+	}
+	rendered, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return &Error{msg: "invalid form timestamp"}
+	}
+	elapsed := f.clock().Sub(time.Unix(0, rendered))
+	if elapsed < f.MinFillTime {
+		return &Error{msg: fmt.Sprintf("form filled in '%fs', while expecting minimum '%fs'", elapsed.Seconds(), f.MinFillTime.Seconds())}
+	}
+	maxFillTime := f.MaxFillTime
+	if maxFillTime == 0 {
+		maxFillTime = DefaultMaxFillTime
+	}
+	if maxFillTime > 0 && elapsed > maxFillTime {
+		return &Error{msg: fmt.Sprintf("form filled in '%fs', while expecting maximum '%fs'", elapsed.Seconds(), maxFillTime.Seconds())}
+	}
+	return nil
+}
+
+func (f *FormTimer) clock() time.Time {
+	if f.now == nil {
+		return time.Now()
+	}
+	return f.now()
+}
@@ -0,0 +1,77 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FormTimerSuite struct{}
+
+var _ = Suite(&FormTimerSuite{})
+
+func (s *FormTimerSuite) TestNewFormTimer(c *C) {
+	_, err := NewFormTimer(nil, time.Second)
+	c.Check(err, ErrorMatches, "form timer key cannot be blank")
+}
+
+func (s *FormTimerSuite) TestCheck(c *C) {
+	now := time.Now()
+	timer, err := NewFormTimer([]byte("key"), 3*time.Second)
+	c.Assert(err, IsNil)
+	timer.MaxFillTime = 2 * time.Hour
+	timer.now = func() time.Time { return now }
+	stamp := timer.Stamp()
+
+	now = now.Add(time.Second)
+	c.Check(timer.Check(stamp), ErrorMatches, "form filled in '1.000000s', while expecting minimum '3.000000s'")
+	now = now.Add(time.Minute)
+	c.Check(timer.Check(stamp), IsNil)
+	now = now.Add(2 * time.Hour)
+	c.Check(timer.Check(stamp), ErrorMatches, "form filled in '7261.000000s', while expecting maximum '7200.000000s'")
+	timer.MaxFillTime = -1
+	c.Check(timer.Check(stamp), IsNil)
+
+	c.Check(timer.Check(""), ErrorMatches, "invalid form timestamp")
+	other, _ := NewFormTimer([]byte("other key"), 0)
+	c.Check(other.Check(stamp), ErrorMatches, "invalid form timestamp")
+}
+
+func (s *FormTimerSuite) TestDefaultMaxFillTime(c *C) {
+	now := time.Now()
+	timer, err := NewFormTimer([]byte("key"), 0)
+	c.Assert(err, IsNil)
+	timer.now = func() time.Time { return now }
+	stamp := timer.Stamp()
+
+	now = now.Add(DefaultMaxFillTime)
+	c.Check(timer.Check(stamp), IsNil)
+	now = now.Add(time.Second)
+	c.Check(timer.Check(stamp), ErrorMatches, "form filled in '3601.000000s', while expecting maximum '3600.000000s'")
+}
+
+func (s *FormTimerSuite) TestMiddlewareFormTimer(c *C) {
+	now := time.Now()
+	timer, _ := NewFormTimer([]byte("key"), 3*time.Second)
+	timer.now = func() time.Time { return now }
+	var events []Event
+	middleware := NewMiddleware(passVerifier(), VerifyOption{})
+	middleware.FormTimer = timer
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+	handler := middleware.Handler(okHandler)
+	form := url.Values{"g-recaptcha-response": {"valid"}, DefaultFormTimeField: {timer.Stamp()}}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(form))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, EventFormTime)
+
+	now = now.Add(5 * time.Second)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(form))
+	c.Check(w.Code, Equals, http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultTokenHeader request header holding the challenge response checked by ForwardAuth
+const DefaultTokenHeader = "X-Recaptcha-Token"
+
+// Response headers set by ForwardAuth, for the reverse proxy to copy to the upstream request.
+const (
+	ScoreHeader    = "X-Recaptcha-Score"
+	ActionHeader   = "X-Recaptcha-Action"
+	DecisionHeader = "X-Recaptcha-Decision"
+)
+
+// ForwardAuth http.Handler implementing the forward-auth contract of reverse proxies such as Traefik
+// (forwardAuth middleware) or Caddy (forward_auth directive), so captcha can be enforced on routes
+// without touching the application. Proxies don't forward the request body, the challenge response
+// is read from a header or a cookie. It replies 200 for allowed and quarantined requests, 401 when
+// the challenge response is missing and 403 otherwise, echoing the score, action and decision in
+// the ScoreHeader, ActionHeader and DecisionHeader headers.
+type ForwardAuth struct {
+	Verifier Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options VerifyOption
+	// Header request header holding the challenge response, DefaultTokenHeader when blank.
+	Header string
+	// Cookie cookie holding the challenge response when the header is missing, unused when blank.
+	Cookie string
+	// RemoteIPHeader header set by the proxy with the client IP, its last address is used, e.g.
+	// X-Forwarded-For or X-Real-IP. The connection remote address is used when blank.
+	RemoteIPHeader string
+	// URIHeader header set by the proxy with the original request URI, added to the events context
+	// as "uri" when present.
+	URIHeader string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider Decider
+	// Observers receive the verification events.
+	Observers []Observer
+}
+
+// NewForwardAuth new ForwardAuth verifying requests with verifier using options, taking the client
+// IP and original URI from the X-Forwarded-For and X-Forwarded-Uri headers
+func NewForwardAuth(verifier Verifier, options VerifyOption) *ForwardAuth {
+	return &ForwardAuth{Verifier: verifier, Options: options, RemoteIPHeader: "X-Forwarded-For", URIHeader: "X-Forwarded-Uri"}
+}
+
+// NewAuthRequest new ForwardAuth tailored to Nginx auth_request subrequests, reading the challenge
+// response from header, set with proxy_set_header, and taking the client IP and original URI from
+// the X-Real-IP and X-Original-URI headers. Nginx only accepts 2xx, 401 and 403 replies, which are
+// the only ones ForwardAuth makes.
+func NewAuthRequest(verifier Verifier, options VerifyOption, header string) *ForwardAuth {
+	return &ForwardAuth{Verifier: verifier, Options: options, Header: header, RemoteIPHeader: "X-Real-IP", URIHeader: "X-Original-URI"}
+}
+
+// ServeHTTP verifies the challenge response of the forwarded request, the request method is ignored
+// and its body never read.
+func (f *ForwardAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	options := f.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = f.remoteIP(r)
+	}
+	if f.URIHeader != "" {
+		if uri := r.Header.Get(f.URIHeader); uri != "" {
+			options.Context = mergeContext(options.Context, map[string]string{"uri": uri})
+		}
+	}
+
+	token := f.token(r)
+	if token == "" {
+		err := &Error{msg: fmt.Sprintf("missing challenge response header '%s'", f.header())}
+		outcome := Outcome{Decision: Block, Reasons: []string{err.Error()}}
+		notify(f.Observers, newEvent(EventVerification, options, Response{}, err, outcome))
+		w.Header().Set(DecisionHeader, outcome.Decision.String())
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	response, err := VerifyContext(r.Context(), f.Verifier, token, options)
+	outcome := DecideWith(r.Context(), f.Decider, f.Policy, VerifyResult{Response: response, Err: err}, options)
+	notify(f.Observers, newEvent(EventVerification, options, response, err, outcome))
+
+	w.Header().Set(ScoreHeader, fmt.Sprintf("%.2f", response.Score))
+	w.Header().Set(ActionHeader, response.Action)
+	w.Header().Set(DecisionHeader, outcome.Decision.String())
+	if outcome.Decision != Allow && outcome.Decision != Quarantine {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *ForwardAuth) token(r *http.Request) string {
+	if token := r.Header.Get(f.header()); token != "" {
+		return token
+	}
+	if f.Cookie != "" {
+		if cookie, err := r.Cookie(f.Cookie); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (f *ForwardAuth) remoteIP(r *http.Request) string {
+	if f.RemoteIPHeader != "" {
+		// the last address is the one added by the proxy, earlier ones are set by the client
+		addresses := strings.Split(r.Header.Get(f.RemoteIPHeader), ",")
+		if ip := strings.TrimSpace(addresses[len(addresses)-1]); ip != "" {
+			return ip
+		}
+	}
+	return remoteIP(r)
+}
+
+func (f *ForwardAuth) header() string {
+	if f.Header == "" {
+		return DefaultTokenHeader
+	}
+	return f.Header
+}
@@ -0,0 +1,99 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type ForwardAuthSuite struct{}
+
+var _ = Suite(&ForwardAuthSuite{})
+
+func (s *ForwardAuthSuite) TestForwardAuth(c *C) {
+	var options VerifyOption
+	verifier := &mockResponseVerifier{response: Response{Success: true, Score: 0.9, Action: "login"}}
+	forwardAuth := NewForwardAuth(verifier, VerifyOption{Action: "login"})
+	var events []Event
+	forwardAuth.Observers = []Observer{ObserverFunc(func(event Event) {
+		events = append(events, event)
+		options.RemoteIP, options.Context = event.RemoteIP, event.Context
+	})}
+
+	r := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r.Header.Set(DefaultTokenHeader, "token")
+	r.Header.Set("X-Forwarded-For", "203.0.113.1, 198.51.100.7")
+	r.Header.Set("X-Forwarded-Uri", "/login")
+	w := httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Header().Get(ScoreHeader), Equals, "0.90")
+	c.Check(w.Header().Get(ActionHeader), Equals, "login")
+	c.Check(w.Header().Get(DecisionHeader), Equals, "allow")
+	c.Check(options.RemoteIP, Equals, "198.51.100.7")
+	c.Check(options.Context, DeepEquals, map[string]string{"uri": "/login"})
+
+	w = httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth", nil))
+	c.Check(w.Code, Equals, http.StatusUnauthorized)
+	c.Check(events[len(events)-1].Err, ErrorMatches, "missing challenge response header 'X-Recaptcha-Token'")
+
+	forwardAuth.Cookie = "recaptcha"
+	r = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r.AddCookie(&http.Cookie{Name: "recaptcha", Value: "token"})
+	w = httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(options.RemoteIP, Equals, "192.0.2.1")
+}
+
+func (s *ForwardAuthSuite) TestForwardAuthDecisions(c *C) {
+	forwardAuth := NewForwardAuth(failVerifier("remote error codes: [timeout-or-duplicate]"), VerifyOption{})
+	r := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r.Header.Set(DefaultTokenHeader, "token")
+	w := httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(w.Header().Get(DecisionHeader), Equals, "block")
+
+	forwardAuth.Verifier = &mockResponseVerifier{response: Response{Success: true, Score: 0.5}}
+	forwardAuth.Policy = &Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	w = httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(w.Header().Get(DecisionHeader), Equals, "challenge")
+
+	forwardAuth.Policy = &Policy{QuarantineBelow: 0.7}
+	w = httptest.NewRecorder()
+	forwardAuth.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Header().Get(DecisionHeader), Equals, "quarantine")
+}
+
+func (s *ForwardAuthSuite) TestAuthRequest(c *C) {
+	var event Event
+	authRequest := NewAuthRequest(&mockResponseVerifier{response: Response{Success: true, Score: 0.7}}, VerifyOption{}, "X-Captcha")
+	authRequest.Observers = []Observer{ObserverFunc(func(e Event) { event = e })}
+
+	// subrequests keep the original method and headers, without the body
+	r := httptest.NewRequest(http.MethodPost, "/_recaptcha", strings.NewReader(""))
+	r.Header.Set("Content-Length", "42")
+	r.Header.Set("X-Captcha", "token")
+	r.Header.Set("X-Real-IP", "203.0.113.1")
+	r.Header.Set("X-Original-URI", "/admin/login")
+	w := httptest.NewRecorder()
+	authRequest.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Header().Get(ScoreHeader), Equals, "0.70")
+	c.Check(event.RemoteIP, Equals, "203.0.113.1")
+	c.Check(event.Context, DeepEquals, map[string]string{"uri": "/admin/login"})
+
+	r.Header.Del("X-Captcha")
+	r.Header.Set(DefaultTokenHeader, "token")
+	w = httptest.NewRecorder()
+	authRequest.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusUnauthorized)
+	c.Check(event.Err, ErrorMatches, "missing challenge response header 'X-Captcha'")
+}
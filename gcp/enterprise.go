@@ -0,0 +1,146 @@
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+const assessmentsLink = "https://recaptchaenterprise.googleapis.com/v1/projects/%s/assessments"
+
+// Enterprise recaptcha.Verifier creating reCAPTCHA Enterprise assessments. The HTTP client must be
+// authorized for the https://www.googleapis.com/auth/cloud-platform scope, on Cloud Run and Cloud
+// Functions the Application Default Credentials of the service account are used with
+// golang.org/x/oauth2/google.DefaultClient.
+type Enterprise struct {
+	// Client OAuth2 authorized HTTP client.
+	Client *http.Client
+	// ProjectID Google Cloud project of the reCAPTCHA Enterprise keys.
+	ProjectID string
+	// SiteKey reCAPTCHA Enterprise key of the site.
+	SiteKey string
+
+	link string
+}
+
+var _ recaptcha.Verifier = (*Enterprise)(nil)
+
+// NewEnterprise new Enterprise assessing tokens of siteKey in projectID with the OAuth2 authorized client
+func NewEnterprise(client *http.Client, projectID, siteKey string) (*Enterprise, error) {
+	if client == nil {
+		return nil, fmt.Errorf("enterprise client cannot be nil")
+	}
+	if projectID == "" || siteKey == "" {
+		return nil, fmt.Errorf("enterprise project ID and site key cannot be blank")
+	}
+	return &Enterprise{Client: client, ProjectID: projectID, SiteKey: siteKey}, nil
+}
+
+// Verify returns `nil` if the token is valid and its score isn't below recaptcha.DefaultThreshold
+func (e *Enterprise) Verify(token string) error {
+	_, err := e.VerifyWithOptionsResponse(token, recaptcha.VerifyOption{})
+	return err
+}
+
+// VerifyWithOptions same as Verify, checking the Threshold, Action, Hostname and ApkPackageName options
+func (e *Enterprise) VerifyWithOptions(token string, options recaptcha.VerifyOption) error {
+	_, err := e.VerifyWithOptionsResponse(token, options)
+	return err
+}
+
+type assessment struct {
+	TokenProperties struct {
+		Valid              bool      `json:"valid"`
+		InvalidReason      string    `json:"invalidReason"`
+		Hostname           string    `json:"hostname"`
+		AndroidPackageName string    `json:"androidPackageName"`
+		Action             string    `json:"action"`
+		CreateTime         time.Time `json:"createTime"`
+	} `json:"tokenProperties"`
+	RiskAnalysis struct {
+		Score   float32  `json:"score"`
+		Reasons []string `json:"reasons"`
+	} `json:"riskAnalysis"`
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the verification response
+// derived from the assessment, its ErrorCodes holding the invalid reason or the risk reasons.
+func (e *Enterprise) VerifyWithOptionsResponse(token string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	event := map[string]string{"token": token, "siteKey": e.SiteKey}
+	if options.Action != "" {
+		event["expectedAction"] = options.Action
+	}
+	if options.RemoteIP != "" {
+		event["userIpAddress"] = options.RemoteIP
+	}
+	body, err := json.Marshal(map[string]interface{}{"event": event})
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+	link := e.link
+	if link == "" {
+		link = assessmentsLink
+	}
+	resp, err := e.Client.Post(fmt.Sprintf(link, url.PathEscape(e.ProjectID)), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return recaptcha.Response{}, fmt.Errorf("error posting to recaptcha enterprise endpoint: '%s'", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return recaptcha.Response{}, fmt.Errorf("couldn't read response body: '%s'", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return recaptcha.Response{}, fmt.Errorf("recaptcha enterprise endpoint replied '%s': %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	var result assessment
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return recaptcha.Response{}, fmt.Errorf("invalid response body json: '%s'", err)
+	}
+
+	properties := result.TokenProperties
+	response := recaptcha.Response{
+		Success:        properties.Valid,
+		ChallengeTS:    properties.CreateTime,
+		Hostname:       properties.Hostname,
+		ApkPackageName: properties.AndroidPackageName,
+		Action:         properties.Action,
+		Score:          result.RiskAnalysis.Score,
+		ErrorCodes:     result.RiskAnalysis.Reasons,
+	}
+	if !properties.Valid {
+		response.ErrorCodes = []string{properties.InvalidReason}
+		return response, fmt.Errorf("invalid token '%s'", properties.InvalidReason)
+	}
+	if err := check(response, options); err != nil {
+		response.Success = false
+		return response, err
+	}
+	return response, nil
+}
+
+func check(response recaptcha.Response, options recaptcha.VerifyOption) error {
+	if options.Hostname != "" && options.Hostname != response.Hostname {
+		return fmt.Errorf("invalid response hostname '%s', while expecting '%s'", response.Hostname, options.Hostname)
+	}
+	if options.ApkPackageName != "" && options.ApkPackageName != response.ApkPackageName {
+		return fmt.Errorf("invalid response ApkPackageName '%s', while expecting '%s'", response.ApkPackageName, options.ApkPackageName)
+	}
+	if options.Action != "" && options.Action != response.Action {
+		return fmt.Errorf("invalid response action '%s', while expecting '%s'", response.Action, options.Action)
+	}
+	threshold := recaptcha.DefaultThreshold
+	if options.Threshold != 0 {
+		threshold = options.Threshold
+	}
+	if threshold > response.Score {
+		return fmt.Errorf("received score '%f', while expecting minimum '%f'", response.Score, threshold)
+	}
+	return nil
+}
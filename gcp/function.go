@@ -0,0 +1,127 @@
+// Package gcp deploys recaptcha verification on Google Cloud Run and Cloud Functions: Function is a
+// functions-framework compatible HTTP handler and Enterprise a verifier for reCAPTCHA Enterprise
+// assessments authorized with Application Default Credentials.
+//
+// Cloud Functions wiring, with the Functions Framework for Go:
+//
+//	func init() {
+//		client, _ := google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/cloud-platform")
+//		enterprise, _ := gcp.NewEnterprise(client, os.Getenv("GOOGLE_CLOUD_PROJECT"), os.Getenv("RECAPTCHA_SITE_KEY"))
+//		functions.HTTP("Verify", gcp.NewFunction(enterprise, recaptcha.VerifyOption{Threshold: 0.5}).ServeHTTP)
+//	}
+//
+// On Cloud Run the same handler is served with ListenAndServe.
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// FunctionRequest JSON body of the requests handled by Function.
+type FunctionRequest struct {
+	Token  string `json:"token"`
+	Action string `json:"action,omitempty"`
+}
+
+// FunctionResponse JSON body replied by Function, failed verifications are reported with a 200.
+type FunctionResponse struct {
+	Success  bool               `json:"success"`
+	Decision string             `json:"decision"`
+	Reasons  []string           `json:"reasons,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	Result   recaptcha.Response `json:"result"`
+}
+
+// Function http.Handler verifying the token posted as JSON FunctionRequest.
+type Function struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, Action is overridden by the request one. RemoteIP is set
+	// from the X-Forwarded-For header added by Google's front end when blank.
+	Options recaptcha.VerifyOption
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// AllowOrigin when set answers CORS preflight requests and allows browser calls from this origin.
+	AllowOrigin string
+}
+
+// NewFunction new Function verifying tokens with verifier using options
+func NewFunction(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Function {
+	return &Function{Verifier: verifier, Options: options}
+}
+
+// ServeHTTP verifies the token of a POST request and replies with a JSON FunctionResponse.
+func (f *Function) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", f.AllowOrigin)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	var req FunctionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body json: '%s'", err), http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "token cannot be blank", http.StatusBadRequest)
+		return
+	}
+
+	options := f.Options
+	if req.Action != "" {
+		options.Action = req.Action
+	}
+	if options.RemoteIP == "" {
+		options.RemoteIP = clientIP(r)
+	}
+	response, err := recaptcha.VerifyContext(r.Context(), f.Verifier, req.Token, options)
+	outcome := recaptcha.DecideWith(r.Context(), f.Decider, f.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+
+	body := FunctionResponse{Success: err == nil, Decision: outcome.Decision.String(), Reasons: outcome.Reasons, Result: response}
+	if err != nil {
+		body.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// clientIP returns the last X-Forwarded-For address, appended by Google's front end with the client
+// IP, earlier ones being set by the client.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addresses[len(addresses)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ListenAndServe serves handler on the port set by Cloud Run in the PORT environment variable,
+// 8080 when unset.
+func ListenAndServe(handler http.Handler) error {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	return http.ListenAndServe(":"+port, handler)
+}
@@ -0,0 +1,111 @@
+package gcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type GCPSuite struct {
+	server     *httptest.Server
+	enterprise *Enterprise
+	events     []map[string]string
+}
+
+var _ = Suite(&GCPSuite{})
+
+const assessmentBody = `{
+	"tokenProperties": {"valid": true, "hostname": "example.com", "action": "login", "createTime": "2024-01-01T00:00:00Z"},
+	"riskAnalysis": {"score": 0.7, "reasons": ["UNEXPECTED_ENVIRONMENT"]}
+}`
+
+func (s *GCPSuite) SetUpTest(c *C) {
+	s.events = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/projects/my-project/assessments")
+		var body struct {
+			Event map[string]string `json:"event"`
+		}
+		c.Check(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		s.events = append(s.events, body.Event)
+		switch body.Event["token"] {
+		case "valid":
+			w.Write([]byte(assessmentBody))
+		case "expired":
+			w.Write([]byte(`{"tokenProperties": {"valid": false, "invalidReason": "EXPIRED"}}`))
+		default:
+			http.Error(w, `{"error": {"message": "permission denied"}}`, http.StatusForbidden)
+		}
+	}))
+	var err error
+	s.enterprise, err = NewEnterprise(s.server.Client(), "my-project", "site-key")
+	c.Assert(err, IsNil)
+	s.enterprise.link = s.server.URL + "/v1/projects/%s/assessments"
+}
+
+func (s *GCPSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *GCPSuite) TestNewEnterprise(c *C) {
+	_, err := NewEnterprise(nil, "my-project", "site-key")
+	c.Check(err, ErrorMatches, "enterprise client cannot be nil")
+	_, err = NewEnterprise(http.DefaultClient, "", "site-key")
+	c.Check(err, ErrorMatches, "enterprise project ID and site key cannot be blank")
+}
+
+func (s *GCPSuite) TestEnterprise(c *C) {
+	response, err := s.enterprise.VerifyWithOptionsResponse("valid", recaptcha.VerifyOption{Action: "login", RemoteIP: "203.0.113.1"})
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(response.Score, Equals, float32(0.7))
+	c.Check(response.ErrorCodes, DeepEquals, []string{"UNEXPECTED_ENVIRONMENT"})
+	c.Check(response.ChallengeTS.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Equals, true)
+	c.Check(s.events[0], DeepEquals, map[string]string{"token": "valid", "siteKey": "site-key", "expectedAction": "login", "userIpAddress": "203.0.113.1"})
+
+	c.Check(s.enterprise.VerifyWithOptions("valid", recaptcha.VerifyOption{Threshold: 0.8}), ErrorMatches, "received score '0.700000', while expecting minimum '0.800000'")
+	c.Check(s.enterprise.VerifyWithOptions("valid", recaptcha.VerifyOption{Hostname: "other.com"}), ErrorMatches, "invalid response hostname 'example.com', while expecting 'other.com'")
+	c.Check(s.enterprise.VerifyWithOptions("valid", recaptcha.VerifyOption{Action: "signup"}), ErrorMatches, "invalid response action 'login', while expecting 'signup'")
+	c.Check(s.enterprise.Verify("expired"), ErrorMatches, "invalid token 'EXPIRED'")
+	c.Check(s.enterprise.Verify("other"), ErrorMatches, "recaptcha enterprise endpoint replied '403 Forbidden'.*permission denied.*")
+}
+
+func (s *GCPSuite) TestFunction(c *C) {
+	function := NewFunction(s.enterprise, recaptcha.VerifyOption{Threshold: 0.5})
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"token": "valid", "action": "login"}`))
+	r.Header.Set("X-Forwarded-For", "10.0.0.1, 203.0.113.1")
+	w := httptest.NewRecorder()
+	function.ServeHTTP(w, r)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	var body FunctionResponse
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &body), IsNil)
+	c.Check(body.Success, Equals, true)
+	c.Check(body.Decision, Equals, "allow")
+	c.Check(s.events[0]["userIpAddress"], Equals, "203.0.113.1")
+
+	w = httptest.NewRecorder()
+	function.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"token": "expired"}`)))
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &body), IsNil)
+	c.Check(body.Success, Equals, false)
+	c.Check(body.Decision, Equals, "block")
+	c.Check(body.Error, Equals, "invalid token 'EXPIRED'")
+
+	w = httptest.NewRecorder()
+	function.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+	c.Check(w.Code, Equals, http.StatusBadRequest)
+
+	function.AllowOrigin = "https://example.com"
+	w = httptest.NewRecorder()
+	function.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/", nil))
+	c.Check(w.Code, Equals, http.StatusNoContent)
+	c.Check(w.Header().Get("Access-Control-Allow-Origin"), Equals, "https://example.com")
+}
@@ -0,0 +1,93 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// maxHedgedBodySize response bodies are buffered by WithHedging up to this size.
+const maxHedgedBodySize = 1 << 20
+
+type hedgedResult struct {
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// settled reports whether the result can be returned without waiting for the other request: a
+// response neither failing with a 5xx status nor reporting the duplicate caused by the hedge itself.
+func (h hedgedResult) settled() bool {
+	return h.err == nil && h.resp.StatusCode < http.StatusInternalServerError && !bytes.Contains(h.body, []byte("timeout-or-duplicate"))
+}
+
+// WithHedging Decorator sending a second verification request when the first one didn't complete
+// after delay, the first settled response being used and the other request canceled, cutting the
+// tail latency when recaptcha is slow. Both requests holding the same token, the timeout-or-duplicate
+// response of the slower one is only used when the other request failed too.
+func WithHedging(delay time.Duration) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(req.Body); err != nil {
+					return nil, err
+				}
+				req.Body.Close()
+			}
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+
+			results := make(chan hedgedResult, 2)
+			send := func() {
+				clone := req.Clone(ctx)
+				if body != nil {
+					clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
+				resp, err := next.RoundTrip(clone)
+				var respBody []byte
+				if err == nil {
+					// buffered so the response outlives the cancellation of the hedging context
+					respBody, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxHedgedBodySize))
+					resp.Body.Close()
+					resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+					if err != nil {
+						resp = nil
+					}
+				}
+				results <- hedgedResult{resp: resp, body: respBody, err: err}
+			}
+			go send()
+
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			pending := 1
+			var fallback *hedgedResult
+			for {
+				select {
+				case <-timer.C:
+					pending++
+					go send()
+				case result := <-results:
+					pending--
+					if result.settled() {
+						return result.resp, nil
+					}
+					if fallback == nil || (fallback.err != nil && result.err == nil) {
+						fallback = &result
+					}
+					// a first request failing before the delay isn't hedged, retries are left to WithRetrier
+					if pending == 0 {
+						return fallback.resp, fallback.err
+					}
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+		})
+	}
+}
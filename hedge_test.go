@@ -0,0 +1,53 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type HedgingSuite struct{}
+
+var _ = Suite(&HedgingSuite{})
+
+func (s *HedgingSuite) TestWithHedging(c *C) {
+	var requests int32
+	var slowBody, fastBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-time.After(300 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+			w.Write([]byte(slowBody))
+			return
+		}
+		w.Write([]byte(fastBody))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithHedging(50*time.Millisecond)), IsNil)
+
+	slowBody, fastBody = `{"success": false, "error-codes": ["timeout-or-duplicate"]}`, `{"success": true}`
+	start := time.Now()
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(time.Since(start) < 250*time.Millisecond, Equals, true)
+	c.Check(atomic.LoadInt32(&requests), Equals, int32(2))
+
+	// the duplicate caused by the hedge waits for the first request
+	atomic.StoreInt32(&requests, 0)
+	slowBody, fastBody = `{"success": true}`, `{"success": false, "error-codes": ["timeout-or-duplicate"]}`
+	c.Check(captcha.Verify("token"), IsNil)
+
+	// fast requests aren't hedged
+	atomic.StoreInt32(&requests, 1)
+	c.Check(captcha.Verify("token"), ErrorMatches, `remote error codes: \[timeout-or-duplicate\]`)
+	c.Check(atomic.LoadInt32(&requests), Equals, int32(2))
+}
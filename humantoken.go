@@ -0,0 +1,151 @@
+package recaptcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHumanTokenTTL default validity of the tokens issued by HumanTokens
+const DefaultHumanTokenTTL = 5 * time.Minute
+
+// HumanTokenHeader request header holding the human token checked by HumanTokens.Middleware
+const HumanTokenHeader = "X-Human-Token"
+
+// humanTokenHeader JOSE header of the human tokens, the only one accepted.
+var humanTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// HumanClaims claims of a human token: the verification it asserts and the token validity.
+type HumanClaims struct {
+	Issuer    string `json:"iss,omitempty"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// Score, Action and Hostname of the verification response.
+	Score    float32 `json:"score"`
+	Action   string  `json:"action,omitempty"`
+	Hostname string  `json:"hostname,omitempty"`
+	// ChallengeTS unix time at which the challenge was solved.
+	ChallengeTS int64 `json:"challenge_ts"`
+}
+
+// HumanTokens mints short-lived HS256 JWTs asserting a successful verification, which internal
+// services validate offline with the shared key so only the edge service calls recaptcha.
+type HumanTokens struct {
+	// Key secret used to sign the tokens, shared with the validating services.
+	Key []byte
+	// TTL validity of the tokens, DefaultHumanTokenTTL when zero.
+	TTL time.Duration
+	// Issuer set as the "iss" claim of the issued tokens, the validated tokens must hold it too.
+	Issuer string
+
+	now func() time.Time
+}
+
+// NewHumanTokens new HumanTokens signing the tokens with key
+func NewHumanTokens(key []byte) (*HumanTokens, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("human token key cannot be blank")
+	}
+	return &HumanTokens{Key: key, now: time.Now}, nil
+}
+
+// Issue returns a signed token asserting the successful verification response.
+func (h *HumanTokens) Issue(response Response) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("couldn't generate human token ID: '%s'", err)
+	}
+	ttl := h.TTL
+	if ttl == 0 {
+		ttl = DefaultHumanTokenTTL
+	}
+	now := h.clock()
+	claims := HumanClaims{
+		Issuer:      h.Issuer,
+		ID:          hex.EncodeToString(nonce),
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(ttl).Unix(),
+		Score:       response.Score,
+		Action:      response.Action,
+		Hostname:    response.Hostname,
+		ChallengeTS: response.ChallengeTS.Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := humanTokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + h.sign(signed), nil
+}
+
+// Validate returns the claims of the token when it is signed with Key, not expired and, when Issuer
+// is set, issued by Issuer.
+func (h *HumanTokens) Validate(token string) (HumanClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != humanTokenHeader || !hmac.Equal([]byte(h.sign(parts[0]+"."+parts[1])), []byte(parts[2])) {
+		return HumanClaims{}, &Error{msg: "invalid human token"}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return HumanClaims{}, &Error{msg: "invalid human token"}
+	}
+	var claims HumanClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return HumanClaims{}, &Error{msg: "invalid human token"}
+	}
+	if h.clock().Unix() >= claims.ExpiresAt {
+		return HumanClaims{}, &Error{msg: "expired human token"}
+	}
+	if h.Issuer != "" && claims.Issuer != h.Issuer {
+		return HumanClaims{}, &Error{msg: fmt.Sprintf("human token issued by '%s', while expecting '%s'", claims.Issuer, h.Issuer)}
+	}
+	return claims, nil
+}
+
+// Middleware wraps next, only calling it for requests presenting a valid token in the HumanTokenHeader
+// header, its claims being available through HumanClaimsFromContext.
+func (h *HumanTokens) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := h.Validate(r.Header.Get(HumanTokenHeader))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), humanClaimsContextKey, claims)))
+	})
+}
+
+// HumanClaimsFromContext returns the claims of the human token validated by HumanTokens.Middleware
+func HumanClaimsFromContext(ctx context.Context) (HumanClaims, bool) {
+	claims, ok := ctx.Value(humanClaimsContextKey).(HumanClaims)
+	return claims, ok
+}
+
+// HumanTokenFromContext returns the human token issued by Middleware for the verified request, to
+// forward to the internal services in the HumanTokenHeader header.
+func HumanTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(humanTokenContextKey).(string)
+	return token
+}
+
+func (h *HumanTokens) sign(signed string) string {
+	mac := hmac.New(sha256.New, h.Key)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *HumanTokens) clock() time.Time {
+	if h.now == nil {
+		return time.Now()
+	}
+	return h.now()
+}
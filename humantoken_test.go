@@ -0,0 +1,82 @@
+package recaptcha
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type HumanTokenSuite struct{}
+
+var _ = Suite(&HumanTokenSuite{})
+
+func (s *HumanTokenSuite) TestHumanTokens(c *C) {
+	_, err := NewHumanTokens(nil)
+	c.Check(err, ErrorMatches, "human token key cannot be blank")
+
+	tokens, err := NewHumanTokens([]byte("key"))
+	c.Assert(err, IsNil)
+	tokens.Issuer = "edge"
+	now := time.Unix(1700000000, 0)
+	tokens.now = func() time.Time { return now }
+
+	token, err := tokens.Issue(Response{Success: true, Score: 0.9, Action: "login", Hostname: "example.com", ChallengeTS: now.Add(-time.Second)})
+	c.Assert(err, IsNil)
+	c.Check(strings.Count(token, "."), Equals, 2)
+	claims, err := tokens.Validate(token)
+	c.Assert(err, IsNil)
+	c.Check(claims.Score, Equals, float32(0.9))
+	c.Check(claims.Action, Equals, "login")
+	c.Check(claims.ChallengeTS, Equals, now.Unix()-1)
+	c.Check(claims.ExpiresAt, Equals, now.Add(DefaultHumanTokenTTL).Unix())
+
+	other := &HumanTokens{Key: []byte("other key")}
+	_, err = other.Validate(token)
+	c.Check(err, ErrorMatches, "invalid human token")
+	other = &HumanTokens{Key: []byte("key"), Issuer: "admin", now: tokens.now}
+	_, err = other.Validate(token)
+	c.Check(err, ErrorMatches, "human token issued by 'edge', while expecting 'admin'")
+	parts := strings.Split(token, ".")
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+	_, err = tokens.Validate(unsigned)
+	c.Check(err, ErrorMatches, "invalid human token")
+
+	now = now.Add(DefaultHumanTokenTTL)
+	_, err = tokens.Validate(token)
+	c.Check(err, ErrorMatches, "expired human token")
+}
+
+func (s *HumanTokenSuite) TestHumanTokensMiddleware(c *C) {
+	tokens, err := NewHumanTokens([]byte("key"))
+	c.Assert(err, IsNil)
+
+	// the edge service issues the token...
+	edge := NewMiddleware(mockVerifier(func(string, VerifyOption) error { return nil }), VerifyOption{})
+	edge.HumanTokens = tokens
+	var token string
+	edge.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = HumanTokenFromContext(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), postForm(url.Values{"g-recaptcha-response": {"valid"}}))
+	c.Assert(token, Not(Equals), "")
+
+	// ...validated by the internal services
+	var claims HumanClaims
+	internal := tokens.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = HumanClaimsFromContext(r.Context())
+	}))
+	r := httptest.NewRequest("GET", "/internal", nil)
+	r.Header.Set(HumanTokenHeader, token)
+	w := httptest.NewRecorder()
+	internal.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(claims.ID, Not(Equals), "")
+
+	w = httptest.NewRecorder()
+	internal.ServeHTTP(w, httptest.NewRequest("GET", "/internal", nil))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+}
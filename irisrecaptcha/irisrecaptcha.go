@@ -0,0 +1,77 @@
+// Package irisrecaptcha adapts recaptcha verification to the Iris web framework, injecting the
+// verification result in the request context values.
+package irisrecaptcha
+
+import (
+	"net/http"
+
+	"github.com/kataras/iris/v12"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// ResultKey context values key of the verification Result.
+const ResultKey = "recaptcha.result"
+
+// Result verification result injected in the context values of every verified request.
+type Result struct {
+	Response recaptcha.Response
+	Err      error
+	Outcome  recaptcha.Outcome
+}
+
+// Middleware Iris middleware verifying the challenge response of the requests, only calling the next
+// handlers of allowed and quarantined requests.
+type Middleware struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options recaptcha.VerifyOption
+	// Field form field holding the challenge response, recaptcha.DefaultResponseField when blank.
+	Field string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// Denied handles the rejected requests, a plain 403 when nil. The result is available through
+	// ResultFromContext.
+	Denied iris.Handler
+}
+
+// New new Middleware verifying requests with verifier using options
+func New(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Middleware {
+	return &Middleware{Verifier: verifier, Options: options}
+}
+
+// Handler verifies the request, to pass to app.Use or a route.
+func (m *Middleware) Handler(ctx iris.Context) {
+	options := m.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = ctx.RemoteAddr()
+	}
+	response, err := recaptcha.VerifyContext(ctx.Request().Context(), m.Verifier, ctx.FormValue(m.field()), options)
+	outcome := recaptcha.DecideWith(ctx.Request().Context(), m.Decider, m.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+	ctx.Values().Set(ResultKey, Result{Response: response, Err: err, Outcome: outcome})
+
+	if outcome.Decision != recaptcha.Allow && outcome.Decision != recaptcha.Quarantine {
+		if m.Denied != nil {
+			m.Denied(ctx)
+			return
+		}
+		ctx.StopWithStatus(http.StatusForbidden)
+		return
+	}
+	ctx.Next()
+}
+
+// ResultFromContext returns the verification result of a request verified by Middleware
+func ResultFromContext(ctx iris.Context) (Result, bool) {
+	result, ok := ctx.Values().Get(ResultKey).(Result)
+	return result, ok
+}
+
+func (m *Middleware) field() string {
+	if m.Field == "" {
+		return recaptcha.DefaultResponseField
+	}
+	return m.Field
+}
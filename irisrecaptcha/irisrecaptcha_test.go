@@ -0,0 +1,73 @@
+package irisrecaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type IrisSuite struct{}
+
+var _ = Suite(&IrisSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct{}
+
+func (mockVerifier) Verify(challengeResponse string) error {
+	return mockVerifier{}.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := mockVerifier{}.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6}, nil
+}
+
+func post(app *iris.Application, challengeResponse string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{recaptcha.DefaultResponseField: {challengeResponse}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	return w
+}
+
+func (s *IrisSuite) TestMiddleware(c *C) {
+	middleware := New(mockVerifier{}, recaptcha.VerifyOption{})
+	app := iris.New()
+	app.Post("/login", middleware.Handler, func(ctx iris.Context) {
+		result, ok := ResultFromContext(ctx)
+		c.Check(ok, Equals, true)
+		ctx.WriteString(fmt.Sprintf("%.1f %s", result.Response.Score, result.Outcome.Decision))
+	})
+	c.Assert(app.Build(), IsNil)
+
+	w := post(app, "valid")
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Body.String(), Equals, "0.6 allow")
+	c.Check(post(app, "invalid").Code, Equals, http.StatusForbidden)
+
+	middleware.Policy = &recaptcha.Policy{ChallengeBelow: 0.7}
+	middleware.Denied = func(ctx iris.Context) {
+		result, _ := ResultFromContext(ctx)
+		ctx.StopWithText(http.StatusUnauthorized, result.Outcome.Decision.String())
+	}
+	w = post(app, "valid")
+	c.Check(w.Code, Equals, http.StatusUnauthorized)
+	c.Check(w.Body.String(), Equals, "challenge")
+}
@@ -0,0 +1,91 @@
+package recaptcha
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Shutdowner is implemented by the subsystems running goroutines or buffering output (webhooks,
+// export writers...), Shutdown flushes them and stops their goroutines, giving up when the context
+// is done.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ShutdownFunc adapter allowing the use of ordinary functions as Shutdowner.
+type ShutdownFunc func(ctx context.Context) error
+
+// Shutdown calls f(ctx).
+func (f ShutdownFunc) Shutdown(ctx context.Context) error { return f(ctx) }
+
+// CloserShutdown adapts an io.Closer such as the export writers, the context being ignored.
+func CloserShutdown(closer io.Closer) Shutdowner {
+	return ShutdownFunc(func(context.Context) error { return closer.Close() })
+}
+
+// Lifecycle shuts down the registered subsystems together, in reverse registration order so that
+// subsystems registered after their dependencies are shut down first. Its Shutdown method can be
+// handed to application lifecycle managers.
+type Lifecycle struct {
+	mu         sync.Mutex
+	subsystems []Shutdowner
+	done       bool
+}
+
+// Register adds subsystems shut down by Shutdown, they are shut down immediately when Shutdown was
+// already called.
+func (l *Lifecycle) Register(subsystems ...Shutdowner) {
+	l.mu.Lock()
+	if !l.done {
+		l.subsystems = append(l.subsystems, subsystems...)
+		l.mu.Unlock()
+		return
+	}
+	l.mu.Unlock()
+	for _, subsystem := range subsystems {
+		subsystem.Shutdown(context.Background())
+	}
+}
+
+// Shutdown shuts down every registered subsystem, even when some fail, and returns the first error.
+// Later calls do nothing.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	subsystems := l.subsystems
+	l.subsystems, l.done = nil, true
+	l.mu.Unlock()
+
+	var err error
+	for i := len(subsystems) - 1; i >= 0; i-- {
+		err = firstError(err, subsystems[i].Shutdown(ctx))
+	}
+	return err
+}
+
+// OnShutdown registers subsystems shut down by the Shutdown method of the verifier.
+func (r *ReCAPTCHA) OnShutdown(subsystems ...Shutdowner) {
+	if r.lifecycle == nil {
+		r.lifecycle = &Lifecycle{}
+	}
+	r.lifecycle.Register(subsystems...)
+}
+
+// Shutdown shuts down the subsystems registered with OnShutdown, in reverse registration order, then
+// closes the idle connections of the verification transport. Verifications still work afterwards.
+func (r *ReCAPTCHA) Shutdown(ctx context.Context) error {
+	var err error
+	if r.lifecycle != nil {
+		err = r.lifecycle.Shutdown(ctx)
+	}
+	if client, ok := r.client.(*http.Client); ok && client.Transport != nil {
+		// the default transport is shared with the rest of the process, it is left alone
+		if closer, ok := client.Transport.(interface {
+			CloseIdleConnections()
+		}); ok {
+			closer.CloseIdleConnections()
+		}
+	}
+	return err
+}
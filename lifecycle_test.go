@@ -0,0 +1,64 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type LifecycleSuite struct{}
+
+var _ = Suite(&LifecycleSuite{})
+
+func (s *LifecycleSuite) TestShutdownOrder(c *C) {
+	var order []string
+	subsystem := func(name string, err error) Shutdowner {
+		return ShutdownFunc(func(context.Context) error {
+			order = append(order, name)
+			return err
+		})
+	}
+
+	lifecycle := &Lifecycle{}
+	lifecycle.Register(subsystem("store", nil), subsystem("sink", errors.New("flush failed")))
+	lifecycle.Register(subsystem("webhook", errors.New("timeout")))
+	c.Check(lifecycle.Shutdown(context.Background()), ErrorMatches, "timeout")
+	c.Check(order, DeepEquals, []string{"webhook", "sink", "store"})
+
+	c.Check(lifecycle.Shutdown(context.Background()), IsNil)
+	c.Check(order, HasLen, 3)
+
+	lifecycle.Register(subsystem("late", nil))
+	c.Check(order, DeepEquals, []string{"webhook", "sink", "store", "late"})
+}
+
+type mockCloser struct{ closed bool }
+
+func (m *mockCloser) Close() error {
+	m.closed = true
+	return nil
+}
+
+type mockIdleTransport struct {
+	http.RoundTripper
+	closed bool
+}
+
+func (m *mockIdleTransport) CloseIdleConnections() { m.closed = true }
+
+func (s *LifecycleSuite) TestReCAPTCHAShutdown(c *C) {
+	transport := &mockIdleTransport{}
+	captcha, err := NewReCAPTCHA("my secret", V3, 0)
+	c.Assert(err, IsNil)
+	captcha.client.(*http.Client).Transport = transport
+
+	closer := &mockCloser{}
+	captcha.OnShutdown(CloserShutdown(closer))
+	c.Check(captcha.Shutdown(context.Background()), IsNil)
+	c.Check(closer.closed, Equals, true)
+	c.Check(transport.closed, Equals, true)
+
+	c.Check((&ReCAPTCHA{client: &mockSuccessClientNoOptions{}}).Shutdown(context.Background()), IsNil)
+}
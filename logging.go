@@ -0,0 +1,70 @@
+package recaptcha
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces the secret in the logged values.
+const redacted = "[REDACTED]"
+
+// WithLogger logs the verifications with logger and returns r: requests sent and responses parsed at
+// debug level, failed validations with their reason at info level, failed requests at warn level.
+// The secret is redacted from the logged values and the challenge responses are never logged. Logs
+// are disabled when logger is nil, the default.
+func (r *ReCAPTCHA) WithLogger(logger *slog.Logger) *ReCAPTCHA {
+	r.logger = logger
+	return r
+}
+
+// log logs msg at level when enabled, redacting the secrets from the string and error values of args.
+// Callers check the logger is set first, so args aren't allocated when logs are disabled.
+func (r *ReCAPTCHA) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if r.logger == nil || !r.logger.Enabled(ctx, level) {
+		return
+	}
+	for _, secret := range [...]string{r.Secret, r.SecondarySecret} {
+		if secret == "" {
+			continue
+		}
+		for i, arg := range args {
+			switch value := arg.(type) {
+			case string:
+				args[i] = strings.ReplaceAll(value, secret, redacted)
+			case error:
+				args[i] = strings.ReplaceAll(value.Error(), secret, redacted)
+			}
+		}
+	}
+	r.logger.Log(ctx, level, msg, args...)
+}
+
+// logResult logs the outcome of a verification, request failures being logged when they happen.
+func (r *ReCAPTCHA) logResult(ctx context.Context, options VerifyOption, result Response, err error, enforced bool) {
+	if r.logger == nil {
+		return
+	}
+	if err == nil {
+		r.log(ctx, slog.LevelDebug, "recaptcha verification succeeded", "action", result.Action, "score", logScore(result.Score), "hostname", result.Hostname)
+		return
+	}
+	reason := ErrorReason(err)
+	if reason == "request_failed" || reason == "circuit_open" {
+		return
+	}
+	msg := "recaptcha verification failed"
+	if !enforced {
+		msg = "recaptcha verification failed, not enforced"
+	}
+	r.log(ctx, slog.LevelInfo, msg, "reason", reason, "error", err,
+		"expected_action", options.Action, "action", result.Action, "score", logScore(result.Score), "hostname", result.Hostname)
+}
+
+// logScore returns the score as the float64 closest to its shortest decimal form, e.g. 0.6 instead of
+// 0.6000000238418579.
+func logScore(score float32) float64 {
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(score), 'g', -1, 32), 64)
+	return value
+}
@@ -0,0 +1,60 @@
+package recaptcha
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type LoggingSuite struct{}
+
+var _ = Suite(&LoggingSuite{})
+
+func (s *LoggingSuite) TestWithLogger(c *C) {
+	body := `{"success": true, "score": 0.6, "action": "login", "hostname": "example.com"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("my-secret", V3, time.Second)
+	c.Assert(err, IsNil)
+	// gateways authenticating with the secret in the path
+	captcha.ReCAPTCHALink = server.URL + "/my-secret"
+	var logs bytes.Buffer
+	c.Check(captcha.WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))), Equals, &captcha)
+
+	c.Check(captcha.VerifyWithOptions("my-token", VerifyOption{Action: "login"}), IsNil)
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Check(lines[0], Matches, `.*level=DEBUG msg="recaptcha request sent" url=http://.*/\[REDACTED\] action=login remote_ip=""`)
+	c.Check(lines[1], Matches, `.*level=DEBUG msg="recaptcha response parsed" status=200 success=true score=0.6 action=login hostname=example.com error_codes=\[\]`)
+	c.Check(lines[2], Matches, `.*level=DEBUG msg="recaptcha verification succeeded" action=login score=0.6 hostname=example.com`)
+	c.Check(strings.Contains(logs.String(), "my-secret"), Equals, false)
+	c.Check(strings.Contains(logs.String(), "my-token"), Equals, false)
+
+	logs.Reset()
+	c.Check(captcha.VerifyWithOptions("my-token", VerifyOption{Action: "login", Threshold: 0.7}), NotNil)
+	c.Check(logs.String(), Matches, `(?s).*level=INFO msg="recaptcha verification failed" reason=score_too_low error="received score '0.600000', while expecting minimum '0.700000'" expected_action=login action=login score=0.6 hostname=example.com\n`)
+
+	logs.Reset()
+	body = `bogus`
+	c.Check(captcha.Verify("my-token"), NotNil)
+	c.Check(logs.String(), Matches, `(?s).*level=WARN msg="recaptcha response parsing failed" status=200 error=.*`)
+	c.Check(strings.Contains(logs.String(), "verification failed"), Equals, false)
+
+	logs.Reset()
+	captcha.WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	c.Check(captcha.VerifyWithOptions("my-token", VerifyOption{Action: "login"}), NotNil)
+	c.Check(logs.String(), Matches, `.*level=WARN msg="recaptcha response parsing failed".*\n`)
+
+	logs.Reset()
+	captcha.WithLogger(nil)
+	c.Check(captcha.Verify("my-token"), NotNil)
+	c.Check(logs.Len(), Equals, 0)
+}
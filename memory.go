@@ -0,0 +1,136 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMemoryCookie cookie set by CookieMemory when Name is blank
+const DefaultMemoryCookie = "recaptcha_verified"
+
+// SessionMemory remembers the clients which passed a verification, so that their next requests, e.g.
+// the following steps of a multi-step form, don't need a new challenge response until it forgets them.
+type SessionMemory interface {
+	// Remembered reports whether the client of the request passed a verification recently.
+	Remembered(r *http.Request) bool
+	// Remember marks the client of the request as verified, before anything is written to w.
+	Remember(w http.ResponseWriter, r *http.Request) error
+}
+
+// CookieMemory stateless SessionMemory remembering the clients with a signed cookie holding its expiry.
+type CookieMemory struct {
+	// Key secret used to sign the cookies.
+	Key []byte
+	// TTL duration a client is remembered after a successful verification.
+	TTL time.Duration
+	// Name of the cookie, DefaultMemoryCookie when blank.
+	Name string
+	// Path of the cookie, "/" when blank.
+	Path string
+	// Secure only send the cookie over HTTPS.
+	Secure bool
+
+	now func() time.Time
+}
+
+var _ SessionMemory = (*CookieMemory)(nil)
+
+// NewCookieMemory new CookieMemory remembering the clients for ttl, cookies are signed with key.
+func NewCookieMemory(key []byte, ttl time.Duration) (*CookieMemory, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("cookie memory key cannot be blank")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("cookie memory ttl must be positive")
+	}
+	return &CookieMemory{Key: key, TTL: ttl, now: time.Now}, nil
+}
+
+// Remembered reports whether the request holds a valid unexpired cookie.
+func (m *CookieMemory) Remembered(r *http.Request) bool {
+	cookie, err := r.Cookie(m.name())
+	if err != nil {
+		return false
+	}
+	payload, ok := unseal(m.Key, purposeCookieMemory, cookie.Value)
+	if !ok {
+		return false
+	}
+	expiry, err := strconv.ParseInt(payload, 10, 64)
+	return err == nil && m.clock().Unix() < expiry
+}
+
+// Remember sets the signed cookie on w.
+func (m *CookieMemory) Remember(w http.ResponseWriter, r *http.Request) error {
+	path := m.Path
+	if path == "" {
+		path = "/"
+	}
+	expiry := m.clock().Add(m.TTL)
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.name(),
+		Value:    seal(m.Key, purposeCookieMemory, strconv.FormatInt(expiry.Unix(), 10)),
+		Path:     path,
+		Expires:  expiry,
+		MaxAge:   int(m.TTL / time.Second),
+		Secure:   m.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (m *CookieMemory) name() string {
+	if m.Name == "" {
+		return DefaultMemoryCookie
+	}
+	return m.Name
+}
+
+func (m *CookieMemory) clock() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// StoreMemory SessionMemory remembering the sessions in a TrustStore, shared between instances with
+// a shared Store backend.
+type StoreMemory struct {
+	TrustStore *TrustStore
+	// Session returns the session identifier of the request, e.g. from the session cookie. The
+	// requests without session are never remembered.
+	Session func(*http.Request) string
+}
+
+var _ SessionMemory = (*StoreMemory)(nil)
+
+// NewStoreMemory new StoreMemory remembering the sessions identified by session in trustStore
+func NewStoreMemory(trustStore *TrustStore, session func(*http.Request) string) (*StoreMemory, error) {
+	if trustStore == nil || session == nil {
+		return nil, fmt.Errorf("store memory trust store and session func cannot be nil")
+	}
+	return &StoreMemory{TrustStore: trustStore, Session: session}, nil
+}
+
+// Remembered reports whether the session of the request is trusted, a failing store only means the
+// session has to be verified again.
+func (m *StoreMemory) Remembered(r *http.Request) bool {
+	session := m.Session(r)
+	if session == "" {
+		return false
+	}
+	trusted, err := m.TrustStore.Trusted(session)
+	return err == nil && trusted
+}
+
+// Remember trusts the session of the request.
+func (m *StoreMemory) Remember(w http.ResponseWriter, r *http.Request) error {
+	session := m.Session(r)
+	if session == "" {
+		return nil
+	}
+	return m.TrustStore.Trust(session)
+}
@@ -0,0 +1,90 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MemorySuite struct{}
+
+var _ = Suite(&MemorySuite{})
+
+func (s *MemorySuite) TestCookieMemory(c *C) {
+	_, err := NewCookieMemory(nil, time.Minute)
+	c.Check(err, ErrorMatches, "cookie memory key cannot be blank")
+	_, err = NewCookieMemory([]byte("key"), 0)
+	c.Check(err, ErrorMatches, "cookie memory ttl must be positive")
+
+	memory, err := NewCookieMemory([]byte("key"), 10*time.Minute)
+	c.Assert(err, IsNil)
+	now := time.Now()
+	memory.now = func() time.Time { return now }
+
+	verified := 0
+	middleware := NewMiddleware(mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		verified++
+		if challengeResponse != "valid" {
+			return &Error{msg: "invalid challenge solution"}
+		}
+		return nil
+	}), VerifyOption{})
+	middleware.Memory = memory
+	handler := middleware.Handler(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}}))
+	c.Check(w.Code, Equals, http.StatusOK)
+	cookies := w.Result().Cookies()
+	c.Assert(cookies, HasLen, 1)
+	c.Check(cookies[0].Name, Equals, DefaultMemoryCookie)
+	c.Check(cookies[0].HttpOnly, Equals, true)
+
+	// the next steps don't need a challenge response
+	r := postForm(url.Values{})
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(verified, Equals, 1)
+
+	now = now.Add(10 * time.Minute)
+	c.Check(memory.Remembered(r), Equals, false)
+	now = now.Add(-time.Minute)
+	c.Check(memory.Remembered(r), Equals, true)
+
+	forged := postForm(url.Values{})
+	forged.AddCookie(&http.Cookie{Name: DefaultMemoryCookie, Value: seal([]byte("other key"), purposeCookieMemory, "9999999999")})
+	c.Check(memory.Remembered(forged), Equals, false)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, forged)
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(w.Result().Cookies(), HasLen, 0)
+
+	// the tokens of the other helpers sharing the key aren't verified sessions
+	timer, err := NewFormTimer([]byte("key"), 0)
+	c.Assert(err, IsNil)
+	stamped := postForm(url.Values{})
+	stamped.AddCookie(&http.Cookie{Name: DefaultMemoryCookie, Value: timer.Stamp()})
+	c.Check(memory.Remembered(stamped), Equals, false)
+}
+
+func (s *MemorySuite) TestStoreMemory(c *C) {
+	trustStore, err := NewTrustStore(NewMemoryStore(), time.Minute)
+	c.Assert(err, IsNil)
+	_, err = NewStoreMemory(trustStore, nil)
+	c.Check(err, ErrorMatches, "store memory trust store and session func cannot be nil")
+	memory, err := NewStoreMemory(trustStore, func(r *http.Request) string { return r.Header.Get("X-Session") })
+	c.Assert(err, IsNil)
+
+	r := httptest.NewRequest("POST", "/form", nil)
+	c.Check(memory.Remember(httptest.NewRecorder(), r), IsNil)
+	c.Check(memory.Remembered(r), Equals, false)
+	r.Header.Set("X-Session", "abc")
+	c.Check(memory.Remembered(r), Equals, false)
+	c.Check(memory.Remember(httptest.NewRecorder(), r), IsNil)
+	c.Check(memory.Remembered(r), Equals, true)
+}
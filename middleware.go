@@ -0,0 +1,269 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// DefaultResponseField form field in which the recaptcha widget sets the challenge response
+const DefaultResponseField = "g-recaptcha-response"
+
+type contextKey int
+
+const (
+	errorContextKey contextKey = iota
+	outcomeContextKey
+	skippedContextKey
+	humanTokenContextKey
+	humanClaimsContextKey
+)
+
+// Middleware net/http middleware rejecting the requests whose challenge response fails verification.
+type Middleware struct {
+	Verifier Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options VerifyOption
+	// ClientIPResolver when set derives the RemoteIP from the forwarding headers of the trusted
+	// proxies instead of using the request remote address.
+	ClientIPResolver *ClientIPResolver
+	// BypassNetworks requests from these networks (health checkers, office IPs...) are passed to the
+	// next handler without verification, reporting an EventBypass to the Observers. See ParseNetworks.
+	BypassNetworks []*net.IPNet
+	// Skip when set and returning true passes the request to the next handler without verification,
+	// e.g. for authenticated admins, reporting an EventBypass to the Observers. See SkippedFromContext.
+	Skip func(*http.Request) bool
+	// Field form field holding the challenge response, DefaultResponseField when blank.
+	Field string
+	// TokenSources when set ordered sources of the challenge response used instead of Field, see
+	// TokenFromRequest.
+	TokenSources []TokenSource
+	// Forbidden handles the requests failing verification, a plain 403 when nil. The verification
+	// error is available through ErrorFromContext.
+	Forbidden http.Handler
+	// TrustStore when set skips the verification of sessions trusted after a previous success.
+	TrustStore *TrustStore
+	// Session returns the session or device identifier used with TrustStore, trust is not used for
+	// requests where it returns a blank identifier.
+	Session func(*http.Request) string
+	// Memory when set remembers the clients passing verification, e.g. with a signed cookie, their
+	// next requests being passed to the next handler without challenge response until it forgets them.
+	Memory SessionMemory
+	// HumanTokens when set issues a human token for the requests passing verification, available to
+	// the next handler through HumanTokenFromContext.
+	HumanTokens *HumanTokens
+	// Velocity when set records verification failures per remote IP and rejects the requests of
+	// locked out IPs without verifying them.
+	Velocity *VelocityTracker
+	// TooManyRequests handles the requests of locked out IPs, a plain 429 when nil. The lockout error
+	// is available through ErrorFromContext.
+	TooManyRequests http.Handler
+	// Observers receive the request level events: one EventVerification per verified request carrying
+	// the middleware decision, and the bypass, lockout, honeypot and form time events. The Verifier
+	// observers (ReCAPTCHA.Observers) receive their own EventVerification of the same verification
+	// with its Duration, register an observer at one of the layers only to count each verification once.
+	Observers []Observer
+	// Policy when set maps verification results to Allow, Challenge, Block or Quarantine decisions,
+	// otherwise failed verifications are blocked. Quarantined requests are passed to the next handler,
+	// flagged in their context, see QuarantinedFromContext.
+	Policy *Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider Decider
+	// Challenge handles the requests whose decision is Challenge (e.g. rendering a V2 widget page),
+	// Forbidden is used when nil. The outcome is available through OutcomeFromContext.
+	Challenge http.Handler
+	// Honeypot name of a hidden form field that humans leave empty, requests filling it are blocked
+	// without verification. Disabled when blank.
+	Honeypot string
+	// FormTimer when set blocks without verification the requests whose signed render timestamp is
+	// missing, invalid or shows the form was filled too fast.
+	FormTimer *FormTimer
+	// FormTimeField form field holding the signed render timestamp, DefaultFormTimeField when blank.
+	FormTimeField string
+	// Context when set returns per request key/value pairs (form name, tenant...) added to the
+	// Options context and propagated to the events.
+	Context func(*http.Request) map[string]string
+}
+
+// NewMiddleware new Middleware verifying requests with verifier using options
+func NewMiddleware(verifier Verifier, options VerifyOption) *Middleware {
+	return &Middleware{Verifier: verifier, Options: options}
+}
+
+// ErrorFromContext returns the verification error of a request rejected by Middleware
+func ErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(errorContextKey).(error)
+	return err
+}
+
+// OutcomeFromContext returns the decision outcome of a request rejected, challenged, quarantined or skipped by Middleware
+func OutcomeFromContext(ctx context.Context) (Outcome, bool) {
+	outcome, ok := ctx.Value(outcomeContextKey).(Outcome)
+	return outcome, ok
+}
+
+// SkippedFromContext reports whether Middleware let the request through without verification, see
+// Middleware.BypassNetworks and Middleware.Skip. The skip reason is in OutcomeFromContext.
+func SkippedFromContext(ctx context.Context) bool {
+	skipped, _ := ctx.Value(skippedContextKey).(bool)
+	return skipped
+}
+
+// QuarantinedFromContext reports whether Middleware let the request through flagged for review
+func QuarantinedFromContext(ctx context.Context) bool {
+	outcome, ok := OutcomeFromContext(ctx)
+	return ok && outcome.Decision == Quarantine
+}
+
+// Handler wraps next, only calling it for requests passing verification.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := m.session(r)
+		if session != "" {
+			// a failing trust store only means the session has to be verified again
+			if trusted, err := m.TrustStore.Trusted(session); err == nil && trusted {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if m.Memory != nil && m.Memory.Remembered(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		options := m.Options
+		if options.RemoteIP == "" {
+			options.RemoteIP = m.ClientIPResolver.ClientIP(r)
+		}
+		if m.Context != nil {
+			options.Context = mergeContext(options.Context, m.Context(r))
+		}
+		if reason := skipReason(r, m.BypassNetworks, m.Skip, options); reason != "" {
+			notify(m.Observers, bypassEvent(options, reason))
+			ctx := context.WithValue(r.Context(), skippedContextKey, true)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, outcomeContextKey, Outcome{Decision: Allow, Reasons: []string{reason}})))
+			return
+		}
+		if m.Velocity != nil {
+			// lockouts are best effort, a failing store shouldn't prevent verification
+			if locked, err := m.Velocity.Locked(options.RemoteIP); err == nil && locked {
+				err := &Error{msg: fmt.Sprintf("too many failed verifications from '%s'", options.RemoteIP)}
+				notify(m.Observers, newEvent(EventLockout, options, Response{}, err, Outcome{Decision: Block, Reasons: []string{err.Error()}}))
+				m.tooManyRequests(w, r.WithContext(context.WithValue(r.Context(), errorContextKey, err)))
+				return
+			}
+		}
+
+		if err := checkHoneypot(r, m.Honeypot); err != nil {
+			m.block(w, r, EventHoneypot, options, err)
+			return
+		}
+		if m.FormTimer != nil {
+			if err := m.FormTimer.Check(r.FormValue(m.formTimeField())); err != nil {
+				m.block(w, r, EventFormTime, options, err)
+				return
+			}
+		}
+
+		response, err := VerifyContext(r.Context(), m.Verifier, m.token(r), options)
+		outcome := DecideWith(r.Context(), m.Decider, m.Policy, VerifyResult{Response: response, Err: err}, options)
+		notify(m.Observers, newEvent(EventVerification, options, response, err, outcome))
+		if m.Velocity != nil && clientFailure(err) {
+			m.Velocity.Fail(options.RemoteIP)
+		}
+		if outcome.Decision == Quarantine {
+			// quarantined sessions aren't trusted, their next requests are verified again
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), outcomeContextKey, outcome)))
+			return
+		}
+		if outcome.Decision != Allow {
+			m.reject(w, r, err, outcome)
+			return
+		}
+
+		if session != "" {
+			m.TrustStore.Trust(session)
+		}
+		if m.Memory != nil {
+			// remembering is best effort, the next requests are verified again
+			m.Memory.Remember(w, r)
+		}
+		if m.HumanTokens != nil {
+			// a failure leaves the request without token, rejected by the internal services
+			if token, err := m.HumanTokens.Issue(response); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), humanTokenContextKey, token))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// block rejects the request failing a check made before verification.
+func (m *Middleware) block(w http.ResponseWriter, r *http.Request, kind EventKind, options VerifyOption, err error) {
+	outcome := Outcome{Decision: Block, Reasons: []string{err.Error()}}
+	notify(m.Observers, newEvent(kind, options, Response{}, err, outcome))
+	if m.Velocity != nil {
+		m.Velocity.Fail(options.RemoteIP)
+	}
+	m.reject(w, r, err, outcome)
+}
+
+// reject hands the request to the Challenge or Forbidden handler depending on the outcome.
+func (m *Middleware) reject(w http.ResponseWriter, r *http.Request, err error, outcome Outcome) {
+	ctx := context.WithValue(r.Context(), errorContextKey, err)
+	r = r.WithContext(context.WithValue(ctx, outcomeContextKey, outcome))
+	if outcome.Decision == Challenge && m.Challenge != nil {
+		m.Challenge.ServeHTTP(w, r)
+		return
+	}
+	m.forbidden(w, r)
+}
+
+func (m *Middleware) session(r *http.Request) string {
+	if m.TrustStore == nil || m.Session == nil {
+		return ""
+	}
+	return m.Session(r)
+}
+
+func (m *Middleware) token(r *http.Request) string {
+	if len(m.TokenSources) > 0 {
+		return TokenFromRequest(r, m.TokenSources...)
+	}
+	if m.Field == "" {
+		return r.FormValue(DefaultResponseField)
+	}
+	return r.FormValue(m.Field)
+}
+
+func (m *Middleware) formTimeField() string {
+	if m.FormTimeField == "" {
+		return DefaultFormTimeField
+	}
+	return m.FormTimeField
+}
+
+func (m *Middleware) forbidden(w http.ResponseWriter, r *http.Request) {
+	if m.Forbidden != nil {
+		m.Forbidden.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
+
+func (m *Middleware) tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	if m.TooManyRequests != nil {
+		m.TooManyRequests.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,287 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MiddlewareSuite struct{}
+
+var _ = Suite(&MiddlewareSuite{})
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func postForm(values url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "/form", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = "123.123.123.123:4567"
+	return r
+}
+
+func (s *MiddlewareSuite) TestMiddleware(c *C) {
+	var token string
+	var options VerifyOption
+	verifier := mockVerifier(func(challengeResponse string, opts VerifyOption) error {
+		token, options = challengeResponse, opts
+		if challengeResponse != "valid" {
+			return &Error{msg: "invalid challenge solution"}
+		}
+		return nil
+	})
+	handler := NewMiddleware(verifier, VerifyOption{Action: "login"}).Handler(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}}))
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Body.String(), Equals, "ok")
+	c.Check(token, Equals, "valid")
+	c.Check(options, DeepEquals, VerifyOption{Action: "login", RemoteIP: "123.123.123.123"})
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"invalid"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareBypassNetworks(c *C) {
+	_, err := ParseNetworks("10.0.0.0/8", "office")
+	c.Check(err, ErrorMatches, "invalid network 'office', while expecting an IP address or a CIDR")
+
+	verified := 0
+	middleware := NewMiddleware(mockVerifier(func(string, VerifyOption) error {
+		verified++
+		return &Error{msg: "invalid challenge solution"}
+	}), VerifyOption{Action: "login"})
+	middleware.BypassNetworks, err = ParseNetworks("10.0.0.0/8", "123.123.123.123")
+	c.Assert(err, IsNil)
+	var events []Event
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+
+	w := httptest.NewRecorder()
+	middleware.Handler(okHandler).ServeHTTP(w, postForm(url.Values{}))
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(verified, Equals, 0)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, EventBypass)
+	c.Check(events[0].Action, Equals, "login")
+	c.Check(events[0].Reasons, DeepEquals, []string{"remote IP '123.123.123.123' in bypass network '123.123.123.123/32'"})
+
+	r := postForm(url.Values{})
+	r.RemoteAddr = "203.0.113.1:4567"
+	w = httptest.NewRecorder()
+	middleware.Handler(okHandler).ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(verified, Equals, 1)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareSkip(c *C) {
+	middleware := NewMiddleware(mockVerifier(func(string, VerifyOption) error {
+		return &Error{msg: "invalid challenge solution"}
+	}), VerifyOption{})
+	middleware.Skip = func(r *http.Request) bool { return r.Header.Get("X-Admin") == "true" }
+	var skipped bool
+	var outcome Outcome
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skipped = SkippedFromContext(r.Context())
+		outcome, _ = OutcomeFromContext(r.Context())
+	}))
+
+	r := postForm(url.Values{})
+	r.Header.Set("X-Admin", "true")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(skipped, Equals, true)
+	c.Check(outcome.Reasons, DeepEquals, []string{"verification skipped by the skip func"})
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareForbiddenHandler(c *C) {
+	middleware := NewMiddleware(failVerifier("invalid challenge solution"), VerifyOption{})
+	middleware.Field = "captcha"
+	middleware.Forbidden = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, ErrorFromContext(r.Context()).Error(), http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	middleware.Handler(okHandler).ServeHTTP(w, postForm(url.Values{"captcha": {"invalid"}}))
+	c.Check(w.Code, Equals, http.StatusTeapot)
+	c.Check(w.Body.String(), Equals, "invalid challenge solution\n")
+}
+
+func (s *MiddlewareSuite) TestMiddlewareTrustStore(c *C) {
+	calls := 0
+	verifier := mockVerifier(func(challengeResponse string, opts VerifyOption) error {
+		calls++
+		if challengeResponse != "valid" {
+			return &Error{msg: "invalid challenge solution"}
+		}
+		return nil
+	})
+	middleware := NewMiddleware(verifier, VerifyOption{})
+	middleware.TrustStore, _ = NewTrustStore(NewMemoryStore(), time.Minute)
+	middleware.Session = func(r *http.Request) string { return r.Header.Get("X-Session") }
+	handler := middleware.Handler(okHandler)
+
+	first := postForm(url.Values{"g-recaptcha-response": {"valid"}})
+	first.Header.Set("X-Session", "session")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, first)
+	c.Check(w.Code, Equals, http.StatusOK)
+
+	second := postForm(url.Values{})
+	second.Header.Set("X-Session", "session")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, second)
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(calls, Equals, 1)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(calls, Equals, 2)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareVelocity(c *C) {
+	calls := 0
+	verifier := mockVerifier(func(challengeResponse string, opts VerifyOption) error {
+		calls++
+		return &Error{msg: "invalid challenge solution"}
+	})
+	var events []Event
+	middleware := NewMiddleware(verifier, VerifyOption{})
+	middleware.Velocity, _ = NewVelocityTracker(NewMemoryStore(), time.Minute, 2)
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+	handler := middleware.Handler(okHandler)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"invalid"}}))
+		c.Check(w.Code, Equals, http.StatusForbidden)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"invalid"}}))
+	c.Check(w.Code, Equals, http.StatusTooManyRequests)
+	c.Check(calls, Equals, 2)
+
+	c.Assert(events, HasLen, 3)
+	c.Check(events[0].Kind, Equals, EventVerification)
+	c.Check(events[0].Err, ErrorMatches, "invalid challenge solution")
+	c.Check(events[2].Kind, Equals, EventLockout)
+	c.Check(events[2].RemoteIP, Equals, "123.123.123.123")
+	c.Check(events[2].Err, ErrorMatches, "too many failed verifications from '123.123.123.123'")
+}
+
+func (s *MiddlewareSuite) TestMiddlewareVelocityOutage(c *C) {
+	captcha := ReCAPTCHA{client: &mockUnavailableClient{}, Version: V3}
+	middleware := NewMiddleware(&captcha, VerifyOption{})
+	middleware.Velocity, _ = NewVelocityTracker(NewMemoryStore(), time.Minute, 2)
+	handler := middleware.Handler(okHandler)
+
+	// request failures and configuration errors aren't the client's doing
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"token"}}))
+		c.Check(w.Code, Equals, http.StatusForbidden)
+	}
+	captcha.client = mockBodyClient(`{"success": false, "error-codes": ["invalid-input-secret"]}`)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"token"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	locked, err := middleware.Velocity.Locked("123.123.123.123")
+	c.Assert(err, IsNil)
+	c.Check(locked, Equals, false)
+
+	captcha.client = mockBodyClient(`{"success": false, "error-codes": ["timeout-or-duplicate"]}`)
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), postForm(url.Values{"g-recaptcha-response": {"token"}}))
+	}
+	locked, err = middleware.Velocity.Locked("123.123.123.123")
+	c.Assert(err, IsNil)
+	c.Check(locked, Equals, true)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareHoneypot(c *C) {
+	calls := 0
+	verifier := mockVerifier(func(challengeResponse string, opts VerifyOption) error {
+		calls++
+		return nil
+	})
+	var events []Event
+	middleware := NewMiddleware(verifier, VerifyOption{})
+	middleware.Honeypot = "website"
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+	middleware.Forbidden = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outcome, _ := OutcomeFromContext(r.Context())
+		http.Error(w, outcome.Reasons[0], http.StatusForbidden)
+	})
+	handler := middleware.Handler(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}, "website": {""}}))
+	c.Check(w.Code, Equals, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}, "website": {"http://spam.com"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(w.Body.String(), Equals, "honeypot field 'website' filled\n")
+	c.Check(calls, Equals, 1)
+	c.Assert(events, HasLen, 2)
+	c.Check(events[1].Kind, Equals, EventHoneypot)
+	c.Check(events[1].Decision, Equals, Block)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareObserverLayers(c *C) {
+	var verifierEvents, middlewareEvents []Event
+	captcha := &ReCAPTCHA{
+		client:    mockBodyClient(loginResponseBody),
+		Version:   V3,
+		Observers: []Observer{ObserverFunc(func(event Event) { verifierEvents = append(verifierEvents, event) })},
+	}
+	middleware := NewMiddleware(captcha, VerifyOption{})
+	middleware.Policy = &Policy{BlockBelow: 0.3, ChallengeBelow: 0.7}
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { middlewareEvents = append(middlewareEvents, event) })}
+
+	w := httptest.NewRecorder()
+	middleware.Handler(okHandler).ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}}))
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	// each layer reports the verification once, the middleware with its own decision
+	c.Assert(verifierEvents, HasLen, 1)
+	c.Assert(middlewareEvents, HasLen, 1)
+	c.Check(verifierEvents[0].Kind, Equals, EventVerification)
+	c.Check(verifierEvents[0].Decision, Equals, Allow)
+	c.Check(middlewareEvents[0].Kind, Equals, EventVerification)
+	c.Check(middlewareEvents[0].Decision, Equals, Challenge)
+}
+
+func (s *MiddlewareSuite) TestMiddlewareContext(c *C) {
+	var options VerifyOption
+	verifier := mockVerifier(func(challengeResponse string, opts VerifyOption) error {
+		options = opts
+		return nil
+	})
+	var events []Event
+	middleware := NewMiddleware(verifier, VerifyOption{Context: map[string]string{"tenant": "acme", "form": "default"}})
+	middleware.Context = func(r *http.Request) map[string]string {
+		return map[string]string{"form": r.URL.Path}
+	}
+	middleware.Observers = []Observer{ObserverFunc(func(event Event) { events = append(events, event) })}
+
+	w := httptest.NewRecorder()
+	middleware.Handler(okHandler).ServeHTTP(w, postForm(url.Values{"g-recaptcha-response": {"valid"}}))
+	c.Check(w.Code, Equals, http.StatusOK)
+	expected := map[string]string{"tenant": "acme", "form": "/form"}
+	c.Check(options.Context, DeepEquals, expected)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Context, DeepEquals, expected)
+	c.Check(middleware.Options.Context["form"], Equals, "default")
+}
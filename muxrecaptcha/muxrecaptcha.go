@@ -0,0 +1,73 @@
+// Package muxrecaptcha adapts recaptcha.Middleware to gorilla/mux routers, reading the per-route
+// action and threshold registered under the route names instead of wrapping every handler.
+package muxrecaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// RouteOptions verification metadata of a named route.
+type RouteOptions struct {
+	Action string
+	// Threshold minimum score of the route, the middleware one when zero.
+	Threshold float32
+}
+
+// Middleware mux.MiddlewareFunc provider verifying the requests of the routes registered with Route,
+// requests of other routes are passed through.
+type Middleware struct {
+	// Base middleware verifying the requests, its Options are completed with the route ones.
+	Base *recaptcha.Middleware
+
+	mu     sync.RWMutex
+	routes map[string]RouteOptions
+}
+
+// New new Middleware verifying the registered routes with base
+func New(base *recaptcha.Middleware) (*Middleware, error) {
+	if base == nil {
+		return nil, fmt.Errorf("base middleware cannot be nil")
+	}
+	return &Middleware{Base: base, routes: map[string]RouteOptions{}}, nil
+}
+
+// Route registers the options of the route named name, e.g. set with router.HandleFunc(...).Name(name).
+func (m *Middleware) Route(name string, options RouteOptions) *Middleware {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[name] = options
+	return m
+}
+
+// Middleware wraps next, verifying the requests of registered routes, to pass to router.Use.
+func (m *Middleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.mu.RLock()
+		options, ok := m.routes[route.GetName()]
+		m.mu.RUnlock()
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		middleware := *m.Base
+		middleware.Options.Action = options.Action
+		if options.Threshold != 0 {
+			middleware.Options.Threshold = options.Threshold
+		}
+		middleware.Handler(next).ServeHTTP(w, r)
+	})
+}
+
+var _ mux.MiddlewareFunc = (*Middleware)(nil).Middleware
@@ -0,0 +1,81 @@
+package muxrecaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type MuxSuite struct{}
+
+var _ = Suite(&MuxSuite{})
+
+// mockVerifier records the options of the verifications, failing the "invalid" challenge response.
+type mockVerifier struct {
+	options []recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = append(m.options, options)
+	if challengeResponse == "invalid" {
+		return recaptcha.Response{}, &recaptcha.Error{}
+	}
+	return recaptcha.Response{Success: true, Score: 0.9}, nil
+}
+
+func post(router http.Handler, path, challengeResponse string) int {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(url.Values{recaptcha.DefaultResponseField: {challengeResponse}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func (s *MuxSuite) TestNew(c *C) {
+	_, err := New(nil)
+	c.Check(err, ErrorMatches, "base middleware cannot be nil")
+}
+
+func (s *MuxSuite) TestRoutes(c *C) {
+	verifier := &mockVerifier{}
+	middleware, err := New(recaptcha.NewMiddleware(verifier, recaptcha.VerifyOption{Threshold: 0.5}))
+	c.Assert(err, IsNil)
+	middleware.Route("login", RouteOptions{Action: "login", Threshold: 0.7}).Route("signup", RouteOptions{Action: "signup"})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router := mux.NewRouter()
+	router.Handle("/login", ok).Methods(http.MethodPost).Name("login")
+	router.Handle("/signup", ok).Methods(http.MethodPost).Name("signup")
+	router.Handle("/search", ok).Name("search")
+	router.Use(middleware.Middleware)
+
+	c.Check(post(router, "/login", "valid"), Equals, http.StatusOK)
+	c.Check(post(router, "/signup", "valid"), Equals, http.StatusOK)
+	c.Check(verifier.options, HasLen, 2)
+	c.Check(verifier.options[0].Action, Equals, "login")
+	c.Check(verifier.options[0].Threshold, Equals, float32(0.7))
+	c.Check(verifier.options[1].Action, Equals, "signup")
+	c.Check(verifier.options[1].Threshold, Equals, float32(0.5))
+
+	c.Check(post(router, "/login", "invalid"), Equals, http.StatusForbidden)
+	c.Check(post(router, "/search", "invalid"), Equals, http.StatusOK)
+	c.Check(verifier.options, HasLen, 3)
+}
@@ -0,0 +1,169 @@
+// Package opa delegates verification decisions to Open Policy Agent, sending the verification result
+// and request metadata as input to a Rego policy evaluated by a remote OPA server or an embedded engine.
+//
+// The policy must evaluate to an object such as {"decision": "challenge", "reasons": ["low score"]},
+// decision being one of "allow", "challenge" or "block". For example:
+//
+//	package recaptcha
+//
+//	default decision := {"decision": "block", "reasons": ["verification failed"]}
+//
+//	decision := {"decision": "allow", "reasons": []} if {
+//		input.result.success
+//		input.result.score >= 0.5
+//	}
+//
+// Embedded engines, e.g. a rego.PreparedEvalQuery, are plugged in by implementing Evaluator.
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Input document passed to the policy.
+type Input struct {
+	Result  ResultInput  `json:"result"`
+	Request RequestInput `json:"request"`
+}
+
+// ResultInput verification result, Error is blank for successful verifications.
+type ResultInput struct {
+	Success     bool      `json:"success"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	Action      string    `json:"action"`
+	Score       float32   `json:"score"`
+	ErrorCodes  []string  `json:"error_codes"`
+	Error       string    `json:"error"`
+}
+
+// RequestInput metadata of the verified request.
+type RequestInput struct {
+	RemoteIP string            `json:"remote_ip"`
+	Action   string            `json:"action"`
+	Context  map[string]string `json:"context"`
+}
+
+// Output document the policy must evaluate to.
+type Output struct {
+	Decision string   `json:"decision"`
+	Reasons  []string `json:"reasons"`
+}
+
+// Evaluator evaluates the policy for an input.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input Input) (Output, error)
+}
+
+// Decider recaptcha.Decider evaluating a Rego policy.
+type Decider struct {
+	Evaluator Evaluator
+	// Timeout of the policy evaluations, none when zero.
+	Timeout time.Duration
+}
+
+var _ recaptcha.Decider = (*Decider)(nil)
+
+// NewDecider new Decider evaluating policies with evaluator
+func NewDecider(evaluator Evaluator) *Decider {
+	return &Decider{Evaluator: evaluator}
+}
+
+// Decide evaluates the policy for the verification result and request metadata.
+func (d *Decider) Decide(ctx context.Context, result recaptcha.VerifyResult, options recaptcha.VerifyOption) (recaptcha.Outcome, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	output, err := d.Evaluator.Evaluate(ctx, NewInput(result, options))
+	if err != nil {
+		return recaptcha.Outcome{}, err
+	}
+	decision, err := recaptcha.ParseDecision(output.Decision)
+	if err != nil {
+		return recaptcha.Outcome{}, err
+	}
+	return recaptcha.Outcome{Decision: decision, Reasons: output.Reasons}, nil
+}
+
+// NewInput builds the policy input of a verification.
+func NewInput(result recaptcha.VerifyResult, options recaptcha.VerifyOption) Input {
+	input := Input{
+		Result: ResultInput{
+			Success:     result.Response.Success,
+			ChallengeTS: result.Response.ChallengeTS,
+			Hostname:    result.Response.Hostname,
+			Action:      result.Response.Action,
+			Score:       result.Response.Score,
+			ErrorCodes:  result.Response.ErrorCodes,
+		},
+		Request: RequestInput{RemoteIP: options.RemoteIP, Action: options.Action, Context: options.Context},
+	}
+	if result.Err != nil {
+		input.Result.Error = result.Err.Error()
+	}
+	return input
+}
+
+// Remote Evaluator querying the data API of an OPA server.
+type Remote struct {
+	// URL base URL of the OPA server, e.g. http://localhost:8181.
+	URL string
+	// Path slash separated path of the policy decision, e.g. recaptcha/decision.
+	Path   string
+	Client *http.Client
+}
+
+// NewRemote new Remote querying the decision at path of the OPA server at url
+func NewRemote(url, path string) *Remote {
+	return &Remote{URL: url, Path: path, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Evaluate posts the input to the OPA data API and decodes the result.
+func (r *Remote) Evaluate(ctx context.Context, input Input) (Output, error) {
+	body, err := json.Marshal(struct {
+		Input Input `json:"input"`
+	}{input})
+	if err != nil {
+		return Output{}, err
+	}
+	url := strings.TrimSuffix(r.URL, "/") + "/v1/data/" + strings.Trim(r.Path, "/")
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Output{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return Output{}, fmt.Errorf("couldn't query OPA: '%s'", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Output{}, fmt.Errorf("OPA replied '%s'", resp.Status)
+	}
+
+	var decoded struct {
+		Result *Output `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return Output{}, fmt.Errorf("couldn't decode OPA response: '%s'", err)
+	}
+	if decoded.Result == nil {
+		return Output{}, fmt.Errorf("OPA policy '%s' is undefined", r.Path)
+	}
+	return *decoded.Result, nil
+}
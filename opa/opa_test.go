@@ -0,0 +1,89 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type OPASuite struct{}
+
+var _ = Suite(&OPASuite{})
+
+// opaServer fakes the OPA data API, replying with body and recording the input.
+func opaServer(c *C, body string, input *Input) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, http.MethodPost)
+		c.Check(r.URL.Path, Equals, "/v1/data/recaptcha/decision")
+		var request struct {
+			Input Input `json:"input"`
+		}
+		c.Check(json.NewDecoder(r.Body).Decode(&request), IsNil)
+		*input = request.Input
+		w.Write([]byte(body))
+	}))
+}
+
+func (s *OPASuite) TestRemoteDecider(c *C) {
+	var input Input
+	server := opaServer(c, `{"result": {"decision": "challenge", "reasons": ["new account"]}}`, &input)
+	defer server.Close()
+
+	decider := NewDecider(NewRemote(server.URL+"/", "/recaptcha/decision"))
+	outcome, err := decider.Decide(context.Background(),
+		recaptcha.VerifyResult{Response: recaptcha.Response{Success: true, Action: "signup", Score: 0.7}},
+		recaptcha.VerifyOption{RemoteIP: "127.0.0.1", Action: "signup", Context: map[string]string{"tenant": "acme"}})
+	c.Assert(err, IsNil)
+	c.Check(outcome, DeepEquals, recaptcha.Outcome{Decision: recaptcha.Challenge, Reasons: []string{"new account"}})
+
+	c.Check(input.Result.Success, Equals, true)
+	c.Check(input.Result.Score, Equals, float32(0.7))
+	c.Check(input.Result.Error, Equals, "")
+	c.Check(input.Request, DeepEquals, RequestInput{RemoteIP: "127.0.0.1", Action: "signup", Context: map[string]string{"tenant": "acme"}})
+}
+
+func (s *OPASuite) TestRemoteUndefinedDecision(c *C) {
+	var input Input
+	server := opaServer(c, `{}`, &input)
+	defer server.Close()
+
+	_, err := NewRemote(server.URL, "recaptcha/decision").Evaluate(context.Background(), Input{})
+	c.Check(err, ErrorMatches, "OPA policy 'recaptcha/decision' is undefined")
+}
+
+func (s *OPASuite) TestRemoteUnknownDecision(c *C) {
+	var input Input
+	server := opaServer(c, `{"result": {"decision": "maybe"}}`, &input)
+	defer server.Close()
+
+	_, err := NewDecider(NewRemote(server.URL, "recaptcha/decision")).Decide(context.Background(), recaptcha.VerifyResult{}, recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "unknown decision 'maybe'")
+}
+
+func (s *OPASuite) TestRemoteError(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := NewRemote(server.URL, "recaptcha/decision").Evaluate(context.Background(), Input{})
+	c.Check(err, ErrorMatches, "OPA replied '500 Internal Server Error'")
+}
+
+func (s *OPASuite) TestNewInput(c *C) {
+	input := NewInput(recaptcha.VerifyResult{
+		Response: recaptcha.Response{ErrorCodes: []string{"invalid-input-response"}},
+		Err:      errors.New("invalid challenge solution"),
+	}, recaptcha.VerifyOption{})
+	c.Check(input.Result.Error, Equals, "invalid challenge solution")
+	c.Check(input.Result.ErrorCodes, DeepEquals, []string{"invalid-input-response"})
+}
@@ -0,0 +1,75 @@
+package recaptcha
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PassToken content of a pass token issued by IssuePassToken.
+type PassToken struct {
+	// VerifiedAt time of the verification.
+	VerifiedAt time.Time
+	ExpiresAt  time.Time
+	// Score and Action of the verification response, V3 only.
+	Score  float32
+	Action string
+}
+
+// IssuePassToken returns a compact token signed with secretKey asserting that response passed
+// verification, valid for ttl. A lightweight alternative to HumanTokens for propagating "captcha
+// passed" across services sharing secretKey, see ValidatePassToken.
+func IssuePassToken(secretKey []byte, ttl time.Duration, response Response) (string, error) {
+	return IssuePassTokenAt(secretKey, ttl, response, time.Now())
+}
+
+// IssuePassTokenAt same as IssuePassToken, the verification taking place at now
+func IssuePassTokenAt(secretKey []byte, ttl time.Duration, response Response, now time.Time) (string, error) {
+	if len(secretKey) == 0 {
+		return "", fmt.Errorf("pass token key cannot be blank")
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("pass token ttl must be positive")
+	}
+	payload := strings.Join([]string{
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(now.Add(ttl).Unix(), 10),
+		strconv.FormatFloat(float64(response.Score), 'g', -1, 32),
+		response.Action,
+	}, "|")
+	return seal(secretKey, purposePassToken, payload), nil
+}
+
+// ValidatePassToken returns the content of the token when it is signed with secretKey and not expired.
+func ValidatePassToken(secretKey []byte, token string) (PassToken, error) {
+	return ValidatePassTokenAt(secretKey, token, time.Now())
+}
+
+// ValidatePassTokenAt same as ValidatePassToken, checking the expiry at now
+func ValidatePassTokenAt(secretKey []byte, token string, now time.Time) (PassToken, error) {
+	payload, ok := unseal(secretKey, purposePassToken, token)
+	if !ok {
+		return PassToken{}, &Error{msg: "invalid pass token"}
+	}
+	fields := strings.SplitN(payload, "|", 4)
+	if len(fields) != 4 {
+		return PassToken{}, &Error{msg: "invalid pass token"}
+	}
+	verifiedAt, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return PassToken{}, &Error{msg: "invalid pass token"}
+	}
+	expiry, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return PassToken{}, &Error{msg: "invalid pass token"}
+	}
+	score, err := strconv.ParseFloat(fields[2], 32)
+	if err != nil {
+		return PassToken{}, &Error{msg: "invalid pass token"}
+	}
+	if now.Unix() >= expiry {
+		return PassToken{}, &Error{msg: "expired pass token"}
+	}
+	return PassToken{VerifiedAt: time.Unix(verifiedAt, 0), ExpiresAt: time.Unix(expiry, 0), Score: float32(score), Action: fields[3]}, nil
+}
@@ -0,0 +1,49 @@
+package recaptcha
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type PassTokenSuite struct{}
+
+var _ = Suite(&PassTokenSuite{})
+
+func (s *PassTokenSuite) TestPassToken(c *C) {
+	_, err := IssuePassToken(nil, time.Minute, Response{})
+	c.Check(err, ErrorMatches, "pass token key cannot be blank")
+	_, err = IssuePassToken([]byte("key"), 0, Response{})
+	c.Check(err, ErrorMatches, "pass token ttl must be positive")
+
+	token, err := IssuePassToken([]byte("key"), time.Minute, Response{Success: true, Score: 0.7, Action: "checkout|step_2"})
+	c.Assert(err, IsNil)
+	pass, err := ValidatePassToken([]byte("key"), token)
+	c.Assert(err, IsNil)
+	c.Check(pass.Score, Equals, float32(0.7))
+	c.Check(pass.Action, Equals, "checkout|step_2")
+	c.Check(pass.ExpiresAt.Sub(pass.VerifiedAt), Equals, time.Minute)
+	c.Check(time.Since(pass.VerifiedAt) < time.Minute, Equals, true)
+
+	_, err = ValidatePassToken([]byte("other key"), token)
+	c.Check(err, ErrorMatches, "invalid pass token")
+	_, err = ValidatePassToken([]byte("key"), seal([]byte("key"), purposePassToken, "1|2"))
+	c.Check(err, ErrorMatches, "invalid pass token")
+	_, err = ValidatePassToken([]byte("key"), seal([]byte("key"), purposePassToken, "1|2|0.9|login"))
+	c.Check(err, ErrorMatches, "expired pass token")
+}
+
+func (s *PassTokenSuite) TestPassTokenAt(c *C) {
+	now := time.Unix(1500000000, 0)
+	token, err := IssuePassTokenAt([]byte("key"), time.Minute, Response{Score: 0.9, Action: "login"}, now)
+	c.Assert(err, IsNil)
+	pass, err := ValidatePassTokenAt([]byte("key"), token, now.Add(59*time.Second))
+	c.Assert(err, IsNil)
+	c.Check(pass.VerifiedAt, Equals, now)
+	c.Check(pass.ExpiresAt, Equals, now.Add(time.Minute))
+	_, err = ValidatePassTokenAt([]byte("key"), token, now.Add(time.Minute))
+	c.Check(err, ErrorMatches, "expired pass token")
+	// issued in 2017, long expired
+	_, err = ValidatePassToken([]byte("key"), token)
+	c.Check(err, ErrorMatches, "expired pass token")
+}
@@ -0,0 +1,303 @@
+// Package playintegrity verifies Google Play Integrity API verdicts behind the recaptcha.Verifier
+// interface, so Android integrity tokens go through the same middleware, policies and decisions as
+// recaptcha challenges.
+//
+// Tokens are decoded by Google's decodeIntegrityToken endpoint, which requires an OAuth2 client
+// authorized for the https://www.googleapis.com/auth/playintegrity scope, e.g. one built with
+// golang.org/x/oauth2/google.DefaultClient.
+package playintegrity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+const decodeLink = "https://playintegrity.googleapis.com/v1/%s:decodeIntegrityToken"
+
+// DefaultMaxAge maximum time elapsed since the token was requested when Verifier.MaxAge is zero, so a
+// captured token can't be replayed forever.
+const DefaultMaxAge = 5 * time.Minute
+
+// maxResponseSize decoded token responses larger than this are rejected.
+const maxResponseSize = 1 << 20
+
+// DeviceIntegrity device recognition verdict, from the weakest to the strongest
+type DeviceIntegrity int8
+
+const (
+	// NoIntegrity the device doesn't meet any integrity level.
+	NoIntegrity DeviceIntegrity = iota
+	// MeetsBasicIntegrity the device passes basic integrity checks, it may be rooted or an emulator.
+	MeetsBasicIntegrity
+	// MeetsDeviceIntegrity the device is a genuine Android device with Google Play services.
+	MeetsDeviceIntegrity
+	// MeetsStrongIntegrity the device integrity is backed by hardware, with a recent security patch.
+	MeetsStrongIntegrity
+)
+
+var deviceVerdicts = map[DeviceIntegrity]string{
+	NoIntegrity:          "NO_INTEGRITY",
+	MeetsBasicIntegrity:  "MEETS_BASIC_INTEGRITY",
+	MeetsDeviceIntegrity: "MEETS_DEVICE_INTEGRITY",
+	MeetsStrongIntegrity: "MEETS_STRONG_INTEGRITY",
+}
+
+func (d DeviceIntegrity) String() string {
+	if verdict, ok := deviceVerdicts[d]; ok {
+		return verdict
+	}
+	return fmt.Sprintf("DeviceIntegrity(%d)", int8(d))
+}
+
+// scores maps device integrity levels to the score of the verification response, letting recaptcha
+// policies apply to integrity verdicts.
+var scores = map[DeviceIntegrity]float32{NoIntegrity: 0, MeetsBasicIntegrity: 0.5, MeetsDeviceIntegrity: 0.9, MeetsStrongIntegrity: 1}
+
+// Verdict decoded integrity token payload
+type Verdict struct {
+	RequestDetails struct {
+		RequestPackageName string `json:"requestPackageName"`
+		TimestampMillis    string `json:"timestampMillis"`
+		Nonce              string `json:"nonce"`
+		RequestHash        string `json:"requestHash"`
+	} `json:"requestDetails"`
+	AppIntegrity struct {
+		AppRecognitionVerdict string `json:"appRecognitionVerdict"`
+		PackageName           string `json:"packageName"`
+		VersionCode           string `json:"versionCode"`
+	} `json:"appIntegrity"`
+	DeviceIntegrity struct {
+		DeviceRecognitionVerdict []string `json:"deviceRecognitionVerdict"`
+	} `json:"deviceIntegrity"`
+	AccountDetails struct {
+		AppLicensingVerdict string `json:"appLicensingVerdict"`
+	} `json:"accountDetails"`
+}
+
+// Device returns the strongest integrity level met by the device.
+func (v Verdict) Device() DeviceIntegrity {
+	level := NoIntegrity
+	for _, verdict := range v.DeviceIntegrity.DeviceRecognitionVerdict {
+		for l, name := range deviceVerdicts {
+			if name == verdict && l > level {
+				level = l
+			}
+		}
+	}
+	return level
+}
+
+// Verifier recaptcha.Verifier decoding Play Integrity tokens. The decoded verification response
+// holds the package name as ApkPackageName, the request time as ChallengeTS and a Score derived
+// from the device integrity: 0.5 for basic, 0.9 for device and 1 for strong integrity. The request
+// and app package names must both be PackageName. Bind the tokens to the protected request with
+// VerifyRequest or VerifyNonce, the other methods only bounding their age.
+type Verifier struct {
+	// Client OAuth2 authorized HTTP client.
+	Client *http.Client
+	// PackageName Android package name of the app.
+	PackageName string
+	// MinDeviceIntegrity minimum device integrity, none when zero.
+	MinDeviceIntegrity DeviceIntegrity
+	// RequirePlayRecognized reject apps not recognized by Google Play, e.g. sideloaded or modified.
+	RequirePlayRecognized bool
+	// RequireLicensed reject users who didn't install or buy the app on Google Play.
+	RequireLicensed bool
+	// MaxAge maximum time elapsed since the token was requested, DefaultMaxAge when zero and unlimited
+	// when negative. VerifyOption.MaxChallengeAge takes precedence.
+	MaxAge time.Duration
+
+	decodeLink string
+	now        func() time.Time
+}
+
+var _ recaptcha.Verifier = (*Verifier)(nil)
+var _ recaptcha.ContextVerifier = (*Verifier)(nil)
+
+// NewVerifier new Verifier decoding tokens of the app packageName with the OAuth2 authorized client
+func NewVerifier(client *http.Client, packageName string) (*Verifier, error) {
+	if client == nil {
+		return nil, fmt.Errorf("play integrity client cannot be nil")
+	}
+	if packageName == "" {
+		return nil, fmt.Errorf("play integrity package name cannot be blank")
+	}
+	return &Verifier{Client: client, PackageName: packageName, RequirePlayRecognized: true, now: time.Now}, nil
+}
+
+// Verify returns `nil` if the integrity token is valid and its verdict meets the requirements
+func (v *Verifier) Verify(token string) error {
+	_, err := v.VerifyWithOptionsResponse(token, recaptcha.VerifyOption{})
+	return err
+}
+
+// VerifyWithOptions same as Verify, ApkPackageName and MaxChallengeAge options are checked as well
+func (v *Verifier) VerifyWithOptions(token string, options recaptcha.VerifyOption) error {
+	_, err := v.VerifyWithOptionsResponse(token, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the verification response
+// derived from the verdict
+func (v *Verifier) VerifyWithOptionsResponse(token string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(context.Background(), token, binding{}, options)
+}
+
+// VerifyWithOptionsResponseContext same as VerifyWithOptionsResponse, ctx canceling the decoding request
+func (v *Verifier) VerifyWithOptionsResponseContext(ctx context.Context, token string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(ctx, token, binding{}, options)
+}
+
+// VerifyRequest same as VerifyWithOptionsResponse, also checking the standard request token was
+// requested for the requestHash computed by the app for the protected request, when not blank
+func (v *Verifier) VerifyRequest(token, requestHash string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(context.Background(), token, binding{requestHash: requestHash}, options)
+}
+
+// VerifyRequestContext same as VerifyRequest, ctx canceling the decoding request
+func (v *Verifier) VerifyRequestContext(ctx context.Context, token, requestHash string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(ctx, token, binding{requestHash: requestHash}, options)
+}
+
+// VerifyNonce same as VerifyWithOptionsResponse, also checking the classic request token was
+// requested with the nonce issued by the server for the protected request, when not blank
+func (v *Verifier) VerifyNonce(token, nonce string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(context.Background(), token, binding{nonce: nonce}, options)
+}
+
+// VerifyNonceContext same as VerifyNonce, ctx canceling the decoding request
+func (v *Verifier) VerifyNonceContext(ctx context.Context, token, nonce string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return v.verify(ctx, token, binding{nonce: nonce}, options)
+}
+
+// binding request the token must have been requested for, unchecked when blank.
+type binding struct {
+	requestHash, nonce string
+}
+
+func (v *Verifier) verify(ctx context.Context, token string, binding binding, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	verdict, err := v.DecodeContext(ctx, token)
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+
+	response := recaptcha.Response{
+		ApkPackageName: verdict.AppIntegrity.PackageName,
+		Score:          scores[verdict.Device()],
+	}
+	if millis, err := strconv.ParseInt(verdict.RequestDetails.TimestampMillis, 10, 64); err == nil {
+		response.ChallengeTS = time.Unix(0, millis*int64(time.Millisecond))
+	}
+
+	if err := v.check(verdict, response, binding, options); err != nil {
+		return response, err
+	}
+	response.Success = true
+	return response, nil
+}
+
+func (v *Verifier) check(verdict Verdict, response recaptcha.Response, binding binding, options recaptcha.VerifyOption) error {
+	if verdict.RequestDetails.RequestPackageName != v.PackageName {
+		return fmt.Errorf("invalid request package name '%s', while expecting '%s'", verdict.RequestDetails.RequestPackageName, v.PackageName)
+	}
+	if options.ApkPackageName != "" && options.ApkPackageName != response.ApkPackageName {
+		return fmt.Errorf("invalid response ApkPackageName '%s', while expecting '%s'", response.ApkPackageName, options.ApkPackageName)
+	}
+	if binding.requestHash != "" && verdict.RequestDetails.RequestHash != binding.requestHash {
+		return fmt.Errorf("invalid request hash '%s', while expecting '%s'", verdict.RequestDetails.RequestHash, binding.requestHash)
+	}
+	if binding.nonce != "" && verdict.RequestDetails.Nonce != binding.nonce {
+		return fmt.Errorf("invalid request nonce '%s', while expecting '%s'", verdict.RequestDetails.Nonce, binding.nonce)
+	}
+	if v.RequirePlayRecognized && verdict.AppIntegrity.AppRecognitionVerdict != "PLAY_RECOGNIZED" {
+		return fmt.Errorf("app recognition verdict '%s', while expecting 'PLAY_RECOGNIZED'", verdict.AppIntegrity.AppRecognitionVerdict)
+	}
+	if response.ApkPackageName != v.PackageName {
+		return fmt.Errorf("invalid app package name '%s', while expecting '%s'", response.ApkPackageName, v.PackageName)
+	}
+	if v.RequireLicensed && verdict.AccountDetails.AppLicensingVerdict != "LICENSED" {
+		return fmt.Errorf("app licensing verdict '%s', while expecting 'LICENSED'", verdict.AccountDetails.AppLicensingVerdict)
+	}
+	if device := verdict.Device(); device < v.MinDeviceIntegrity {
+		return fmt.Errorf("device integrity '%s', while expecting minimum '%s'", device, v.MinDeviceIntegrity)
+	}
+
+	maxAge := options.MaxChallengeAge
+	if maxAge == 0 {
+		maxAge = v.MaxAge
+	}
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	if maxAge > 0 {
+		if response.ChallengeTS.IsZero() {
+			return fmt.Errorf("missing request timestamp")
+		}
+		if age := v.clock().Sub(response.ChallengeTS); maxAge < age {
+			return fmt.Errorf("token requested '%fs' ago, while expecting maximum '%fs'", age.Seconds(), maxAge.Seconds())
+		}
+	}
+	return nil
+}
+
+// Decode decodes the integrity token with Google's API.
+func (v *Verifier) Decode(token string) (Verdict, error) {
+	return v.DecodeContext(context.Background(), token)
+}
+
+// DecodeContext same as Decode, ctx canceling the decoding request
+func (v *Verifier) DecodeContext(ctx context.Context, token string) (Verdict, error) {
+	body, err := json.Marshal(map[string]string{"integrity_token": token})
+	if err != nil {
+		return Verdict{}, err
+	}
+	link := v.decodeLink
+	if link == "" {
+		link = decodeLink
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(link, url.PathEscape(v.PackageName)), bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("error posting to play integrity endpoint: '%s'", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("couldn't read response body: '%s'", err)
+	}
+	if len(respBody) > maxResponseSize {
+		return Verdict{}, fmt.Errorf("response body larger than '%d' bytes", maxResponseSize)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("play integrity endpoint replied '%s': %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	var decoded struct {
+		TokenPayloadExternal Verdict `json:"tokenPayloadExternal"`
+	}
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		return Verdict{}, fmt.Errorf("invalid response body json: '%s'", err)
+	}
+	return decoded.TokenPayloadExternal, nil
+}
+
+func (v *Verifier) clock() time.Time {
+	if v.now == nil {
+		return time.Now()
+	}
+	return v.now()
+}
@@ -0,0 +1,141 @@
+package playintegrity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type PlayIntegritySuite struct {
+	server  *httptest.Server
+	payload string
+}
+
+var _ = Suite(&PlayIntegritySuite{})
+
+const verdictPayload = `{"tokenPayloadExternal": {
+	"requestDetails": {"requestPackageName": "com.example.app", "timestampMillis": "1500000000000", "requestHash": "hash", "nonce": "bm9uY2U"},
+	"appIntegrity": {"appRecognitionVerdict": "PLAY_RECOGNIZED", "packageName": "com.example.app", "versionCode": "42"},
+	"deviceIntegrity": {"deviceRecognitionVerdict": ["MEETS_BASIC_INTEGRITY", "MEETS_DEVICE_INTEGRITY"]},
+	"accountDetails": {"appLicensingVerdict": "UNLICENSED"}
+}}`
+
+func (s *PlayIntegritySuite) SetUpTest(c *C) {
+	s.payload = verdictPayload
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/com.example.app:decodeIntegrityToken")
+		var body map[string]string
+		c.Check(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		if body["integrity_token"] != "token" {
+			http.Error(w, `{"error": {"message": "invalid token"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(s.payload))
+	}))
+}
+
+func (s *PlayIntegritySuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *PlayIntegritySuite) verifier(c *C) *Verifier {
+	verifier, err := NewVerifier(s.server.Client(), "com.example.app")
+	c.Assert(err, IsNil)
+	verifier.decodeLink = s.server.URL + "/v1/%s:decodeIntegrityToken"
+	verifier.now = func() time.Time { return time.Unix(1500000060, 0) }
+	return verifier
+}
+
+func (s *PlayIntegritySuite) TestNewVerifier(c *C) {
+	_, err := NewVerifier(nil, "com.example.app")
+	c.Check(err, ErrorMatches, "play integrity client cannot be nil")
+	_, err = NewVerifier(http.DefaultClient, "")
+	c.Check(err, ErrorMatches, "play integrity package name cannot be blank")
+}
+
+func (s *PlayIntegritySuite) TestVerify(c *C) {
+	verifier := s.verifier(c)
+	response, err := verifier.VerifyWithOptionsResponse("token", recaptcha.VerifyOption{ApkPackageName: "com.example.app"})
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(response.Score, Equals, float32(0.9))
+	c.Check(response.ApkPackageName, Equals, "com.example.app")
+	c.Check(response.ChallengeTS.Equal(time.Unix(1500000000, 0)), Equals, true)
+
+	c.Check(verifier.Verify("other"), ErrorMatches, "play integrity endpoint replied '400 Bad Request'.*invalid token.*")
+
+	_, err = verifier.VerifyRequest("token", "hash", recaptcha.VerifyOption{})
+	c.Check(err, IsNil)
+	_, err = verifier.VerifyRequest("token", "other", recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "invalid request hash 'hash', while expecting 'other'")
+
+	_, err = verifier.VerifyNonce("token", "bm9uY2U", recaptcha.VerifyOption{})
+	c.Check(err, IsNil)
+	_, err = verifier.VerifyNonce("token", "b3RoZXI", recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "invalid request nonce 'bm9uY2U', while expecting 'b3RoZXI'")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = verifier.VerifyWithOptionsResponseContext(ctx, "token", recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "error posting to play integrity endpoint: .*context canceled.*")
+}
+
+func (s *PlayIntegritySuite) TestDefaultMaxAge(c *C) {
+	verifier := s.verifier(c)
+	// a captured token isn't accepted forever
+	verifier.now = func() time.Time { return time.Unix(1500000000, 0).Add(DefaultMaxAge + time.Second) }
+	c.Check(verifier.Verify("token"), ErrorMatches, "token requested '301.000000s' ago, while expecting maximum '300.000000s'")
+	verifier.MaxAge = -1
+	c.Check(verifier.Verify("token"), IsNil)
+}
+
+func (s *PlayIntegritySuite) TestPackageName(c *C) {
+	verifier := s.verifier(c)
+	s.payload = strings.Replace(verdictPayload, `"packageName": "com.example.app"`, `"packageName": "com.repackaged.app"`, 1)
+	c.Check(verifier.Verify("token"), ErrorMatches, "invalid app package name 'com.repackaged.app', while expecting 'com.example.app'")
+}
+
+func (s *PlayIntegritySuite) TestLargeResponse(c *C) {
+	verifier := s.verifier(c)
+	s.payload = `{"padding": "` + strings.Repeat("a", maxResponseSize) + `"}`
+	c.Check(verifier.Verify("token"), ErrorMatches, "response body larger than '1048576' bytes")
+}
+
+func (s *PlayIntegritySuite) TestVerdictRequirements(c *C) {
+	verifier := s.verifier(c)
+	verifier.RequireLicensed = true
+	c.Check(verifier.Verify("token"), ErrorMatches, "app licensing verdict 'UNLICENSED', while expecting 'LICENSED'")
+
+	verifier.RequireLicensed = false
+	verifier.MinDeviceIntegrity = MeetsStrongIntegrity
+	c.Check(verifier.Verify("token"), ErrorMatches, "device integrity 'MEETS_DEVICE_INTEGRITY', while expecting minimum 'MEETS_STRONG_INTEGRITY'")
+
+	verifier.MinDeviceIntegrity = MeetsDeviceIntegrity
+	verifier.MaxAge = 30 * time.Second
+	c.Check(verifier.Verify("token"), ErrorMatches, "token requested '60.000000s' ago, while expecting maximum '30.000000s'")
+	c.Check(verifier.VerifyWithOptions("token", recaptcha.VerifyOption{MaxChallengeAge: 2 * time.Minute}), IsNil)
+
+	verifier.MaxAge = 0
+	c.Check(verifier.VerifyWithOptions("token", recaptcha.VerifyOption{ApkPackageName: "com.other.app"}), ErrorMatches, "invalid response ApkPackageName 'com.example.app', while expecting 'com.other.app'")
+
+	s.payload = `{"tokenPayloadExternal": {"requestDetails": {"requestPackageName": "com.example.app"}, "appIntegrity": {"appRecognitionVerdict": "UNRECOGNIZED_VERSION"}}}`
+	response, err := verifier.VerifyWithOptionsResponse("token", recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "app recognition verdict 'UNRECOGNIZED_VERSION', while expecting 'PLAY_RECOGNIZED'")
+	c.Check(response.Score, Equals, float32(0))
+}
+
+func (s *PlayIntegritySuite) TestDecision(c *C) {
+	response, err := s.verifier(c).VerifyWithOptionsResponse("token", recaptcha.VerifyOption{})
+	outcome := recaptcha.Decide(recaptcha.VerifyResult{Response: response, Err: err}, recaptcha.Policy{ChallengeBelow: 1, BlockBelow: 0.5})
+	c.Check(outcome.Decision, Equals, recaptcha.Challenge)
+}
@@ -0,0 +1,93 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxPooledBodySize response body buffers grown beyond this size aren't kept in the pool.
+const maxPooledBodySize = 64 << 10
+
+// PooledResult verification result borrowed from a pool by VerifyPooled. Release returns it to the
+// pool once the caller is done with it, neither the result nor its Response may be used afterwards.
+type PooledResult struct {
+	Response Response
+	Err      error
+
+	form url.Values
+	body bytes.Buffer
+	// failedOpen the request failure in Err was accepted by the fail open policy
+	failedOpen bool
+}
+
+var resultPool = sync.Pool{
+	New: func() interface{} { return &PooledResult{form: url.Values{}} },
+}
+
+// VerifyPooled same as VerifyWithOptionsResponse but reuses the result, the form values and the
+// response body buffer across calls, reducing allocations for services doing many verifications
+// per second. The returned result must be released.
+func (r *ReCAPTCHA) VerifyPooled(challengeResponse string, options VerifyOption) *PooledResult {
+	p := resultPool.Get().(*PooledResult)
+	ctx := context.Background()
+	// the tracer ends with the verification error, even when not enforced
+	var err error
+	if r.Tracer != nil {
+		var end func(Response, error)
+		ctx, end = r.Tracer.Start(ctx, r.Version, options)
+		defer func() { end(p.Response, err) }()
+	}
+	start := time.Now()
+	r.verifyPooled(ctx, p, challengeResponse, options)
+	err = p.Err
+	if len(r.Observers) > 0 {
+		r.observe(options, p.Response, err, p.failedOpen, time.Since(start))
+	}
+	if p.failedOpen {
+		err, p.Err = nil, nil
+	}
+	enforced := err == nil || r.enforced(challengeResponse, options)
+	r.logResult(ctx, options, p.Response, err, enforced)
+	if !enforced {
+		p.Err = nil
+	}
+	return p
+}
+
+func (r *ReCAPTCHA) verifyPooled(ctx context.Context, p *PooledResult, challengeResponse string, options VerifyOption) {
+	if r.Version == V3 && options.Action != "" {
+		if err := ValidateAction(options.Action); err != nil {
+			p.Err = &Error{msg: err.Error(), kind: kindInvalidAction}
+			return
+		}
+	}
+	recaptcha := reCHAPTCHARequest{Secret: r.Secret, Response: challengeResponse, RemoteIP: options.RemoteIP}
+	if err := r.checkReplay(challengeResponse); err != nil {
+		p.Err = err
+		return
+	}
+	resultBody, err := r.fetchInto(ctx, recaptcha, options, p.form, &p.body, &p.Response)
+	if err != nil {
+		p.Err = err
+		p.failedOpen = r.failOpen(ctx, options, err)
+		return
+	}
+	p.Err = r.check(recaptcha, options, p.Response, resultBody)
+}
+
+// Release resets the result and returns it to the pool.
+func (p *PooledResult) Release() {
+	// errors keep referencing the error codes, the slice isn't reused
+	p.Response = Response{}
+	p.Err = nil
+	p.failedOpen = false
+	if p.body.Cap() > maxPooledBodySize {
+		p.body = bytes.Buffer{}
+	} else {
+		p.body.Reset()
+	}
+	resultPool.Put(p)
+}
@@ -0,0 +1,78 @@
+package recaptcha
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+type PooledResultSuite struct{}
+
+var _ = Suite(&PooledResultSuite{})
+
+func (s *PooledResultSuite) TestVerifyPooled(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	result := captcha.VerifyPooled("mycode", VerifyOption{Action: "login"})
+	c.Check(result.Err, IsNil)
+	c.Check(result.Response.Action, Equals, "login")
+	c.Check(result.Response.Score, Equals, float32(0.6))
+	result.Release()
+
+	result = captcha.VerifyPooled("mycode", VerifyOption{Action: "login", Threshold: 0.7})
+	c.Check(result.Err, ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+	c.Check(result.Err.(*Error).kind, Equals, kindLowScore)
+	result.Release()
+
+	result = captcha.VerifyPooled("mycode", VerifyOption{Action: "signup"})
+	c.Check(result.Err, ErrorMatches, "invalid response action 'login', while expecting 'signup'")
+	result.Release()
+}
+
+func (s *PooledResultSuite) TestVerifyPooledErrors(c *C) {
+	captcha := ReCAPTCHA{client: &mockInvalidClient{}}
+	result := captcha.VerifyPooled("mycode", VerifyOption{})
+	c.Check(result.Err, ErrorMatches, "invalid response body json: .*")
+	c.Check(result.Err.(*Error).RequestError, Equals, true)
+	result.Release()
+
+	captcha = ReCAPTCHA{client: &mockFailedClientNoOptions{}}
+	result = captcha.VerifyPooled("mycode", VerifyOption{})
+	c.Check(result.Err, ErrorMatches, "remote error codes: .*")
+	codes := result.Err.(*Error).ErrorCodes
+	result.Release()
+
+	// a released result is reset and doesn't alter the errors it returned
+	captcha = ReCAPTCHA{client: &mockSuccessClientNoOptions{}}
+	result = captcha.VerifyPooled("mycode", VerifyOption{})
+	c.Check(result.Err, IsNil)
+	c.Check(result.Response.ErrorCodes, IsNil)
+	c.Check(codes, Not(HasLen), 0)
+	result.Release()
+}
+
+func BenchmarkVerifyWithOptionsResponse(b *testing.B) {
+	captcha := ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3}
+	options := VerifyOption{Action: "login", RemoteIP: "127.0.0.1"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := captcha.VerifyWithOptionsResponse("mycode", options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyPooled(b *testing.B) {
+	captcha := ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3}
+	options := VerifyOption{Action: "login", RemoteIP: "127.0.0.1"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		result := captcha.VerifyPooled("mycode", options)
+		if result.Err != nil {
+			b.Fatal(result.Err)
+		}
+		result.Release()
+	}
+}
@@ -0,0 +1,252 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// FailurePolicy decides the outcome of a verification when the recaptcha endpoint couldn't be reached
+// or answered with an unreadable response: timeouts, 5xx statuses, open circuit...
+type FailurePolicy int8
+
+const (
+	// FailInherit the zero value, apply the policy of the enclosing level: the ReCAPTCHA FailurePolicy
+	// for action profiles, FailClosed for the ReCAPTCHA.
+	FailInherit FailurePolicy = iota
+	// FailClosed reject the challenge when the verification request fails, the default.
+	FailClosed
+	// FailOpen accept the challenge when the verification request fails.
+	FailOpen
+)
+
+// ActionProfile verification policy applied to every V3 response whose action matches the
+// registered action name, centralizing per action settings instead of repeating VerifyOption values.
+// Options explicitly set in VerifyOption take precedence over the profile.
+type ActionProfile struct {
+	// Threshold minimum score, DefaultThreshold is used when zero.
+	Threshold float32
+	// Hostnames allowed response hostnames, any hostname is allowed when empty.
+	Hostnames []string
+	// MaxAge maximum time elapsed since the challenge was solved when VerifyOption.MaxChallengeAge is
+	// zero, ReCAPTCHA.MaxChallengeAge and then unlimited when zero too.
+	MaxAge time.Duration
+	// FailurePolicy applied when verifying with VerifyOption.Action set to the profile action, the
+	// ReCAPTCHA FailurePolicy when FailInherit.
+	FailurePolicy FailurePolicy
+	// Policy maps the verification results of ReCAPTCHA.VerifyDecision to decisions, e.g. a wider
+	// challenge zone for payments than for comments.
+	Policy *Policy
+}
+
+// RegisterActionProfile registers the profile applied to V3 responses for the given action. Names
+// differing from a registered one only by case are rejected, they would be ambiguous with
+// NormalizeActions.
+func (r *ReCAPTCHA) RegisterActionProfile(action string, profile ActionProfile) error {
+	if err := validateProfileName(action); err != nil {
+		return err
+	}
+	if err := r.profileCollision(action); err != nil {
+		return err
+	}
+	if r.profiles == nil {
+		r.profiles = make(map[string]ActionProfile)
+	}
+	r.profiles[action] = profile
+	return nil
+}
+
+func validateProfileName(action string) error {
+	if action == "" {
+		return fmt.Errorf("action profile name cannot be blank")
+	}
+	return ValidateAction(action)
+}
+
+// profileCollision fails when action equals a registered profile name ignoring case only.
+func (r *ReCAPTCHA) profileCollision(action string) error {
+	for name := range r.profiles {
+		if name != action && strings.EqualFold(name, action) {
+			return fmt.Errorf("action profile '%s' collides with the registered profile '%s'", action, name)
+		}
+	}
+	return nil
+}
+
+// SetAllowedActions validates and sets AllowedActions, nothing is set when an action name is blank,
+// invalid or listed twice.
+func (r *ReCAPTCHA) SetAllowedActions(actions ...string) error {
+	for i, action := range actions {
+		if action == "" {
+			return fmt.Errorf("allowed action cannot be blank")
+		}
+		if err := ValidateAction(action); err != nil {
+			return err
+		}
+		for _, previous := range actions[:i] {
+			if strings.EqualFold(previous, action) {
+				return fmt.Errorf("allowed action '%s' listed twice", action)
+			}
+		}
+	}
+	r.AllowedActions = actions
+	return nil
+}
+
+// SetThresholds sets the minimum score of each action, e.g. map[string]float32{"login": 0.7,
+// "comment": 0.3}, keeping the other settings of their registered profiles. Nothing is applied when
+// an action name or a threshold is invalid.
+func (r *ReCAPTCHA) SetThresholds(thresholds map[string]float32) error {
+	for action, threshold := range thresholds {
+		if err := validateProfileName(action); err != nil {
+			return err
+		}
+		if err := r.profileCollision(action); err != nil {
+			return err
+		}
+		for other := range thresholds {
+			if other != action && strings.EqualFold(other, action) {
+				return fmt.Errorf("threshold actions '%s' and '%s' collide", action, other)
+			}
+		}
+		if threshold < 0 || threshold > 1 {
+			return fmt.Errorf("invalid threshold '%f' of action '%s', while expecting a score between 0 and 1", threshold, action)
+		}
+	}
+	for action, threshold := range thresholds {
+		profile := r.profiles[action]
+		profile.Threshold = threshold
+		r.RegisterActionProfile(action, profile)
+	}
+	return nil
+}
+
+// ValidateAction checks the action name only contains characters allowed by recaptcha: alphanumeric
+// characters, slashes and underscores.
+func ValidateAction(action string) error {
+	for _, c := range action {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '/' && c != '_' {
+			return fmt.Errorf("invalid action name '%s', only alphanumeric characters, slashes and underscores are allowed", action)
+		}
+	}
+	return nil
+}
+
+// sameAction compares action names, ignoring case when NormalizeActions is set.
+func (r *ReCAPTCHA) sameAction(expected, actual string) bool {
+	if r.NormalizeActions {
+		return strings.EqualFold(expected, actual)
+	}
+	return expected == actual
+}
+
+// hostnames returns the allowed response hostnames: the options ones, else those of the action
+// profile, else Hostnames.
+func (r *ReCAPTCHA) hostnames(options VerifyOption, profile ActionProfile) []string {
+	if len(options.Hostnames) > 0 {
+		return options.Hostnames
+	}
+	if len(profile.Hostnames) > 0 {
+		return profile.Hostnames
+	}
+	return r.Hostnames
+}
+
+// matchHostname reports whether hostname matches pattern, an exact hostname or a "*." wildcard
+// matching the subdomains on a label boundary.
+func matchHostname(pattern, hostname string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if !strings.HasPrefix(pattern, "*.") {
+		return pattern == hostname
+	}
+	suffix := pattern[1:]
+	return len(hostname) > len(suffix) && strings.HasSuffix(hostname, suffix)
+}
+
+func matchHostnames(patterns []string, hostname string) bool {
+	for _, pattern := range patterns {
+		if matchHostname(pattern, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleHostname returns a hostname matching pattern.
+func exampleHostname(pattern string) string {
+	if strings.HasPrefix(pattern, "*.") {
+		return "dev" + pattern[1:]
+	}
+	return pattern
+}
+
+// allowedAction reports whether the response action is one of AllowedActions.
+func (r *ReCAPTCHA) allowedAction(action string) bool {
+	if action == "" {
+		return false
+	}
+	for _, allowed := range r.AllowedActions {
+		if r.sameAction(allowed, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// profile returns the action profile registered for the action, if any.
+func (r *ReCAPTCHA) profile(action string) ActionProfile {
+	profile, _ := r.lookupProfile(action)
+	return profile
+}
+
+func (r *ReCAPTCHA) lookupProfile(action string) (ActionProfile, bool) {
+	if profile, ok := r.profiles[action]; ok || !r.NormalizeActions {
+		return profile, ok
+	}
+	for name, profile := range r.profiles {
+		if r.sameAction(name, action) {
+			return profile, true
+		}
+	}
+	return ActionProfile{}, false
+}
+
+// failOpen reports whether the failed verification request is accepted: OnRequestFailure decides
+// when set, then the FailurePolicy of the action profile, then the ReCAPTCHA one, the first policy
+// other than FailInherit applying.
+func (r *ReCAPTCHA) failOpen(ctx context.Context, options VerifyOption, err error) bool {
+	var policy FailurePolicy
+	if r.OnRequestFailure != nil {
+		policy = r.OnRequestFailure(ctx, options, err)
+	}
+	if policy == FailInherit {
+		if profile, ok := r.lookupProfile(options.Action); ok {
+			policy = profile.FailurePolicy
+		}
+	}
+	if policy == FailInherit {
+		policy = r.FailurePolicy
+	}
+	if policy != FailOpen {
+		return false
+	}
+	if r.logger != nil {
+		r.log(ctx, slog.LevelWarn, "recaptcha request failure accepted by the fail open policy", "action", options.Action, "error", err)
+	}
+	if len(r.Observers) > 0 {
+		notify(r.Observers, newEvent(EventFailOpen, options, Response{}, err, Outcome{Decision: Allow, Reasons: []string{err.Error()}}))
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,361 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ActionProfileSuite struct{}
+
+var _ = Suite(&ActionProfileSuite{})
+
+// mockBodyClient answers every verification request with its own content as response body.
+type mockBodyClient string
+
+func (m mockBodyClient) PostForm(url string, formValues url.Values) (resp *http.Response, err error) {
+	resp = &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(string(m)))
+	return
+}
+
+const loginResponseBody = `
+	{
+		"success": true,
+		"challenge_ts": "2018-03-06T03:41:29+00:00",
+		"hostname": "test.com",
+		"action": "login",
+		"score": 0.6
+	}
+	`
+
+func (s *ActionProfileSuite) TestRegisterActionProfile(c *C) {
+	captcha := ReCAPTCHA{}
+	c.Check(captcha.RegisterActionProfile("", ActionProfile{}), ErrorMatches, "action profile name cannot be blank")
+	c.Check(captcha.RegisterActionProfile("login", ActionProfile{Threshold: 0.7}), IsNil)
+	c.Check(captcha.profiles["login"].Threshold, Equals, float32(0.7))
+
+	// names only differing by case are ambiguous with NormalizeActions
+	c.Check(captcha.RegisterActionProfile("Login", ActionProfile{}), ErrorMatches, "action profile 'Login' collides with the registered profile 'login'")
+	c.Check(captcha.SetThresholds(map[string]float32{"LOGIN": 0.9}), ErrorMatches, "action profile 'LOGIN' collides .*")
+	c.Check(captcha.SetThresholds(map[string]float32{"signup": 0.9, "SignUp": 0.8}), ErrorMatches, "threshold actions '.*' and '.*' collide")
+	c.Check(captcha.profiles, HasLen, 1)
+	c.Check(captcha.RegisterActionProfile("login", ActionProfile{Threshold: 0.8}), IsNil)
+}
+
+func (s *ActionProfileSuite) TestActionProfileThreshold(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	c.Assert(captcha.Verify("mycode"), IsNil)
+
+	captcha.RegisterActionProfile("login", ActionProfile{Threshold: 0.7})
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Threshold: 0.5}), IsNil)
+
+	captcha.RegisterActionProfile("comment", ActionProfile{Threshold: 0.9})
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Threshold: 0.5}), IsNil)
+}
+
+func (s *ActionProfileSuite) TestSetThresholds(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	c.Assert(captcha.RegisterActionProfile("login", ActionProfile{Hostnames: []string{"test.com"}}), IsNil)
+	c.Assert(captcha.SetThresholds(map[string]float32{"login": 0.7, "comment": 0.3}), IsNil)
+	c.Check(captcha.profiles["login"].Hostnames, DeepEquals, []string{"test.com"})
+	c.Check(captcha.profiles["comment"].Threshold, Equals, float32(0.3))
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+
+	c.Check(captcha.SetThresholds(map[string]float32{"login": 1.5}), ErrorMatches, "invalid threshold '1.500000' of action 'login', while expecting a score between 0 and 1")
+	c.Check(captcha.SetThresholds(map[string]float32{"log in": 0.5}), ErrorMatches, "invalid action name 'log in'.*")
+	c.Check(captcha.profiles["login"].Threshold, Equals, float32(0.7))
+
+	// the valid actions aren't applied either, whatever the iteration order
+	for i := 0; i < 10; i++ {
+		c.Check(captcha.SetThresholds(map[string]float32{"login": 0.2, "signup": 0.4, "check out": 0.5, "comment": 0.9}), ErrorMatches, "invalid action name 'check out'.*")
+	}
+	c.Check(captcha.profiles["login"].Threshold, Equals, float32(0.7))
+	c.Check(captcha.profiles["comment"].Threshold, Equals, float32(0.3))
+	_, ok := captcha.profiles["signup"]
+	c.Check(ok, Equals, false)
+}
+
+func (s *ActionProfileSuite) TestAllowedActions(c *C) {
+	captcha := ReCAPTCHA{
+		client:         mockBodyClient(loginResponseBody),
+		Version:        V3,
+		AllowedActions: []string{"signup", "Login"},
+	}
+	err := captcha.Verify("mycode")
+	c.Check(err, ErrorMatches, `response action 'login' not allowed, while expecting one of \[signup Login\]`)
+	c.Check(errors.Is(err, ErrActionNotAllowed), Equals, true)
+	c.Check(ErrorReason(err), Equals, "action_not_allowed")
+
+	captcha.NormalizeActions = true
+	c.Check(captcha.Verify("mycode"), IsNil)
+
+	captcha.client = mockBodyClient(`{"success": true, "score": 0.9}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, `response action '' not allowed, .*`)
+}
+
+func (s *ActionProfileSuite) TestSetAllowedActions(c *C) {
+	captcha := ReCAPTCHA{}
+	c.Check(captcha.SetAllowedActions("login", ""), ErrorMatches, "allowed action cannot be blank")
+	c.Check(captcha.SetAllowedActions("login", "log-in"), ErrorMatches, "invalid action name 'log-in'.*")
+	c.Check(captcha.SetAllowedActions("login", "Login"), ErrorMatches, "allowed action 'Login' listed twice")
+	c.Check(captcha.AllowedActions, IsNil)
+	c.Check(captcha.SetAllowedActions("login", "signup"), IsNil)
+	c.Check(captcha.AllowedActions, DeepEquals, []string{"login", "signup"})
+}
+
+func (s *ActionProfileSuite) TestActionProfileHostnames(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	captcha.RegisterActionProfile("login", ActionProfile{Hostnames: []string{"www.test.com", "test.com"}})
+	c.Check(captcha.Verify("mycode"), IsNil)
+
+	captcha.RegisterActionProfile("login", ActionProfile{Hostnames: []string{"www.test.com"}})
+	c.Check(captcha.Verify("mycode"), ErrorMatches, `invalid response hostname 'test.com', while expecting one of \[www.test.com\]`)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "test.com"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostnames: []string{"example.com", "test.com"}}), IsNil)
+}
+
+func (s *ActionProfileSuite) TestHostnames(c *C) {
+	captcha := ReCAPTCHA{
+		client:    mockBodyClient(`{"success": true, "hostname": "eu.example.com"}`),
+		Version:   V2,
+		Hostnames: []string{"example.com", "www.example.com"},
+	}
+	c.Check(captcha.Verify("mycode"), ErrorMatches, `invalid response hostname 'eu.example.com', while expecting one of \[example.com www.example.com\]`)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostnames: []string{"example.com", "eu.example.com"}}), IsNil)
+	captcha.Hostnames = append(captcha.Hostnames, "eu.example.com")
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "example.com"}), ErrorMatches, "invalid response hostname 'eu.example.com', while expecting 'example.com'")
+}
+
+func (s *ActionProfileSuite) TestMatchHostname(c *C) {
+	c.Check(matchHostname("example.com", "Example.COM."), Equals, true)
+	c.Check(matchHostname("example.com", "www.example.com"), Equals, false)
+	c.Check(matchHostname("*.example.com", "acme.example.com"), Equals, true)
+	c.Check(matchHostname("*.example.com", "eu.acme.example.com"), Equals, true)
+	c.Check(matchHostname("*.example.com", "example.com"), Equals, false)
+	c.Check(matchHostname("*.example.com", "badexample.com"), Equals, false)
+	c.Check(matchHostname("*.example.com", "example.com.evil.com"), Equals, false)
+	c.Check(matchHostname("*.example.com", ".example.com"), Equals, false)
+
+	captcha := ReCAPTCHA{
+		client:    mockBodyClient(`{"success": true, "hostname": "acme.example.com"}`),
+		Version:   V2,
+		Hostnames: []string{"example.com", "*.example.com"},
+	}
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "*.example.org"}), ErrorMatches, `invalid response hostname 'acme.example.com', while expecting '\*.example.org'`)
+}
+
+func (s *ActionProfileSuite) TestActionProfileMaxAge(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+		horloge: &mockClockOverRespenseTime{},
+	}
+	captcha.RegisterActionProfile("login", ActionProfile{MaxAge: 10 * time.Second})
+	c.Check(captcha.Verify("mycode"), IsNil)
+
+	captcha.RegisterActionProfile("login", ActionProfile{MaxAge: 5 * time.Second})
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "challenge solved '8.000000s' ago, while expecting maximum '5.000000s'")
+}
+
+func (s *ActionProfileSuite) TestActionProfileFailurePolicy(c *C) {
+	captcha := ReCAPTCHA{
+		client:  &mockUnavailableClient{},
+		Version: V3,
+	}
+	captcha.RegisterActionProfile("login", ActionProfile{FailurePolicy: FailOpen})
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "comment"}), ErrorMatches, "error posting to recaptcha endpoint:.*")
+}
+
+func (s *ActionProfileSuite) TestFailurePolicy(c *C) {
+	var events []Event
+	captcha := ReCAPTCHA{
+		client:        &mockUnavailableClient{},
+		Version:       V3,
+		FailurePolicy: FailOpen,
+		Observers:     []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+	captcha.RegisterActionProfile("payment", ActionProfile{FailurePolicy: FailClosed})
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "comment"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "payment"}), ErrorMatches, "error posting to recaptcha endpoint:.*")
+	c.Assert(events, HasLen, 3)
+	c.Check(events[0].Kind, Equals, EventFailOpen)
+	c.Check(errors.Is(events[0].Err, ErrRequestFailed), Equals, true)
+	c.Check(events[0].Decision, Equals, Allow)
+	c.Check(events[1].Kind, Equals, EventVerification)
+	c.Check(errors.Is(events[1].Err, ErrRequestFailed), Equals, true)
+	c.Check(events[1].Decision, Equals, Allow)
+	c.Check(events[1].FailedOpen(), Equals, true)
+	c.Check(events[2].FailedOpen(), Equals, false)
+	c.Check(events[2].Decision, Equals, Block)
+
+	scores := 0
+	captcha.WithScoreObserver(func(action string, score float32, success bool) { scores++ })
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "comment"}), IsNil)
+	c.Check(scores, Equals, 0)
+
+	captcha.OnRequestFailure = func(ctx context.Context, options VerifyOption, err error) FailurePolicy {
+		if options.RemoteIP == "10.0.0.1" {
+			return FailOpen
+		}
+		return FailClosed
+	}
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "payment", RemoteIP: "10.0.0.1"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "comment"}), NotNil)
+	events = nil
+	result := captcha.VerifyPooled("mycode", VerifyOption{RemoteIP: "10.0.0.1"})
+	c.Check(result.Err, IsNil)
+	result.Release()
+	c.Assert(events, HasLen, 2)
+	c.Check(events[1].Decision, Equals, Allow)
+	c.Check(events[1].FailedOpen(), Equals, true)
+
+	captcha.OnRequestFailure = func(ctx context.Context, options VerifyOption, err error) FailurePolicy { return FailInherit }
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "comment"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "payment"}), NotNil)
+}
+
+func (s *ActionProfileSuite) TestFailurePolicyInherited(c *C) {
+	captcha := ReCAPTCHA{
+		client:        &mockUnavailableClient{},
+		Version:       V3,
+		FailurePolicy: FailOpen,
+	}
+	// the profiles created for the thresholds keep the verifier policy
+	c.Assert(captcha.SetThresholds(map[string]float32{"login": 0.7}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"}), IsNil)
+	c.Assert(captcha.RegisterActionProfile("signup", ActionProfile{Threshold: 0.6}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "signup"}), IsNil)
+
+	captcha.FailurePolicy = FailInherit
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"}), NotNil)
+}
+
+func (s *ActionProfileSuite) TestValidateAction(c *C) {
+	c.Check(ValidateAction("login"), IsNil)
+	c.Check(ValidateAction("checkout/step_2"), IsNil)
+	c.Check(ValidateAction("log-in"), ErrorMatches, "invalid action name 'log-in', only alphanumeric characters, slashes and underscores are allowed")
+
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	c.Check(captcha.RegisterActionProfile("log in", ActionProfile{}), NotNil)
+	err := captcha.VerifyWithOptions("mycode", VerifyOption{Action: "log-in"})
+	c.Assert(err, NotNil)
+	c.Check(err.(*Error).RequestError, Equals, false)
+	c.Check(err, ErrorMatches, "invalid action name 'log-in'.*")
+}
+
+func (s *ActionProfileSuite) TestNormalizeActions(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(loginResponseBody),
+		Version: V3,
+	}
+	captcha.RegisterActionProfile("Login", ActionProfile{Threshold: 0.7})
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "LOGIN"}), ErrorMatches, "invalid response action 'login', while expecting 'LOGIN'")
+
+	captcha.NormalizeActions = true
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "LOGIN"}), ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+}
+
+func (s *ActionProfileSuite) TestVersionMismatch(c *C) {
+	var events []Event
+	mismatches := ObserverFunc(func(event Event) {
+		if event.Kind == EventVersionMismatch {
+			events = append(events, event)
+		}
+	})
+	captcha := ReCAPTCHA{
+		client:    &mockSuccessClientNoOptions{},
+		Version:   V3,
+		Observers: []Observer{mismatches},
+	}
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received score '0.000000', while expecting minimum '0.500000'")
+	c.Check(captcha.Verify("mycode"), NotNil)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, EventVersionMismatch)
+	c.Check(events[0].Err, ErrorMatches, "received response without score nor action, while expecting a V3 response")
+
+	captcha.StrictVersion = true
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response without score nor action, while expecting a V3 response")
+	c.Check(events, HasLen, 1)
+
+	events = nil
+	captcha = ReCAPTCHA{
+		client:    mockBodyClient(loginResponseBody),
+		Version:   V2,
+		Observers: []Observer{mismatches},
+	}
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Action, Equals, "login")
+
+	captcha.StrictVersion = true
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response with score '0.600000' and action 'login', while expecting a V2 response")
+
+	captcha = ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3, StrictVersion: true}
+	c.Check(captcha.Verify("mycode"), IsNil)
+}
+
+func (s *ActionProfileSuite) TestStrictResponse(c *C) {
+	captcha := ReCAPTCHA{client: mockBodyClient(loginResponseBody), Version: V3, StrictResponse: true}
+	c.Check(captcha.Verify("mycode"), IsNil)
+
+	captcha.client = mockBodyClient(`{"success": true, "hostname": "test.com", "action": "login"}`)
+	err := captcha.Verify("mycode")
+	c.Check(err, ErrorMatches, "received response without score, while expecting a V3 response")
+	c.Check(errors.Is(err, ErrUnexpectedResponse), Equals, true)
+	c.Check(ErrorReason(err), Equals, "unexpected_response")
+
+	captcha.client = mockBodyClient(`{"success": true, "hostname": "test.com", "score": 0.9}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response without action, while expecting a V3 response")
+
+	// the version mismatches are rejected as with StrictVersion
+	captcha.client = mockBodyClient(`{"success": true, "hostname": "test.com"}`)
+	c.Check(errors.Is(captcha.Verify("mycode"), ErrVersionMismatch), Equals, true)
+
+	captcha.client = mockBodyClient(`{"success": true, "action": "login", "score": 0.9}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response without hostname, while expecting a web response")
+
+	captcha.client = mockBodyClient(`{"success": true, "hostname": "test.com", "apk_package_name": "com.example.app", "action": "login", "score": 0.9}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response with hostname 'test.com' and apk_package_name 'com.example.app', while expecting only one of them")
+
+	captcha.client = mockBodyClient(`{"success": true, "apk_package_name": "com.example.app", "action": "login", "score": 0.9}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response with apk_package_name 'com.example.app', while expecting a web response")
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{ApkPackageName: "com.example.app"}), IsNil)
+
+	captcha.client = mockBodyClient(loginResponseBody)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{ApkPackageName: "com.example.app"}), ErrorMatches, "received response with hostname 'test.com', while expecting an Android response")
+
+	captcha = ReCAPTCHA{client: mockBodyClient(`{"success": true, "hostname": "test.com", "score": 0}`), Version: V2, StrictResponse: true}
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "received response with score '0.000000', while expecting a V2 response")
+	captcha.client = mockBodyClient(`{"success": true, "hostname": "test.com"}`)
+	c.Check(captcha.Verify("mycode"), IsNil)
+
+	// failed responses are left to the other checks
+	captcha.client = mockBodyClient(`{"success": false}`)
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "invalid challenge solution")
+}
@@ -0,0 +1,204 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// TurnstileLink Cloudflare Turnstile verification endpoint
+	TurnstileLink = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	// HCaptchaLink hCaptcha verification endpoint
+	HCaptchaLink = "https://api.hcaptcha.com/siteverify"
+)
+
+// Provider captcha backend verifying the challenge responses: it builds the verification request,
+// decodes the response and checks its provider specific fields. The common checks (success, error
+// codes, hostname, challenge age...) are made by ReCAPTCHA whatever the provider, the V3 action and
+// score checks only for ReCAPTCHAProvider. Third parties implement it to add new backends, see
+// RegisterProvider.
+type Provider interface {
+	// Link default verification endpoint of the provider.
+	Link() string
+	// BuildRequest fills the form posted to the verification endpoint. The form is reused across
+	// requests, its values being emptied before.
+	BuildRequest(form url.Values, secret, challengeResponse string, options VerifyOption)
+	// ParseResponse decodes the response body into response.
+	ParseResponse(body []byte, response *Response) error
+	// Validate checks the provider specific fields of a decoded response.
+	Validate(response Response, options VerifyOption) error
+}
+
+var (
+	// ReCAPTCHAProvider Google reCAPTCHA, the default provider. The V3 action and score checks
+	// depend on ReCAPTCHA.Version.
+	ReCAPTCHAProvider Provider = recaptchaProvider{}
+	// TurnstileProvider Cloudflare Turnstile, sending VerifyOption.IdempotencyKey and checking the
+	// action and VerifyOption.CData.
+	TurnstileProvider Provider = turnstileProvider{}
+)
+
+type recaptchaProvider struct{}
+
+func (recaptchaProvider) Link() string { return reCAPTCHALink }
+
+func (recaptchaProvider) BuildRequest(form url.Values, secret, challengeResponse string, options VerifyOption) {
+	setFormValue(form, "secret", secret)
+	setFormValue(form, "response", challengeResponse)
+	if options.RemoteIP != "" {
+		setFormValue(form, "remoteip", options.RemoteIP)
+	}
+}
+
+func (recaptchaProvider) ParseResponse(body []byte, response *Response) error {
+	return json.Unmarshal(body, response)
+}
+
+func (recaptchaProvider) Validate(response Response, options VerifyOption) error {
+	return nil
+}
+
+type turnstileProvider struct{ recaptchaProvider }
+
+func (turnstileProvider) Link() string { return TurnstileLink }
+
+func (p turnstileProvider) BuildRequest(form url.Values, secret, challengeResponse string, options VerifyOption) {
+	p.recaptchaProvider.BuildRequest(form, secret, challengeResponse, options)
+	if options.IdempotencyKey != "" {
+		setFormValue(form, "idempotency_key", options.IdempotencyKey)
+	}
+}
+
+func (turnstileProvider) Validate(response Response, options VerifyOption) error {
+	if options.Action != "" && options.Action != response.Action {
+		return &Error{
+			msg:  fmt.Sprintf("invalid response action '%s', while expecting '%s'", response.Action, options.Action),
+			kind: kindActionMismatch,
+		}
+	}
+	if options.CData != "" && options.CData != response.CData {
+		return &Error{
+			msg:  fmt.Sprintf("invalid response cdata '%s', while expecting '%s'", response.CData, options.CData),
+			kind: kindCDataMismatch,
+		}
+	}
+	return nil
+}
+
+// HCaptcha hCaptcha provider, the form contract being the reCAPTCHA one plus the site key.
+type HCaptcha struct {
+	recaptchaProvider
+	// SiteKey when set is sent so hCaptcha checks the token was issued for it.
+	SiteKey string
+}
+
+// Link returns HCaptchaLink.
+func (HCaptcha) Link() string { return HCaptchaLink }
+
+// BuildRequest fills the reCAPTCHA form fields and the site key.
+func (h HCaptcha) BuildRequest(form url.Values, secret, challengeResponse string, options VerifyOption) {
+	h.recaptchaProvider.BuildRequest(form, secret, challengeResponse, options)
+	if h.SiteKey != "" {
+		setFormValue(form, "sitekey", h.SiteKey)
+	}
+}
+
+// NewReCAPTCHAWithProvider same as NewReCAPTCHA but verifies the challenge responses with provider,
+// against its Link
+func NewReCAPTCHAWithProvider(secret string, version VERSION, timeout time.Duration, provider Provider) (ReCAPTCHA, error) {
+	if provider == nil {
+		return ReCAPTCHA{}, fmt.Errorf("captcha provider cannot be nil")
+	}
+	captcha, err := NewReCAPTCHA(secret, version, timeout)
+	if err != nil {
+		return captcha, err
+	}
+	captcha.Provider = provider
+	captcha.ReCAPTCHALink = provider.Link()
+	return captcha, nil
+}
+
+// NewTurnstile new ReCAPTCHA verifying Cloudflare Turnstile tokens, get your secret from the
+// Cloudflare dashboard
+func NewTurnstile(secret string, timeout time.Duration) (ReCAPTCHA, error) {
+	if secret == "" {
+		return ReCAPTCHA{}, fmt.Errorf("turnstile secret cannot be blank")
+	}
+	return NewReCAPTCHAWithProvider(secret, V2, timeout, TurnstileProvider)
+}
+
+// NewHCaptcha new ReCAPTCHA verifying hCaptcha tokens issued for siteKey, not checked when blank,
+// get your secret from https://dashboard.hcaptcha.com
+func NewHCaptcha(secret, siteKey string, timeout time.Duration) (ReCAPTCHA, error) {
+	if secret == "" {
+		return ReCAPTCHA{}, fmt.Errorf("hcaptcha secret cannot be blank")
+	}
+	return NewReCAPTCHAWithProvider(secret, V2, timeout, HCaptcha{SiteKey: siteKey})
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"recaptcha": ReCAPTCHAProvider,
+		"turnstile": TurnstileProvider,
+		"hcaptcha":  HCaptcha{},
+	}
+)
+
+// RegisterProvider registers a third party provider under name so it can be selected by name, e.g.
+// from configuration files. The built-in providers are registered as recaptcha, turnstile and hcaptcha.
+func RegisterProvider(name string, provider Provider) error {
+	if name == "" {
+		return fmt.Errorf("captcha provider name cannot be blank")
+	}
+	if provider == nil {
+		return fmt.Errorf("captcha provider cannot be nil")
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if _, ok := providers[name]; ok {
+		return fmt.Errorf("captcha provider '%s' already registered", name)
+	}
+	providers[name] = provider
+	return nil
+}
+
+// LookupProvider returns the provider registered under name.
+func LookupProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+// ProviderNames returns the sorted names of the registered providers.
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewReCAPTCHAWithProviderName same as NewReCAPTCHAWithProvider with the provider registered under name
+func NewReCAPTCHAWithProviderName(secret string, version VERSION, timeout time.Duration, name string) (ReCAPTCHA, error) {
+	provider, ok := LookupProvider(name)
+	if !ok {
+		return ReCAPTCHA{}, fmt.Errorf("unknown captcha provider '%s', while expecting one of %v", name, ProviderNames())
+	}
+	return NewReCAPTCHAWithProvider(secret, version, timeout, provider)
+}
+
+func (r *ReCAPTCHA) provider() Provider {
+	if r.Provider == nil {
+		return ReCAPTCHAProvider
+	}
+	return r.Provider
+}
@@ -0,0 +1,185 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ProviderSuite struct{}
+
+var _ = Suite(&ProviderSuite{})
+
+// providerServer verification endpoint recording the posted forms and replying with body.
+func providerServer(body string, forms *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*forms = append(*forms, r.PostForm)
+		w.Write([]byte(body))
+	}))
+}
+
+func (s *ProviderSuite) TestTurnstile(c *C) {
+	var forms []url.Values
+	server := providerServer(`{
+		"success": true,
+		"challenge_ts": "2018-03-06T03:41:29.000Z",
+		"hostname": "test.com",
+		"error-codes": [],
+		"action": "login",
+		"cdata": "session-1",
+		"metadata": {"interactive": false}
+	}`, &forms)
+	defer server.Close()
+
+	_, err := NewTurnstile("", time.Second)
+	c.Check(err, ErrorMatches, "turnstile secret cannot be blank")
+	captcha, err := NewTurnstile("my secret", time.Second)
+	c.Assert(err, IsNil)
+	c.Check(captcha.ReCAPTCHALink, Equals, TurnstileLink)
+	captcha.ReCAPTCHALink = server.URL
+
+	response, err := captcha.VerifyWithOptionsResponse("mycode", VerifyOption{
+		Action: "login", CData: "session-1", Hostname: "test.com", RemoteIP: "127.0.0.1", IdempotencyKey: "key-1",
+	})
+	c.Assert(err, IsNil)
+	c.Check(response.CData, Equals, "session-1")
+	c.Check(forms[0], DeepEquals, url.Values{
+		"secret": {"my secret"}, "response": {"mycode"}, "remoteip": {"127.0.0.1"}, "idempotency_key": {"key-1"},
+	})
+
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Check(forms[1], DeepEquals, url.Values{"secret": {"my secret"}, "response": {"mycode"}})
+
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{Action: "signup"})
+	c.Check(err, ErrorMatches, "invalid response action 'login', while expecting 'signup'")
+	c.Check(errors.Is(err, ErrActionMismatch), Equals, true)
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{CData: "session-2"})
+	c.Check(err, ErrorMatches, "invalid response cdata 'session-1', while expecting 'session-2'")
+	c.Check(errors.Is(err, ErrCDataMismatch), Equals, true)
+	c.Check(err.(*Error).ResponseBody, Matches, "(?s).*session-1.*")
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "other.com"}), ErrorMatches, "invalid response hostname 'test.com', while expecting 'other.com'")
+
+	result := captcha.VerifyPooled("mycode", VerifyOption{CData: "session-1"})
+	c.Check(result.Err, IsNil)
+	result.Release()
+}
+
+func (s *ProviderSuite) TestTurnstileFailure(c *C) {
+	var forms []url.Values
+	server := providerServer(`{"success": false, "error-codes": ["invalid-input-response"]}`, &forms)
+	defer server.Close()
+
+	captcha, err := NewTurnstile("my secret", time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"})
+	c.Check(err, ErrorMatches, "remote error codes: \\[invalid-input-response\\]")
+	c.Check(err.(*Error).ErrorCodes, DeepEquals, []string{"invalid-input-response"})
+}
+
+func (s *ProviderSuite) TestHCaptcha(c *C) {
+	var forms []url.Values
+	server := providerServer(`{
+		"success": true,
+		"challenge_ts": "2018-03-06T03:41:29.000Z",
+		"hostname": "test.com",
+		"credit": false
+	}`, &forms)
+	defer server.Close()
+
+	_, err := NewHCaptcha("", "site-key", time.Second)
+	c.Check(err, ErrorMatches, "hcaptcha secret cannot be blank")
+	captcha, err := NewHCaptcha("my secret", "site-key", time.Second)
+	c.Assert(err, IsNil)
+	c.Check(captcha.ReCAPTCHALink, Equals, HCaptchaLink)
+	captcha.ReCAPTCHALink = server.URL
+
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "test.com", RemoteIP: "127.0.0.1"}), IsNil)
+	c.Check(forms[0], DeepEquals, url.Values{
+		"secret": {"my secret"}, "response": {"mycode"}, "remoteip": {"127.0.0.1"}, "sitekey": {"site-key"},
+	})
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Hostname: "other.com"}), ErrorMatches, "invalid response hostname 'test.com', while expecting 'other.com'")
+
+	captcha, err = NewReCAPTCHAWithProvider("my secret", V2, time.Second, HCaptcha{})
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Check(forms[2], DeepEquals, url.Values{"secret": {"my secret"}, "response": {"mycode"}})
+
+	_, err = NewReCAPTCHAWithProvider("my secret", V2, time.Second, nil)
+	c.Check(err, ErrorMatches, "captcha provider cannot be nil")
+}
+
+// mockProvider third party provider posting the token as "token" and replying with a "valid" flag.
+type mockProvider struct{}
+
+func (mockProvider) Link() string { return "https://captcha.example.com/verify" }
+
+func (mockProvider) BuildRequest(form url.Values, secret, challengeResponse string, options VerifyOption) {
+	form.Set("key", secret)
+	form.Set("token", challengeResponse)
+}
+
+func (mockProvider) ParseResponse(body []byte, response *Response) error {
+	var reply struct {
+		Valid bool    `json:"valid"`
+		Risk  float32 `json:"risk"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return err
+	}
+	response.Success, response.Score = reply.Valid, 1-reply.Risk
+	return nil
+}
+
+func (mockProvider) Validate(response Response, options VerifyOption) error {
+	if options.Threshold > response.Score {
+		return errors.New("too risky")
+	}
+	return nil
+}
+
+func (s *ProviderSuite) TestRegisterProvider(c *C) {
+	c.Check(RegisterProvider("", mockProvider{}), ErrorMatches, "captcha provider name cannot be blank")
+	c.Check(RegisterProvider("mock", nil), ErrorMatches, "captcha provider cannot be nil")
+	c.Check(RegisterProvider("turnstile", mockProvider{}), ErrorMatches, "captcha provider 'turnstile' already registered")
+	c.Assert(RegisterProvider("mock", mockProvider{}), IsNil)
+	defer func() {
+		providersMu.Lock()
+		delete(providers, "mock")
+		providersMu.Unlock()
+	}()
+	c.Check(ProviderNames(), DeepEquals, []string{"hcaptcha", "mock", "recaptcha", "turnstile"})
+
+	provider, ok := LookupProvider("hcaptcha")
+	c.Check(ok, Equals, true)
+	c.Check(provider.Link(), Equals, HCaptchaLink)
+	_, ok = LookupProvider("other")
+	c.Check(ok, Equals, false)
+
+	_, err := NewReCAPTCHAWithProviderName("my secret", V3, time.Second, "other")
+	c.Check(err, ErrorMatches, "unknown captcha provider 'other', while expecting one of \\[hcaptcha mock recaptcha turnstile\\]")
+
+	var forms []url.Values
+	server := providerServer(`{"valid": true, "risk": 0.3}`, &forms)
+	defer server.Close()
+	captcha, err := NewReCAPTCHAWithProviderName("my secret", V3, time.Second, "mock")
+	c.Assert(err, IsNil)
+	c.Check(captcha.ReCAPTCHALink, Equals, "https://captcha.example.com/verify")
+	captcha.ReCAPTCHALink = server.URL
+
+	// the V3 threshold applies to ReCAPTCHAProvider only
+	response, err := captcha.VerifyWithOptionsResponse("mycode", VerifyOption{Threshold: 0.5})
+	c.Check(err, IsNil)
+	c.Check(response.Score, Equals, float32(0.7))
+	c.Check(forms[0], DeepEquals, url.Values{"key": {"my secret"}, "token": {"mycode"}})
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{Threshold: 0.8})
+	c.Check(err, ErrorMatches, "too risky")
+	c.Check(err.(*Error).ResponseBody, Equals, `{"valid": true, "risk": 0.3}`)
+}
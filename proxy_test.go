@@ -0,0 +1,40 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ProxySuite struct{}
+
+var _ = Suite(&ProxySuite{})
+
+func (s *ProxySuite) TestSetProxy(c *C) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String()+" "+r.Header.Get("Proxy-Authorization"))
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer proxy.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = "http://siteverify.invalid/recaptcha/api/siteverify"
+	c.Check(captcha.SetProxy("proxy.internal:3128"), ErrorMatches, "invalid proxy URL 'proxy.internal:3128', while expecting an absolute http\\(s\\) or socks5 URL")
+	c.Check(captcha.SetProxy("ftp://proxy.internal"), NotNil)
+
+	shared := &http.Client{Timeout: time.Second}
+	c.Assert(captcha.SetHTTPClient(shared), IsNil)
+	c.Assert(captcha.SetProxy("http://user:password@"+proxy.Listener.Addr().String()), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(proxied, DeepEquals, []string{"http://siteverify.invalid/recaptcha/api/siteverify Basic dXNlcjpwYXNzd29yZA=="})
+	c.Check(shared.Transport, IsNil)
+
+	c.Assert(captcha.Decorate(WithUserAgent("my-app/1.0")), IsNil)
+	c.Check(captcha.SetProxy(""), ErrorMatches, "cannot configure a decorated transport, configure it before decorating")
+	captcha.client = &mockInvalidClient{}
+	c.Check(captcha.SetProxy(""), ErrorMatches, "cannot configure the transport of a custom client")
+}
@@ -0,0 +1,65 @@
+// Package kafkaqueue adapts Kafka readers and writers to the queue package. Offsets are committed
+// once the outcome of their job is published, use a single job at a time (queue.Consumer
+// Concurrency 1) for at-least-once processing as concurrent jobs may be committed out of order.
+package kafkaqueue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+
+	"gopkg.in/ezzarghili/recaptcha-go.v4/queue"
+)
+
+// Source queue.Source fetching the jobs of a consumer group reader.
+type Source struct {
+	Reader *kafka.Reader
+}
+
+var _ queue.Source = (*Source)(nil)
+
+// NewSource new Source fetching jobs with reader, which must have a GroupID to commit offsets
+func NewSource(reader *kafka.Reader) (*Source, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("kafka reader cannot be nil")
+	}
+	if reader.Config().GroupID == "" {
+		return nil, fmt.Errorf("kafka reader group ID cannot be blank")
+	}
+	return &Source{Reader: reader}, nil
+}
+
+// Receive fetches the next message, its offset is committed by the delivery Ack.
+func (s *Source) Receive(ctx context.Context) (queue.Delivery, error) {
+	msg, err := s.Reader.FetchMessage(ctx)
+	if err != nil {
+		return queue.Delivery{}, err
+	}
+	return queue.Delivery{
+		Key:  msg.Key,
+		Data: msg.Value,
+		Ack:  func() error { return s.Reader.CommitMessages(context.Background(), msg) },
+	}, nil
+}
+
+// Publisher queue.Publisher writing the outcomes, keyed as their job, with a writer configured with
+// the outcome topic.
+type Publisher struct {
+	Writer *kafka.Writer
+}
+
+var _ queue.Publisher = (*Publisher)(nil)
+
+// NewPublisher new Publisher writing outcomes with writer
+func NewPublisher(writer *kafka.Writer) (*Publisher, error) {
+	if writer == nil {
+		return nil, fmt.Errorf("kafka writer cannot be nil")
+	}
+	return &Publisher{Writer: writer}, nil
+}
+
+// Publish writes the outcome message.
+func (p *Publisher) Publish(ctx context.Context, key, data []byte) error {
+	return p.Writer.WriteMessages(ctx, kafka.Message{Key: key, Value: data})
+}
@@ -0,0 +1,65 @@
+// Package natsqueue adapts NATS subscriptions and subjects to the queue package, core NATS
+// subscriptions deliver jobs at most once while JetStream ones are acknowledged once processed.
+package natsqueue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+
+	"gopkg.in/ezzarghili/recaptcha-go.v4/queue"
+)
+
+// Source queue.Source receiving the jobs of a synchronous subscription, e.g. from
+// Conn.QueueSubscribeSync or JetStreamContext.QueueSubscribeSync.
+type Source struct {
+	Subscription *nats.Subscription
+	// JetStream acknowledge the messages once their outcome is published, the subscription must be a
+	// JetStream one.
+	JetStream bool
+}
+
+var _ queue.Source = (*Source)(nil)
+
+// NewSource new Source receiving jobs from subscription
+func NewSource(subscription *nats.Subscription, jetStream bool) (*Source, error) {
+	if subscription == nil {
+		return nil, fmt.Errorf("nats subscription cannot be nil")
+	}
+	return &Source{Subscription: subscription, JetStream: jetStream}, nil
+}
+
+// Receive returns the next message of the subscription.
+func (s *Source) Receive(ctx context.Context) (queue.Delivery, error) {
+	msg, err := s.Subscription.NextMsgWithContext(ctx)
+	if err != nil {
+		return queue.Delivery{}, err
+	}
+	delivery := queue.Delivery{Data: msg.Data}
+	if s.JetStream {
+		delivery.Ack = func() error { return msg.Ack() }
+	}
+	return delivery, nil
+}
+
+// Publisher queue.Publisher publishing the outcomes to a subject.
+type Publisher struct {
+	Conn    *nats.Conn
+	Subject string
+}
+
+var _ queue.Publisher = (*Publisher)(nil)
+
+// NewPublisher new Publisher publishing outcomes to subject
+func NewPublisher(conn *nats.Conn, subject string) (*Publisher, error) {
+	if conn == nil || subject == "" {
+		return nil, fmt.Errorf("nats connection and subject cannot be blank")
+	}
+	return &Publisher{Conn: conn, Subject: subject}, nil
+}
+
+// Publish publishes the outcome to the subject, NATS messages have no key.
+func (p *Publisher) Publish(ctx context.Context, key, data []byte) error {
+	return p.Conn.Publish(p.Subject, data)
+}
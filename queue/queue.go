@@ -0,0 +1,206 @@
+// Package queue consumes verification jobs from a message queue, verifies them and publishes their
+// outcomes, for pipelines decoupling form ingestion from bot scoring. Transports are adapted by the
+// natsqueue and kafkaqueue packages.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// JobMessage verification job, serialized as JSON.
+type JobMessage struct {
+	ID                string            `json:"id"`
+	ChallengeResponse string            `json:"challenge_response"`
+	Action            string            `json:"action,omitempty"`
+	Threshold         float32           `json:"threshold,omitempty"`
+	Hostname          string            `json:"hostname,omitempty"`
+	ApkPackageName    string            `json:"apk_package_name,omitempty"`
+	RemoteIP          string            `json:"remote_ip,omitempty"`
+	Context           map[string]string `json:"context,omitempty"`
+}
+
+// OutcomeMessage outcome of a verification job, serialized as JSON.
+type OutcomeMessage struct {
+	ID       string             `json:"id"`
+	Success  bool               `json:"success"`
+	Decision string             `json:"decision"`
+	Reasons  []string           `json:"reasons,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	Response recaptcha.Response `json:"response"`
+	Time     time.Time          `json:"time"`
+}
+
+// Delivery message received from the queue.
+type Delivery struct {
+	// Key partitioning key of the message, if any, reused for the outcome.
+	Key  []byte
+	Data []byte
+	// Ack acknowledges the message once its outcome is published, nil when the transport doesn't
+	// acknowledge messages.
+	Ack func() error
+}
+
+// Source receives the job messages.
+type Source interface {
+	// Receive blocks until a message is available or the context is done.
+	Receive(ctx context.Context) (Delivery, error)
+}
+
+// Publisher publishes the outcome messages.
+type Publisher interface {
+	Publish(ctx context.Context, key, data []byte) error
+}
+
+// Consumer reads verification jobs from a Source, verifies them and publishes their outcomes. Jobs
+// are acknowledged once their outcome is published, so transports redeliver the jobs of a crashed
+// consumer.
+type Consumer struct {
+	Verifier recaptcha.Verifier
+	Source   Source
+	// Publisher receives the outcomes, outcomes are only passed to the observers when nil.
+	Publisher Publisher
+	// Policy when set is used to decide the outcome of every job, failed verifications are blocked
+	// otherwise.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// Concurrency number of jobs verified in parallel, 1 when zero.
+	Concurrency int
+	// Observers receive the verification events.
+	Observers []recaptcha.Observer
+	// OnError is called with the messages that couldn't be decoded, published or acknowledged, the
+	// consumer keeps running. Errors are ignored when nil.
+	OnError func(delivery Delivery, err error)
+
+	now func() time.Time
+}
+
+// NewConsumer new Consumer verifying the jobs of source with verifier and publishing to publisher
+func NewConsumer(verifier recaptcha.Verifier, source Source, publisher Publisher) (*Consumer, error) {
+	if verifier == nil || source == nil {
+		return nil, fmt.Errorf("consumer verifier and source cannot be nil")
+	}
+	return &Consumer{Verifier: verifier, Source: source, Publisher: publisher, now: time.Now}, nil
+}
+
+// Run consumes jobs until the context is done or the source fails, waiting for the jobs in progress
+// before returning.
+func (c *Consumer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, c.concurrency())
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delivery, err := c.Source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("couldn't receive job: '%s'", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			if err := c.handle(ctx, delivery); err != nil && c.OnError != nil {
+				c.OnError(delivery, err)
+			}
+		}()
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, delivery Delivery) error {
+	var job JobMessage
+	if err := json.Unmarshal(delivery.Data, &job); err != nil {
+		// redelivering a malformed job can't succeed
+		return firstError(fmt.Errorf("invalid job json: '%s'", err), ack(delivery))
+	}
+	outcome := c.Process(ctx, job)
+	if c.Publisher != nil {
+		data, err := json.Marshal(outcome)
+		if err != nil {
+			return err
+		}
+		if err := c.Publisher.Publish(ctx, delivery.Key, data); err != nil {
+			return fmt.Errorf("couldn't publish outcome of job '%s': '%s'", job.ID, err)
+		}
+	}
+	return ack(delivery)
+}
+
+// Process verifies a single job and returns its outcome.
+func (c *Consumer) Process(ctx context.Context, job JobMessage) OutcomeMessage {
+	options := recaptcha.VerifyOption{
+		Action:         job.Action,
+		Threshold:      job.Threshold,
+		Hostname:       job.Hostname,
+		ApkPackageName: job.ApkPackageName,
+		RemoteIP:       job.RemoteIP,
+		Context:        job.Context,
+	}
+	response, err := recaptcha.VerifyContext(ctx, c.Verifier, job.ChallengeResponse, options)
+	outcome := recaptcha.DecideWith(ctx, c.Decider, c.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+
+	message := OutcomeMessage{ID: job.ID, Success: err == nil, Decision: outcome.Decision.String(), Reasons: outcome.Reasons, Response: response, Time: c.clock()}
+	if err != nil {
+		message.Error = err.Error()
+	}
+	event := recaptcha.Event{
+		Kind:     recaptcha.EventVerification,
+		Time:     message.Time,
+		RemoteIP: options.RemoteIP,
+		Action:   response.Action,
+		Hostname: response.Hostname,
+		Score:    response.Score,
+		Err:      err,
+		Decision: outcome.Decision,
+		Reasons:  outcome.Reasons,
+		Context:  options.Context,
+	}
+	for _, observer := range c.Observers {
+		observer.Observe(event)
+	}
+	return message
+}
+
+func (c *Consumer) concurrency() int {
+	if c.Concurrency <= 0 {
+		return 1
+	}
+	return c.Concurrency
+}
+
+func (c *Consumer) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
+
+func ack(delivery Delivery) error {
+	if delivery.Ack == nil {
+		return nil
+	}
+	if err := delivery.Ack(); err != nil {
+		return fmt.Errorf("couldn't acknowledge job: '%s'", err)
+	}
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
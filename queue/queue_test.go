@@ -0,0 +1,165 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type QueueSuite struct{}
+
+var _ = Suite(&QueueSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct{}
+
+func (mockVerifier) Verify(challengeResponse string) error {
+	return mockVerifier{}.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := mockVerifier{}.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6, Action: options.Action}, nil
+}
+
+// mockQueue in-memory Source and Publisher recording the acknowledged jobs and published outcomes.
+type mockQueue struct {
+	deliveries chan Delivery
+	mu         sync.Mutex
+	acked      int
+	published  []OutcomeMessage
+	keys       []string
+	publishErr error
+}
+
+func newMockQueue(jobs ...string) *mockQueue {
+	q := &mockQueue{deliveries: make(chan Delivery, len(jobs))}
+	for _, job := range jobs {
+		q.deliveries <- Delivery{Key: []byte("key"), Data: []byte(job), Ack: q.ack}
+	}
+	return q
+}
+
+func (q *mockQueue) ack() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.acked++
+	return nil
+}
+
+func (q *mockQueue) Receive(ctx context.Context) (Delivery, error) {
+	select {
+	case delivery := <-q.deliveries:
+		return delivery, nil
+	case <-ctx.Done():
+		return Delivery{}, ctx.Err()
+	}
+}
+
+func (q *mockQueue) Publish(ctx context.Context, key, data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.publishErr != nil {
+		return q.publishErr
+	}
+	var outcome OutcomeMessage
+	if err := json.Unmarshal(data, &outcome); err != nil {
+		return err
+	}
+	q.published = append(q.published, outcome)
+	q.keys = append(q.keys, string(key))
+	return nil
+}
+
+// run runs the consumer until the queue is drained.
+func run(c *C, consumer *Consumer, q *mockQueue) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+	for len(q.deliveries) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	c.Check(<-done, Equals, context.Canceled)
+}
+
+func (s *QueueSuite) TestNewConsumer(c *C) {
+	_, err := NewConsumer(nil, newMockQueue(), nil)
+	c.Check(err, ErrorMatches, "consumer verifier and source cannot be nil")
+}
+
+func (s *QueueSuite) TestRun(c *C) {
+	q := newMockQueue(`{"id": "1", "challenge_response": "valid", "action": "signup"}`, `{"id": "2", "challenge_response": "invalid"}`, `not json`)
+	consumer, err := NewConsumer(mockVerifier{}, q, q)
+	c.Assert(err, IsNil)
+	consumer.Concurrency = 2
+	var errs []error
+	var mu sync.Mutex
+	consumer.OnError = func(delivery Delivery, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+	var events []recaptcha.Event
+	consumer.Observers = []recaptcha.Observer{recaptcha.ObserverFunc(func(event recaptcha.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})}
+	run(c, consumer, q)
+
+	c.Check(q.acked, Equals, 3)
+	c.Assert(q.published, HasLen, 2)
+	c.Check(q.keys, DeepEquals, []string{"key", "key"})
+	outcomes := map[string]OutcomeMessage{}
+	for _, outcome := range q.published {
+		outcomes[outcome.ID] = outcome
+	}
+	c.Check(outcomes["1"].Success, Equals, true)
+	c.Check(outcomes["1"].Decision, Equals, "allow")
+	c.Check(outcomes["1"].Response.Action, Equals, "signup")
+	c.Check(outcomes["2"].Decision, Equals, "block")
+	c.Check(outcomes["2"].Error, Equals, "remote error codes: [invalid-input-response]")
+	c.Assert(errs, HasLen, 1)
+	c.Check(errs[0], ErrorMatches, "invalid job json.*")
+	c.Check(events, HasLen, 2)
+}
+
+func (s *QueueSuite) TestPublishFailure(c *C) {
+	q := newMockQueue(`{"id": "1", "challenge_response": "valid"}`)
+	q.publishErr = fmt.Errorf("broker unavailable")
+	consumer, _ := NewConsumer(mockVerifier{}, q, q)
+	var errs []error
+	consumer.OnError = func(delivery Delivery, err error) { errs = append(errs, err) }
+	run(c, consumer, q)
+
+	c.Check(q.acked, Equals, 0)
+	c.Check(errs, HasLen, 1)
+	c.Check(errs[0], ErrorMatches, "couldn't publish outcome of job '1': 'broker unavailable'")
+}
+
+func (s *QueueSuite) TestProcessPolicy(c *C) {
+	consumer, _ := NewConsumer(mockVerifier{}, newMockQueue(), nil)
+	consumer.Policy = &recaptcha.Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	consumer.now = func() time.Time { return time.Unix(1500000000, 0) }
+	outcome := consumer.Process(context.Background(), JobMessage{ID: "1", ChallengeResponse: "valid"})
+	c.Check(outcome.Decision, Equals, "challenge")
+	c.Check(outcome.Time.Equal(time.Unix(1500000000, 0)), Equals, true)
+}
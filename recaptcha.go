@@ -1,15 +1,30 @@
 package recaptcha
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-const reCAPTCHALink = "https://www.google.com/recaptcha/api/siteverify"
+const (
+	// GoogleBaseURL default origin of the verification endpoint
+	GoogleBaseURL = "https://www.google.com"
+	// RecaptchaNetBaseURL origin of the verification endpoint for servers that can't reach google.com,
+	// e.g. deployments in China
+	RecaptchaNetBaseURL = "https://www.recaptcha.net"
+
+	siteVerifyPath = "/recaptcha/api/siteverify"
+	reCAPTCHALink  = GoogleBaseURL + siteVerifyPath
+)
 
 // VERSION the recaptcha api version
 type VERSION int8
@@ -21,6 +36,12 @@ const (
 	V3
 	// DefaultThreshold Default minimin score when using V3 api
 	DefaultThreshold float32 = 0.5
+	// DefaultClockSkew Default tolerance for challenges solved in the future
+	DefaultClockSkew = 5 * time.Minute
+	// DefaultMaxChallengeAge validity of the recaptcha tokens, a sensible ReCAPTCHA.MaxChallengeAge
+	DefaultMaxChallengeAge = 2 * time.Minute
+	// DefaultMaxResponseSize Default maximum size in bytes of the verification response bodies
+	DefaultMaxResponseSize int64 = 64 << 10
 )
 
 type reCHAPTCHARequest struct {
@@ -29,14 +50,16 @@ type reCHAPTCHARequest struct {
 	RemoteIP string `json:"remoteip,omitempty"`
 }
 
-type reCHAPTCHAResponse struct {
+// Response decoded verification response returned by recaptcha
+type Response struct {
 	Success        bool      `json:"success"`
 	ChallengeTS    time.Time `json:"challenge_ts"`
 	Hostname       string    `json:"hostname,omitempty"`
 	ApkPackageName string    `json:"apk_package_name,omitempty"`
-	Action         string    `json:"action,omitempty"`
-	Score          float32   `json:"score,omitempty"`
+	Action         string    `json:"action,omitempty"` // v3 recaptcha only
+	Score          float32   `json:"score,omitempty"`  // v3 recaptcha only
 	ErrorCodes     []string  `json:"error-codes,omitempty"`
+	CData          string    `json:"cdata,omitempty"` // turnstile only
 }
 
 // custom client so we can mock in tests
@@ -44,6 +67,24 @@ type netClient interface {
 	PostForm(url string, formValues url.Values) (resp *http.Response, err error)
 }
 
+// jsonClient clients able to post the JSON verification requests
+type jsonClient interface {
+	Post(url, contentType string, body io.Reader) (resp *http.Response, err error)
+}
+
+// doClient clients able to send requests canceled with their context
+type doClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// JSONFields names of the members of JSON verification requests, blank ones use the form field
+// names: secret, response and remoteip.
+type JSONFields struct {
+	Secret   string
+	Response string
+	RemoteIP string
+}
+
 // custom clock so we can mock in tests
 type clock interface {
 	Since(t time.Time) time.Duration
@@ -64,6 +105,111 @@ type ReCAPTCHA struct {
 	Version       VERSION
 	Timeout       time.Duration
 	horloge       clock
+	profiles      map[string]ActionProfile
+	// AllowedActions when set the V3 responses whose action is blank or not one of these are rejected,
+	// see ErrActionNotAllowed. It catches the frontends forgetting the action and the tokens minted
+	// for a cheaper action. Set it with SetAllowedActions to validate the action names.
+	AllowedActions []string
+	// Hostnames allowed response hostnames when neither VerifyOption.Hostname, VerifyOption.Hostnames
+	// nor the action profile hostnames are set, any hostname is allowed when empty. The hostnames are
+	// compared case insensitively and "*.example.com" matches the subdomains of example.com at any
+	// depth, but neither example.com itself nor badexample.com.
+	Hostnames []string
+	// NormalizeActions compare V3 action names case insensitively.
+	NormalizeActions bool
+	// ClockSkew challenges solved further in the future are rejected, DefaultClockSkew when zero.
+	ClockSkew time.Duration
+	// RequireChallengeTS reject the responses without challenge_ts, see ErrMissingChallengeTS. By
+	// default the ResponseTime and challenge age checks are skipped for these responses.
+	RequireChallengeTS bool
+	// ResponseTimeSkew tolerance added to VerifyOption.ResponseTime for the clock difference between
+	// the server and recaptcha, whose challenge_ts is compared to the server time.
+	ResponseTimeSkew time.Duration
+	// MaxChallengeAge verifier-wide maximum time elapsed since the challenge was solved, unlimited
+	// when zero. The first non-zero of VerifyOption.MaxChallengeAge, the ActionProfile.MaxAge of the
+	// response action and this one applies. The tokens expiring two minutes after the challenge,
+	// older ones usually reveal replays or queuing bugs, see DefaultMaxChallengeAge.
+	MaxChallengeAge time.Duration
+	// StrictVersion reject the responses not matching the configured version instead of only warning.
+	StrictVersion bool
+	// StrictResponse reject the successful responses missing the fields expected for the configured
+	// version and platform, or holding unexpected ones, see checkFields. Implies StrictVersion.
+	StrictResponse bool
+	// JSONBody when set posts the verification requests as a JSON object with these member names
+	// instead of a form, for ReCAPTCHALink proxies or self-hosted verifiers only accepting JSON.
+	JSONBody *JSONFields
+	// Provider captcha backend, ReCAPTCHAProvider when nil. ReCAPTCHALink must be set accordingly,
+	// see NewTurnstile.
+	Provider Provider
+	// ReplayCache when set rejects the challenge responses already verified before sending them to
+	// recaptcha, see ErrTokenReplayed.
+	ReplayCache *ReplayCache
+	// MaxResponseSize maximum size in bytes of the verification response bodies, larger ones failing
+	// the verification instead of being buffered, DefaultMaxResponseSize when zero.
+	MaxResponseSize int64
+	// ResultCache when set remembers the verification responses, repeated verifications of a token
+	// reusing them. The ReplayCache rejects the repeated verifications first, VerifyPooled doesn't use
+	// the cache.
+	ResultCache *ResultCache
+	// Policy maps the verification results of VerifyDecision to decisions when the action profile
+	// has no Policy.
+	Policy *Policy
+	// FailurePolicy applied when the verification request fails and the action profile, if any, has
+	// no policy of its own, FailClosed by default.
+	FailurePolicy FailurePolicy
+	// OnRequestFailure when set decides the FailurePolicy of every failed verification request
+	// instead of the action profiles and FailurePolicy, e.g. to fail open for known users only,
+	// FailInherit deferring to them.
+	OnRequestFailure func(ctx context.Context, options VerifyOption, err error) FailurePolicy
+	// Breaker when set short-circuits the verification requests while recaptcha is failing, see
+	// ErrCircuitOpen.
+	Breaker *CircuitBreaker
+	// SecondarySecret when set the verification requests failing with the invalid-input-secret error
+	// code are sent again with this secret, for zero-downtime key rotation: deploy the new secret as
+	// SecondarySecret, switch the site key, then promote it to Secret.
+	SecondarySecret string
+	// Coalesce share a single verification request between the concurrent verifications of the same
+	// challenge response, e.g. double-click submits, instead of failing the second one with the
+	// timeout-or-duplicate error code. Each verification still checks the response against its own
+	// options. VerifyPooled doesn't coalesce, and the ReplayCache rejects the duplicates first.
+	Coalesce bool
+	// DryRun run every check and report the failures to the Tracer, Observers and logger, but accept
+	// all the challenges, e.g. to collect the score distributions of a V3 rollout before enforcing
+	// the thresholds.
+	DryRun bool
+	// Rollout when set only enforces the verification failures of a percentage of the challenges, the
+	// others being reported as in DryRun mode, for the gradual rollout of stricter thresholds.
+	Rollout *Rollout
+	// ScoreEvaluator when set replaces the comparison of the V3 scores with the thresholds, e.g. for
+	// risk models depending on the time of day. The errors it returns fail the verification as
+	// ErrScoreTooLow and stay reachable with errors.Is and errors.As.
+	ScoreEvaluator func(response Response) error
+	// TokenSources ordered sources of the challenge response read by VerifyFromRequest, e.g. a JSON
+	// body member for SPAs and a header for mobile clients, DefaultTokenSources when empty.
+	TokenSources []TokenSource
+	// ClientIPResolver when set derives the remote IP of VerifyFromRequest from the forwarding headers
+	// of the trusted proxies instead of using the request remote address.
+	ClientIPResolver *ClientIPResolver
+	// BypassNetworks requests of VerifyFromRequest from these networks (health checkers, office
+	// IPs...) are accepted without verification, reporting an EventBypass to the Observers. See
+	// ParseNetworks.
+	BypassNetworks []*net.IPNet
+	// Honeypot name of a hidden form field that humans leave empty, requests of VerifyFromRequest
+	// filling it fail without verification, reporting an EventHoneypot to the Observers. Disabled
+	// when blank.
+	Honeypot string
+	// Tracer when set instruments every verification.
+	Tracer Tracer
+	// Observers receive an EventVerification for every verification, e.g. to collect metrics, and a
+	// single EventVersionMismatch warning when the responses don't match the configured version. The
+	// middlewares wrapping the ReCAPTCHA report the same verifications to their own Observers along
+	// with their decisions, see Middleware.Observers.
+	Observers      []Observer
+	lifecycle      *Lifecycle
+	logger         *slog.Logger
+	devBypass      bool
+	skip           func(*http.Request) bool
+	mismatchWarned int32
 }
 
 // Error custom error to pass ErrorCodes and RequestError to user.
@@ -75,8 +221,36 @@ type Error struct {
 	RequestError bool
 	// ResponseBody holds the raw response body from recaptcha.
 	ResponseBody string
+	kind         errorKind
+	cause        error
 }
 
+// errorKind classifies verification failures for helpers reacting to specific failures.
+type errorKind int8
+
+const (
+	kindOther errorKind = iota
+	kindLowScore
+	kindFutureChallenge
+	kindChallengeTooFresh
+	kindChallengeTooOld
+	kindVersionMismatch
+	kindActionMismatch
+	kindInvalidAction
+	kindRemoteErrors
+	kindInvalidSolution
+	kindHostnameMismatch
+	kindApkPackageNameMismatch
+	kindResponseTimeExceeded
+	kindRequestFailed
+	kindCDataMismatch
+	kindReplayedToken
+	kindCircuitOpen
+	kindActionNotAllowed
+	kindMissingChallengeTS
+	kindUnexpectedResponse
+)
+
 func (e *Error) Error() string { return e.msg }
 
 // NewReCAPTCHA new ReCAPTCHA instance if version is set to V2 uses recatpcha v2 API
@@ -98,10 +272,72 @@ func NewReCAPTCHA(ReCAPTCHASecret string, version VERSION, timeout time.Duration
 	}, nil
 }
 
+// NewReCAPTCHAWithBaseURL same as NewReCAPTCHA but verifies against the siteverify endpoint of
+// baseURL, e.g. RecaptchaNetBaseURL
+func NewReCAPTCHAWithBaseURL(ReCAPTCHASecret string, version VERSION, timeout time.Duration, baseURL string) (ReCAPTCHA, error) {
+	captcha, err := NewReCAPTCHA(ReCAPTCHASecret, version, timeout)
+	if err != nil {
+		return captcha, err
+	}
+	if err := captcha.SetBaseURL(baseURL); err != nil {
+		return ReCAPTCHA{}, err
+	}
+	return captcha, nil
+}
+
+// SetBaseURL verifies against the siteverify endpoint of baseURL, e.g. RecaptchaNetBaseURL. Set
+// ReCAPTCHALink instead for endpoints not following the /recaptcha/api/siteverify path.
+func (r *ReCAPTCHA) SetBaseURL(baseURL string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid recaptcha base URL '%s', while expecting an absolute http(s) URL", baseURL)
+	}
+	r.ReCAPTCHALink = strings.TrimSuffix(baseURL, "/") + siteVerifyPath
+	return nil
+}
+
+// NewReCAPTCHAWithClient same as NewReCAPTCHA but sends the verification requests with client, e.g. an
+// instrumented, pooled or proxied client, its Timeout being used
+func NewReCAPTCHAWithClient(ReCAPTCHASecret string, version VERSION, client *http.Client) (ReCAPTCHA, error) {
+	if client == nil {
+		return ReCAPTCHA{}, fmt.Errorf("recaptcha http client cannot be nil")
+	}
+	captcha, err := NewReCAPTCHA(ReCAPTCHASecret, version, client.Timeout)
+	if err != nil {
+		return captcha, err
+	}
+	captcha.client = client
+	return captcha, nil
+}
+
+// SetHTTPClient replaces the client sending the verification requests, discarding the decorators
+// added with Decorate
+func (r *ReCAPTCHA) SetHTTPClient(client *http.Client) error {
+	if client == nil {
+		return fmt.Errorf("recaptcha http client cannot be nil")
+	}
+	r.client = client
+	r.Timeout = client.Timeout
+	return nil
+}
+
 // Verify returns `nil` if no error and the client solved the challenge correctly
 func (r *ReCAPTCHA) Verify(challengeResponse string) error {
+	return r.VerifyWithContext(context.Background(), challengeResponse)
+}
+
+// VerifyWithContext same as Verify but the verification request is canceled when the context is done,
+// e.g. with the context of the server request being handled
+func (r *ReCAPTCHA) VerifyWithContext(ctx context.Context, challengeResponse string) error {
+	body := reCHAPTCHARequest{Secret: r.Secret, Response: challengeResponse}
+	return r.confirm(ctx, body, VerifyOption{})
+}
+
+// VerifyWithResponse same as Verify but also returns the decoded verification response (score, action,
+// hostname, challenge_ts, error codes), the response is zero when the verification request failed
+func (r *ReCAPTCHA) VerifyWithResponse(challengeResponse string) (Response, error) {
 	body := reCHAPTCHARequest{Secret: r.Secret, Response: challengeResponse}
-	return r.confirm(body, VerifyOption{})
+	return r.confirmResponse(context.Background(), body, VerifyOption{})
 }
 
 // VerifyOption verification options expected for the challenge
@@ -112,80 +348,191 @@ type VerifyOption struct {
 	ApkPackageName string
 	ResponseTime   time.Duration
 	RemoteIP       string
+	// Hostnames allowed response hostnames when Hostname is blank, for apps served from several
+	// domains. The action profile and ReCAPTCHA.Hostnames apply when empty. Hostnames, like Hostname,
+	// may be wildcard patterns such as "*.example.com", see ReCAPTCHA.Hostnames.
+	Hostnames []string
+	// MinChallengeAge minimum time elapsed since the challenge was solved, unchecked when zero.
+	MinChallengeAge time.Duration
+	// MaxChallengeAge maximum time elapsed since the challenge was solved, the maximum token age of
+	// this verification. Takes precedence over ActionProfile.MaxAge and then ReCAPTCHA.MaxChallengeAge,
+	// applied in this order when zero.
+	MaxChallengeAge time.Duration
+	// Context arbitrary key/value pairs (user ID hash, form name, tenant...) propagated to events for
+	// correlation, never sent to recaptcha.
+	Context map[string]string
+	// CData customer data expected in the response, Turnstile only.
+	CData string
+	// IdempotencyKey sent with the request so it can be retried without failing as a duplicate,
+	// Turnstile only.
+	IdempotencyKey string
+	// Timeout time budget of the verification request, retries included, overriding ReCAPTCHA.Timeout
+	// for this call only, e.g. shorter on a login hot path and longer in background jobs. The context
+	// deadline still applies.
+	Timeout time.Duration
 }
 
 // VerifyWithOptions returns `nil` if no error and the client solved the challenge correctly and all options are matching
 // `Threshold` and `Action` are ignored when using V2 version
 func (r *ReCAPTCHA) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	return r.VerifyWithOptionsContext(context.Background(), challengeResponse, options)
+}
+
+// VerifyWithOptionsContext same as VerifyWithOptions but the verification request is canceled when the
+// context is done
+func (r *ReCAPTCHA) VerifyWithOptionsContext(ctx context.Context, challengeResponse string, options VerifyOption) error {
+	_, err := r.VerifyWithOptionsResponseContext(ctx, challengeResponse, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the decoded verification response,
+// the response is zero when the verification request failed
+func (r *ReCAPTCHA) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	return r.VerifyWithOptionsResponseContext(context.Background(), challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as VerifyWithOptionsResponse but the verification request is
+// canceled when the context is done
+func (r *ReCAPTCHA) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options VerifyOption) (Response, error) {
+	if r.Version == V3 && options.Action != "" {
+		if err := ValidateAction(options.Action); err != nil {
+			return Response{}, &Error{msg: err.Error(), kind: kindInvalidAction}
+		}
+	}
 	var body reCHAPTCHARequest
 	if options.RemoteIP == "" {
 		body = reCHAPTCHARequest{Secret: r.Secret, Response: challengeResponse}
 	} else {
 		body = reCHAPTCHARequest{Secret: r.Secret, Response: challengeResponse, RemoteIP: options.RemoteIP}
 	}
-	return r.confirm(body, options)
+	return r.confirmResponse(ctx, body, options)
 }
 
-func (r *ReCAPTCHA) confirm(recaptcha reCHAPTCHARequest, options VerifyOption) error {
-	var formValues url.Values
-	if recaptcha.RemoteIP != "" {
-		formValues = url.Values{"secret": {recaptcha.Secret}, "remoteip": {recaptcha.RemoteIP}, "response": {recaptcha.Response}}
-	} else {
-		formValues = url.Values{"secret": {recaptcha.Secret}, "response": {recaptcha.Response}}
+func (r *ReCAPTCHA) confirm(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) error {
+	_, err := r.confirmResponse(ctx, recaptcha, options)
+	return err
+}
+
+func (r *ReCAPTCHA) confirmResponse(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, error) {
+	var result Response
+	var err error
+	if r.Tracer != nil {
+		var end func(Response, error)
+		ctx, end = r.Tracer.Start(ctx, r.Version, options)
+		defer func() { end(result, err) }()
+	}
+	start := time.Now()
+	var failedOpen bool
+	result, failedOpen, err = r.verify(ctx, recaptcha, options)
+	if len(r.Observers) > 0 {
+		r.observe(options, result, err, failedOpen, time.Since(start))
+	}
+	if failedOpen {
+		err = nil
 	}
+	enforced := err == nil || r.enforced(recaptcha.Response, options)
+	r.logResult(ctx, options, result, err, enforced)
+	if !enforced {
+		return result, nil
+	}
+	return result, err
+}
 
-	response, err := r.client.PostForm(r.ReCAPTCHALink, formValues)
-	if err != nil {
-		return &Error{
-			msg:          fmt.Sprintf("error posting to recaptcha endpoint: '%s'", err),
-			RequestError: true,
-		}
+// enforced reports whether the verification failures of the challenge are returned to the caller,
+// see DryRun and Rollout.
+func (r *ReCAPTCHA) enforced(challengeResponse string, options VerifyOption) bool {
+	if r.DryRun {
+		return false
 	}
-	defer response.Body.Close()
+	return r.Rollout == nil || r.Rollout.enforced(challengeResponse, options)
+}
 
-	resultBody, err := ioutil.ReadAll(response.Body)
+func (r *ReCAPTCHA) verify(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, bool, error) {
+	if err := r.checkReplay(recaptcha.Response); err != nil {
+		return Response{}, false, err
+	}
+	result, resultBody, err := r.fetchCached(ctx, recaptcha, options)
 	if err != nil {
-		return &Error{
-			msg:          fmt.Sprintf("couldn't read response body: '%s'", err),
-			RequestError: true,
-		}
+		return result, r.failOpen(ctx, options, err), err
 	}
+	return result, false, r.check(recaptcha, options, result, resultBody)
+}
 
-	var result reCHAPTCHAResponse
-	err = json.Unmarshal(resultBody, &result)
-	if err != nil {
-		return &Error{
-			msg:          fmt.Sprintf("invalid response body json: '%s'", err),
-			RequestError: true,
-			ResponseBody: string(resultBody),
-		}
+// threshold returns the minimum score of the verification.
+func (r *ReCAPTCHA) threshold(options VerifyOption, profile ActionProfile) float32 {
+	if options.Threshold != 0 {
+		return options.Threshold
+	}
+	if profile.Threshold != 0 {
+		return profile.Threshold
 	}
+	return DefaultThreshold
+}
+
+// observe notifies the observers of a verification which took elapsed, not reported when rejected
+// without sending a request. The request failures accepted by the fail open policy are allowed with
+// their error and the FailOpenReason.
+func (r *ReCAPTCHA) observe(options VerifyOption, result Response, err error, failedOpen bool, elapsed time.Duration) {
+	if verr, ok := err.(*Error); ok && (verr.kind == kindInvalidAction || verr.kind == kindReplayedToken || verr.kind == kindCircuitOpen) {
+		elapsed = 0
+	}
+	outcome := Outcome{Decision: Allow}
+	if failedOpen {
+		outcome.Reasons = []string{FailOpenReason, err.Error()}
+	} else if err != nil {
+		outcome = Outcome{Decision: Block, Reasons: []string{err.Error()}}
+	}
+	event := newEvent(EventVerification, options, result, err, outcome)
+	event.Duration = elapsed
+	notify(r.Observers, event)
+}
 
-	if r.Version == V3 {
-		if options.Action != "" && options.Action != result.Action {
+// check validates the decoded response against the options, resultBody being reported in the errors.
+func (r *ReCAPTCHA) check(recaptcha reCHAPTCHARequest, options VerifyOption, result Response, resultBody []byte) error {
+	if err := r.checkVersion(options, result); err != nil {
+		err.ResponseBody = string(resultBody)
+		return err
+	}
+	if err := r.checkFields(options, result, resultBody); err != nil {
+		err.ResponseBody = string(resultBody)
+		return err
+	}
+
+	var profile ActionProfile
+	if _, ok := r.provider().(recaptchaProvider); ok && r.Version == V3 {
+		profile = r.profile(result.Action)
+		if len(r.AllowedActions) > 0 && !r.allowedAction(result.Action) {
 			return &Error{
-				msg:          fmt.Sprintf("invalid response action '%s', while expecting '%s'", result.Action, options.Action),
+				msg:          fmt.Sprintf("response action '%s' not allowed, while expecting one of %v", result.Action, r.AllowedActions),
 				ResponseBody: string(resultBody),
+				kind:         kindActionNotAllowed,
 			}
 		}
-		if options.Threshold != 0 && options.Threshold > result.Score {
+		if options.Action != "" && !r.sameAction(options.Action, result.Action) {
 			return &Error{
-				msg:          fmt.Sprintf("received score '%f', while expecting minimum '%f'", result.Score, options.Threshold),
+				msg:          fmt.Sprintf("invalid response action '%s', while expecting '%s'", result.Action, options.Action),
 				ResponseBody: string(resultBody),
+				kind:         kindActionMismatch,
 			}
 		}
-		if options.Threshold == 0 && DefaultThreshold > result.Score {
+		if r.ScoreEvaluator != nil {
+			if err := r.ScoreEvaluator(result); err != nil {
+				return &Error{msg: err.Error(), ResponseBody: string(resultBody), kind: kindLowScore, cause: err}
+			}
+		} else if threshold := r.threshold(options, profile); threshold > result.Score {
 			return &Error{
-				msg:          fmt.Sprintf("received score '%f', while expecting minimum '%f'", result.Score, DefaultThreshold),
+				msg:          fmt.Sprintf("received score '%f', while expecting minimum '%f'", result.Score, threshold),
 				ResponseBody: string(resultBody),
+				kind:         kindLowScore,
 			}
 		}
 	}
 
-	if result.ErrorCodes != nil {
+	if len(result.ErrorCodes) > 0 {
 		return &Error{
 			msg: fmt.Sprintf("remote error codes: %v", result.ErrorCodes), ErrorCodes: result.ErrorCodes,
 			ResponseBody: string(resultBody),
+			kind:         kindRemoteErrors,
 		}
 	}
 
@@ -193,18 +540,37 @@ func (r *ReCAPTCHA) confirm(recaptcha reCHAPTCHARequest, options VerifyOption) e
 		return &Error{
 			msg:          fmt.Sprintf("invalid challenge solution or remote IP"),
 			ResponseBody: string(resultBody),
+			kind:         kindInvalidSolution,
 		}
 	} else if !result.Success {
 		return &Error{
 			msg:          fmt.Sprintf("invalid challenge solution"),
 			ResponseBody: string(resultBody),
+			kind:         kindInvalidSolution,
 		}
 	}
 
-	if options.Hostname != "" && options.Hostname != result.Hostname {
+	if err := r.provider().Validate(result, options); err != nil {
+		if recaptchaErr, ok := err.(*Error); ok {
+			recaptchaErr.ResponseBody = string(resultBody)
+			return recaptchaErr
+		}
+		return &Error{msg: err.Error(), ResponseBody: string(resultBody)}
+	}
+
+	if options.Hostname != "" && !matchHostname(options.Hostname, result.Hostname) {
 		return &Error{
 			msg:          fmt.Sprintf("invalid response hostname '%s', while expecting '%s'", result.Hostname, options.Hostname),
 			ResponseBody: string(resultBody),
+			kind:         kindHostnameMismatch,
+		}
+	}
+
+	if hostnames := r.hostnames(options, profile); options.Hostname == "" && len(hostnames) > 0 && !matchHostnames(hostnames, result.Hostname) {
+		return &Error{
+			msg:          fmt.Sprintf("invalid response hostname '%s', while expecting one of %v", result.Hostname, hostnames),
+			ResponseBody: string(resultBody),
+			kind:         kindHostnameMismatch,
 		}
 	}
 
@@ -212,19 +578,374 @@ func (r *ReCAPTCHA) confirm(recaptcha reCHAPTCHARequest, options VerifyOption) e
 		return &Error{
 			msg:          fmt.Sprintf("invalid response ApkPackageName '%s', while expecting '%s'", result.ApkPackageName, options.ApkPackageName),
 			ResponseBody: string(resultBody),
+			kind:         kindApkPackageNameMismatch,
+		}
+	}
+
+	if result.ChallengeTS.IsZero() {
+		// some responses, e.g. Enterprise ones, omit challenge_ts so the times can't be checked
+		if r.RequireChallengeTS {
+			return &Error{
+				msg:          "received response without challenge_ts, while expecting the challenge timestamp",
+				ResponseBody: string(resultBody),
+				kind:         kindMissingChallengeTS,
+			}
 		}
+		return nil
 	}
 
 	if options.ResponseTime != 0 {
-		duration := r.horloge.Since(result.ChallengeTS)
-		if options.ResponseTime < duration {
-			msg := fmt.Sprintf("time spent in resolving challenge '%fs', while expecting maximum '%fs'", duration.Seconds(), options.ResponseTime.Seconds())
+		duration := r.since(result.ChallengeTS)
+		if duration < 0 {
+			// challenge_ts ahead of the server clock
+			duration = 0
+		}
+		if maximum := options.ResponseTime + r.ResponseTimeSkew; maximum < duration {
+			msg := fmt.Sprintf("time spent in resolving challenge '%fs', while expecting maximum '%fs'", duration.Seconds(), maximum.Seconds())
 			return &Error{
 				msg:          msg,
 				ResponseBody: string(resultBody),
+				kind:         kindResponseTimeExceeded,
 			}
 		}
 	}
 
+	if err := r.checkChallengeAge(result.ChallengeTS, options, profile); err != nil {
+		err.ResponseBody = string(resultBody)
+		return err
+	}
+
 	return nil
 }
+
+// checkVersion detects successful responses not matching the configured version, V3 responses always
+// holding an action and V2 ones never, warning the observers once and only failing with StrictVersion.
+func (r *ReCAPTCHA) checkVersion(options VerifyOption, result Response) *Error {
+	if _, ok := r.provider().(recaptchaProvider); !ok || !result.Success {
+		return nil
+	}
+	var err *Error
+	if r.Version == V3 && result.Action == "" && result.Score == 0 {
+		err = &Error{msg: "received response without score nor action, while expecting a V3 response", kind: kindVersionMismatch}
+	} else if r.Version == V2 && (result.Action != "" || result.Score != 0) {
+		err = &Error{msg: fmt.Sprintf("received response with score '%f' and action '%s', while expecting a V2 response", result.Score, result.Action), kind: kindVersionMismatch}
+	}
+	if err == nil {
+		return nil
+	}
+	if atomic.CompareAndSwapInt32(&r.mismatchWarned, 0, 1) {
+		notify(r.Observers, newEvent(EventVersionMismatch, options, result, err, Outcome{Reasons: []string{err.msg}}))
+	}
+	if !r.StrictVersion && !r.StrictResponse {
+		return nil
+	}
+	return err
+}
+
+// responseFields presence of the optional response fields, decoded from the response body since a
+// zero score can't be told from a missing one in Response.
+type responseFields struct {
+	Score          *float32 `json:"score"`
+	Action         *string  `json:"action"`
+	Hostname       *string  `json:"hostname"`
+	ApkPackageName *string  `json:"apk_package_name"`
+}
+
+// checkFields rejects with StrictResponse the successful responses not holding the fields expected
+// for the configured version, score and action for V3 and neither for V2, and platform, hostname for
+// the web and apk_package_name for Android, the platform being Android when
+// VerifyOption.ApkPackageName is set.
+func (r *ReCAPTCHA) checkFields(options VerifyOption, result Response, resultBody []byte) *Error {
+	if _, ok := r.provider().(recaptchaProvider); !ok || !r.StrictResponse || !result.Success {
+		return nil
+	}
+	var fields responseFields
+	if err := json.Unmarshal(resultBody, &fields); err != nil {
+		return &Error{msg: fmt.Sprintf("invalid response body json: %v", err), kind: kindUnexpectedResponse}
+	}
+
+	switch {
+	case r.Version == V3 && fields.Score == nil:
+		return &Error{msg: "received response without score, while expecting a V3 response", kind: kindUnexpectedResponse}
+	case r.Version == V3 && (fields.Action == nil || *fields.Action == ""):
+		return &Error{msg: "received response without action, while expecting a V3 response", kind: kindUnexpectedResponse}
+	case r.Version == V2 && fields.Score != nil:
+		return &Error{msg: fmt.Sprintf("received response with score '%f', while expecting a V2 response", *fields.Score), kind: kindUnexpectedResponse}
+	case r.Version == V2 && fields.Action != nil:
+		return &Error{msg: fmt.Sprintf("received response with action '%s', while expecting a V2 response", *fields.Action), kind: kindUnexpectedResponse}
+	}
+
+	hostname, apkPackageName := fields.Hostname != nil && *fields.Hostname != "", fields.ApkPackageName != nil && *fields.ApkPackageName != ""
+	switch {
+	case hostname && apkPackageName:
+		return &Error{
+			msg:  fmt.Sprintf("received response with hostname '%s' and apk_package_name '%s', while expecting only one of them", result.Hostname, result.ApkPackageName),
+			kind: kindUnexpectedResponse,
+		}
+	case options.ApkPackageName != "" && !apkPackageName:
+		return &Error{
+			msg:  fmt.Sprintf("received response with hostname '%s', while expecting an Android response", result.Hostname),
+			kind: kindUnexpectedResponse,
+		}
+	case options.ApkPackageName == "" && apkPackageName:
+		return &Error{
+			msg:  fmt.Sprintf("received response with apk_package_name '%s', while expecting a web response", result.ApkPackageName),
+			kind: kindUnexpectedResponse,
+		}
+	case options.ApkPackageName == "" && !hostname:
+		return &Error{msg: "received response without hostname, while expecting a web response", kind: kindUnexpectedResponse}
+	}
+	return nil
+}
+
+// checkChallengeAge rejects challenges solved in the future beyond the clock skew, too recently or too long ago.
+func (r *ReCAPTCHA) checkChallengeAge(challengeTS time.Time, options VerifyOption, profile ActionProfile) *Error {
+	age := r.since(challengeTS)
+	skew := r.ClockSkew
+	if skew == 0 {
+		skew = DefaultClockSkew
+	}
+	if age < -skew {
+		return &Error{
+			msg:  fmt.Sprintf("challenge solved '%fs' in the future, while expecting maximum clock skew '%fs'", -age.Seconds(), skew.Seconds()),
+			kind: kindFutureChallenge,
+		}
+	}
+
+	if options.MinChallengeAge != 0 && age < options.MinChallengeAge {
+		return &Error{
+			msg:  fmt.Sprintf("challenge solved '%fs' ago, while expecting minimum '%fs'", age.Seconds(), options.MinChallengeAge.Seconds()),
+			kind: kindChallengeTooFresh,
+		}
+	}
+
+	maxAge := options.MaxChallengeAge
+	if maxAge == 0 {
+		maxAge = profile.MaxAge
+	}
+	if maxAge == 0 {
+		maxAge = r.MaxChallengeAge
+	}
+	if maxAge != 0 && maxAge < age {
+		return &Error{
+			msg:  fmt.Sprintf("challenge solved '%fs' ago, while expecting maximum '%fs'", age.Seconds(), maxAge.Seconds()),
+			kind: kindChallengeTooOld,
+		}
+	}
+	return nil
+}
+
+func (r *ReCAPTCHA) since(t time.Time) time.Duration {
+	if r.horloge == nil {
+		return time.Since(t)
+	}
+	return r.horloge.Since(t)
+}
+
+// fetch posts the challenge to the verification endpoint and decodes its response.
+func (r *ReCAPTCHA) fetch(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, []byte, error) {
+	if r.Coalesce {
+		return r.fetchCoalesced(ctx, recaptcha, options)
+	}
+	return r.fetchOnce(ctx, recaptcha, options)
+}
+
+func (r *ReCAPTCHA) fetchOnce(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, []byte, error) {
+	var result Response
+	var body bytes.Buffer
+	resultBody, err := r.fetchInto(ctx, recaptcha, options, url.Values{}, &body, &result)
+	return result, resultBody, err
+}
+
+// fetchInto same as fetch but reuses the given form, body buffer and response, the returned body
+// is only valid until the buffer is reused. The request goes through Breaker when set.
+func (r *ReCAPTCHA) fetchInto(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption, formValues url.Values, body *bytes.Buffer, result *Response) ([]byte, error) {
+	if r.devBypass && recaptcha.Secret == TestSecret {
+		*result = r.bypass(ctx, options)
+		return nil, nil
+	}
+	if r.Breaker == nil {
+		return r.sendRotating(ctx, recaptcha, options, formValues, body, result)
+	}
+	if !r.Breaker.allow() {
+		if r.logger != nil {
+			r.log(ctx, slog.LevelWarn, "recaptcha circuit open, verification request short-circuited")
+		}
+		return nil, &Error{
+			msg:          "recaptcha circuit open, verification request short-circuited",
+			RequestError: true,
+			kind:         kindCircuitOpen,
+		}
+	}
+	resultBody, err := r.sendRotating(ctx, recaptcha, options, formValues, body, result)
+	r.Breaker.record(ctx, err)
+	return resultBody, err
+}
+
+// send posts the verification request and decodes its response.
+func (r *ReCAPTCHA) send(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption, formValues url.Values, body *bytes.Buffer, result *Response) ([]byte, error) {
+	for key, values := range formValues {
+		formValues[key] = values[:0]
+	}
+	options.RemoteIP = recaptcha.RemoteIP
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	r.provider().BuildRequest(formValues, recaptcha.Secret, recaptcha.Response, options)
+	if r.logger != nil {
+		r.log(ctx, slog.LevelDebug, "recaptcha request sent", "url", r.ReCAPTCHALink, "action", options.Action, "remote_ip", options.RemoteIP)
+	}
+	response, err := r.post(ctx, formValues, options.Timeout)
+	if err != nil {
+		if r.logger != nil {
+			r.log(ctx, slog.LevelWarn, "recaptcha request failed", "url", r.ReCAPTCHALink, "error", err)
+		}
+		return nil, &Error{
+			msg:          fmt.Sprintf("error posting to recaptcha endpoint: '%s'", err),
+			RequestError: true,
+			kind:         kindRequestFailed,
+		}
+	}
+	defer response.Body.Close()
+
+	maxSize := r.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxResponseSize
+	}
+	n, err := readLimited(body, response.Body, maxSize+1)
+	if err != nil {
+		if r.logger != nil {
+			r.log(ctx, slog.LevelWarn, "recaptcha response body read failed", "status", response.StatusCode, "error", err)
+		}
+		return nil, &Error{
+			msg:          fmt.Sprintf("couldn't read response body: '%s'", err),
+			RequestError: true,
+			kind:         kindRequestFailed,
+		}
+	}
+	if n > maxSize {
+		if r.logger != nil {
+			r.log(ctx, slog.LevelWarn, "recaptcha response body too large", "status", response.StatusCode, "max_size", maxSize)
+		}
+		return nil, &Error{
+			msg:          fmt.Sprintf("response body larger than '%d' bytes", maxSize),
+			RequestError: true,
+			kind:         kindRequestFailed,
+		}
+	}
+	resultBody := body.Bytes()
+
+	err = r.provider().ParseResponse(resultBody, result)
+	if err != nil {
+		if r.logger != nil {
+			r.log(ctx, slog.LevelWarn, "recaptcha response parsing failed", "status", response.StatusCode, "error", err)
+		}
+		return resultBody, &Error{
+			msg:          fmt.Sprintf("invalid response body json: '%s'", err),
+			RequestError: true,
+			ResponseBody: string(resultBody),
+			kind:         kindRequestFailed,
+		}
+	}
+	if r.logger != nil {
+		r.log(ctx, slog.LevelDebug, "recaptcha response parsed", "status", response.StatusCode, "success", result.Success,
+			"score", logScore(result.Score), "action", result.Action, "hostname", result.Hostname, "error_codes", result.ErrorCodes)
+	}
+	return resultBody, nil
+}
+
+// readLimited same as body.ReadFrom(io.LimitReader(r, limit)) without allocating the limited reader.
+func readLimited(body *bytes.Buffer, r io.Reader, limit int64) (int64, error) {
+	var total int64
+	for total < limit {
+		body.Grow(bytes.MinRead)
+		buf := body.AvailableBuffer()
+		buf = buf[:cap(buf)]
+		if rest := limit - total; int64(len(buf)) > rest {
+			buf = buf[:rest]
+		}
+		n, err := r.Read(buf)
+		body.Write(buf[:n])
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// post sends the request as a form, or as JSON with JSONBody. Clients without a Do method, such as
+// custom clients, only check the context before posting. A positive timeout replaces the Timeout of
+// http clients, the context carrying the deadline.
+func (r *ReCAPTCHA) post(ctx context.Context, formValues url.Values, timeout time.Duration) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var contentType string
+	var body []byte
+	if r.JSONBody != nil {
+		var err error
+		if body, err = r.jsonBody(formValues); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	client, ok := r.client.(doClient)
+	if httpClient, isHTTP := client.(*http.Client); isHTTP && timeout > 0 && httpClient.Timeout != 0 {
+		perCall := *httpClient
+		perCall.Timeout = 0
+		client = &perCall
+	}
+	if !ok {
+		if r.JSONBody == nil {
+			return r.client.PostForm(r.ReCAPTCHALink, formValues)
+		}
+		jsonClient, ok := r.client.(jsonClient)
+		if !ok {
+			return nil, fmt.Errorf("client cannot post JSON requests")
+		}
+		return jsonClient.Post(r.ReCAPTCHALink, contentType, bytes.NewReader(body))
+	}
+
+	if r.JSONBody == nil {
+		contentType, body = "application/x-www-form-urlencoded", []byte(formValues.Encode())
+	}
+	req, err := http.NewRequest(http.MethodPost, r.ReCAPTCHALink, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return client.Do(req.WithContext(ctx))
+}
+
+// jsonBody returns the form as a JSON object, the secret, response and remoteip members being named
+// after JSONBody.
+func (r *ReCAPTCHA) jsonBody(formValues url.Values) ([]byte, error) {
+	names := map[string]string{
+		"secret":   r.JSONBody.Secret,
+		"response": r.JSONBody.Response,
+		"remoteip": r.JSONBody.RemoteIP,
+	}
+	members := make(map[string]string, len(formValues))
+	for key, values := range formValues {
+		if len(values) == 0 {
+			continue
+		}
+		if name := names[key]; name != "" {
+			key = name
+		}
+		members[key] = values[0]
+	}
+	return json.Marshal(members)
+}
+
+// setFormValue sets the single value of key reusing its slice.
+func setFormValue(formValues url.Values, key, value string) {
+	formValues[key] = append(formValues[key][:0], value)
+}
@@ -1,10 +1,13 @@
 package recaptcha
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -30,6 +33,22 @@ func (s *ReCaptchaSuite) TestNewReCAPTCHA(c *C) {
 	captcha, err = NewReCAPTCHA("", V2, 10)
 }
 
+func (s *ReCaptchaSuite) TestBaseURL(c *C) {
+	captcha, err := NewReCAPTCHAWithBaseURL("my secret", V3, 10*time.Second, RecaptchaNetBaseURL)
+	c.Assert(err, IsNil)
+	c.Check(captcha.ReCAPTCHALink, Equals, "https://www.recaptcha.net/recaptcha/api/siteverify")
+
+	c.Check(captcha.SetBaseURL("http://verifier.internal:8080/"), IsNil)
+	c.Check(captcha.ReCAPTCHALink, Equals, "http://verifier.internal:8080/recaptcha/api/siteverify")
+	c.Check(captcha.SetBaseURL("www.recaptcha.net"), ErrorMatches, "invalid recaptcha base URL 'www.recaptcha.net', while expecting an absolute http\\(s\\) URL")
+	c.Check(captcha.ReCAPTCHALink, Equals, "http://verifier.internal:8080/recaptcha/api/siteverify")
+
+	_, err = NewReCAPTCHAWithBaseURL("my secret", V3, 10*time.Second, "")
+	c.Check(err, NotNil)
+	_, err = NewReCAPTCHAWithBaseURL("", V3, 10*time.Second, RecaptchaNetBaseURL)
+	c.Check(err, ErrorMatches, "recaptcha secret cannot be blank")
+}
+
 type mockInvalidClient struct{}
 type mockUnavailableClient struct{}
 
@@ -79,7 +98,7 @@ func (s *ReCaptchaSuite) TestConfirm(c *C) {
 	}
 	body := reCHAPTCHARequest{Secret: "", Response: ""}
 
-	err := captcha.confirm(body, VerifyOption{})
+	err := captcha.confirm(context.Background(), body, VerifyOption{})
 	c.Assert(err, NotNil)
 	recaptchaErr, ok := err.(*Error)
 	c.Check(ok, Equals, true)
@@ -87,7 +106,7 @@ func (s *ReCaptchaSuite) TestConfirm(c *C) {
 	c.Check(err, ErrorMatches, "invalid response body json:.*")
 
 	captcha.client = &mockUnavailableClient{}
-	err = captcha.confirm(body, VerifyOption{})
+	err = captcha.confirm(context.Background(), body, VerifyOption{})
 	c.Assert(err, NotNil)
 	recaptchaErr, ok = err.(*Error)
 	c.Check(ok, Equals, true)
@@ -95,7 +114,7 @@ func (s *ReCaptchaSuite) TestConfirm(c *C) {
 	c.Check(err, ErrorMatches, "error posting to recaptcha endpoint:.*")
 
 	captcha.client = &mockInvalidReaderClient{}
-	err = captcha.confirm(body, VerifyOption{})
+	err = captcha.confirm(context.Background(), body, VerifyOption{})
 	c.Assert(err, NotNil)
 	recaptchaErr, ok = err.(*Error)
 	c.Check(ok, Equals, true)
@@ -186,6 +205,27 @@ func (s *ReCaptchaSuite) TestVerifyWithoutOptions(c *C) {
 
 }
 
+func (s *ReCaptchaSuite) TestVerifyWithResponse(c *C) {
+	captcha := ReCAPTCHA{
+		client: &mockSuccessClientNoOptions{},
+	}
+	response, err := captcha.VerifyWithResponse("mycode")
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(response.Hostname, Equals, "test.com")
+	c.Check(response.ChallengeTS.Unix(), Equals, int64(1520307689))
+
+	captcha.client = &mockFailedClientNoOptions{}
+	response, err = captcha.VerifyWithResponse("mycode")
+	c.Check(err, ErrorMatches, "remote error codes:.*")
+	c.Check(response.ErrorCodes, DeepEquals, []string{"invalid-input-response", "bad-request"})
+
+	captcha.client = &mockUnavailableClient{}
+	response, err = captcha.VerifyWithResponse("mycode")
+	c.Check(err, NotNil)
+	c.Check(response, DeepEquals, Response{})
+}
+
 type mockSuccessClientWithRemoteIPOption struct{}
 type mockFailClientWithRemoteIPOption struct{}
 
@@ -312,6 +352,82 @@ func (s *ReCaptchaSuite) TestVerifyWithResponseOption(c *C) {
 	c.Check(recaptchaErr.RequestError, Equals, false)
 	c.Check(err, ErrorMatches, "time spent in resolving challenge '8.000000s', while expecting maximum '5.000000s'")
 
+	captcha.ResponseTimeSkew = 3 * time.Second
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{ResponseTime: 5 * time.Second}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{ResponseTime: 4 * time.Second}), ErrorMatches, "time spent in resolving challenge '8.000000s', while expecting maximum '7.000000s'")
+}
+
+func (s *ReCaptchaSuite) TestVerifyWithResponseOptionClockAhead(c *C) {
+	captcha := ReCAPTCHA{
+		client:  &mockSuccessClientNoOptions{},
+		horloge: mockClockAge(-2 * time.Second),
+	}
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{ResponseTime: time.Second}), IsNil)
+	// the negative duration is clamped, not compared
+	response, err := captcha.VerifyWithOptionsResponse("mycode", VerifyOption{ResponseTime: time.Nanosecond})
+	c.Check(err, IsNil)
+	c.Check(response.Success, Equals, true)
+}
+
+func (s *ReCaptchaSuite) TestVerifyWithoutChallengeTS(c *C) {
+	captcha := ReCAPTCHA{
+		client:  mockBodyClient(`{"success": true, "hostname": "example.com"}`),
+		horloge: mockClockAge(time.Hour),
+	}
+	// the time checks are skipped
+	options := VerifyOption{ResponseTime: time.Second, MinChallengeAge: time.Second, MaxChallengeAge: time.Minute}
+	c.Check(captcha.VerifyWithOptions("mycode", options), IsNil)
+
+	captcha.RequireChallengeTS = true
+	err := captcha.VerifyWithOptions("mycode", VerifyOption{})
+	c.Check(err, ErrorMatches, "received response without challenge_ts, while expecting the challenge timestamp")
+	c.Check(errors.Is(err, ErrMissingChallengeTS), Equals, true)
+	c.Check(ErrorReason(err), Equals, "missing_challenge_ts")
+}
+
+// mockClockAge reports every challenge as solved the given duration ago.
+type mockClockAge time.Duration
+
+func (m mockClockAge) Since(t time.Time) time.Duration {
+	return time.Duration(m)
+}
+
+func (s *ReCaptchaSuite) TestVerifyWithChallengeAgeOptions(c *C) {
+	captcha := ReCAPTCHA{
+		client:  &mockSuccessClientNoOptions{},
+		horloge: mockClockAge(-time.Minute),
+	}
+	options := VerifyOption{MinChallengeAge: 2 * time.Second, MaxChallengeAge: 10 * time.Second}
+	err := captcha.VerifyWithOptions("mycode", VerifyOption{})
+	c.Check(err, IsNil)
+
+	captcha.horloge = mockClockAge(-10 * time.Minute)
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{})
+	c.Check(err, ErrorMatches, "challenge solved '600.000000s' in the future, while expecting maximum clock skew '300.000000s'")
+	c.Check(err.(*Error).kind, Equals, kindFutureChallenge)
+	captcha.ClockSkew = 15 * time.Minute
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{}), IsNil)
+
+	captcha.horloge = mockClockAge(time.Second)
+	err = captcha.VerifyWithOptions("mycode", options)
+	c.Check(err, ErrorMatches, "challenge solved '1.000000s' ago, while expecting minimum '2.000000s'")
+	c.Check(err.(*Error).kind, Equals, kindChallengeTooFresh)
+
+	captcha.horloge = mockClockAge(5 * time.Second)
+	c.Check(captcha.VerifyWithOptions("mycode", options), IsNil)
+
+	captcha.horloge = mockClockAge(20 * time.Second)
+	err = captcha.VerifyWithOptions("mycode", options)
+	c.Check(err, ErrorMatches, "challenge solved '20.000000s' ago, while expecting maximum '10.000000s'")
+	c.Check(err.(*Error).kind, Equals, kindChallengeTooOld)
+	c.Check(err.(*Error).ResponseBody, Not(Equals), "")
+
+	captcha.MaxChallengeAge = DefaultMaxChallengeAge
+	captcha.horloge = mockClockAge(3 * time.Minute)
+	err = captcha.VerifyWithOptions("mycode", VerifyOption{})
+	c.Check(err, ErrorMatches, "challenge solved '180.000000s' ago, while expecting maximum '120.000000s'")
+	c.Check(errors.Is(err, ErrChallengeTooOld), Equals, true)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{MaxChallengeAge: 5 * time.Minute}), IsNil)
 }
 
 type mockSuccessClientWithApkPackageNameOption struct{}
@@ -470,6 +586,37 @@ func (s *ReCaptchaSuite) TestV3VerifyWithThresholdOption(c *C) {
 	c.Assert(err, IsNil)
 }
 
+type errNightRisk struct{ score float32 }
+
+func (e errNightRisk) Error() string { return fmt.Sprintf("score '%f' too low at night", e.score) }
+
+func (s *ReCaptchaSuite) TestScoreEvaluator(c *C) {
+	var evaluated []Response
+	captcha := ReCAPTCHA{
+		client:  &mockV3FailClientWithThresholdOption{},
+		Version: V3,
+		ScoreEvaluator: func(response Response) error {
+			evaluated = append(evaluated, response)
+			if response.Score < 0.2 {
+				return errNightRisk{score: response.Score}
+			}
+			return nil
+		},
+	}
+	// the evaluator replaces the thresholds
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Threshold: 0.6}), IsNil)
+	c.Assert(evaluated, HasLen, 1)
+	c.Check(evaluated[0].Score, Equals, float32(0.23))
+
+	captcha.ScoreEvaluator = func(response Response) error { return errNightRisk{score: response.Score} }
+	err := captcha.Verify("mycode")
+	c.Check(err, ErrorMatches, "score '0.230000' too low at night")
+	c.Check(errors.Is(err, ErrScoreTooLow), Equals, true)
+	var nightErr errNightRisk
+	c.Check(errors.As(err, &nightErr), Equals, true)
+	c.Check(ErrorReason(err), Equals, "score_too_low")
+}
+
 type mockV2SuccessClientWithV3IgnoreOptions struct{}
 
 func (*mockV2SuccessClientWithV3IgnoreOptions) PostForm(url string, formValues url.Values) (resp *http.Response, err error) {
@@ -498,3 +645,196 @@ func (s *ReCaptchaSuite) TestRealClock(c *C) {
 	clock := &realClock{}
 	c.Check(clock.Since(time.Now()), FitsTypeOf, time.Duration(0))
 }
+
+func (s *ReCaptchaSuite) TestJSONBody(c *C) {
+	var request map[string]string
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"success": true, "hostname": "test.com"}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("my secret", V2, 10*time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	captcha.JSONBody = &JSONFields{Response: "token", RemoteIP: "client_ip"}
+
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{RemoteIP: "127.0.0.1"}), IsNil)
+	c.Check(contentType, Equals, "application/json")
+	c.Check(request, DeepEquals, map[string]string{"secret": "my secret", "token": "mycode", "client_ip": "127.0.0.1"})
+
+	c.Check(captcha.Verify("mycode"), IsNil)
+	c.Check(request, DeepEquals, map[string]string{"secret": "my secret", "token": "mycode"})
+
+	captcha.client = &mockSuccessClientNoOptions{}
+	err = captcha.Verify("mycode")
+	c.Check(err, ErrorMatches, "error posting to recaptcha endpoint: 'client cannot post JSON requests'")
+	c.Check(err.(*Error).RequestError, Equals, true)
+}
+
+func (s *ReCaptchaSuite) TestVerifyWithContext(c *C) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	captcha, err := NewReCAPTCHA("my secret", V2, 10*time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = captcha.VerifyWithContext(ctx, "mycode")
+	c.Assert(err, NotNil)
+	c.Check(err, ErrorMatches, "error posting to recaptcha endpoint: .*")
+	c.Check(err.(*Error).RequestError, Equals, true)
+
+	// custom clients only check the context before posting
+	captcha.client = &mockSuccessClientNoOptions{}
+	c.Check(captcha.VerifyWithOptionsContext(context.Background(), "mycode", VerifyOption{}), IsNil)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Check(captcha.VerifyWithOptionsContext(canceled, "mycode", VerifyOption{}), ErrorMatches, "error posting to recaptcha endpoint: 'context canceled'")
+
+	response, err := VerifyContext(canceled, &captcha, "mycode", VerifyOption{})
+	c.Check(err, ErrorMatches, "error posting to recaptcha endpoint: 'context canceled'")
+	c.Check(response.Success, Equals, false)
+	response, err = VerifyContext(canceled, mockVerifier(func(string, VerifyOption) error { return nil }), "mycode", VerifyOption{})
+	c.Check(err, IsNil)
+}
+
+func (s *ReCaptchaSuite) TestObservers(c *C) {
+	var events []Event
+	cache, _ := NewReplayCache(NewMemoryStore())
+	captcha := ReCAPTCHA{
+		client:      mockBodyClient(loginResponseBody),
+		Version:     V3,
+		ReplayCache: cache,
+		Observers:   []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login", RemoteIP: "10.0.0.1"}), IsNil)
+	c.Check(captcha.Verify("mycode"), NotNil)
+	result := captcha.VerifyPooled("othercode", VerifyOption{Threshold: 0.7})
+	result.Release()
+
+	c.Assert(events, HasLen, 3)
+	c.Check(events[0].Kind, Equals, EventVerification)
+	c.Check(events[0].Action, Equals, "login")
+	c.Check(events[0].RemoteIP, Equals, "10.0.0.1")
+	c.Check(events[0].Decision, Equals, Allow)
+	c.Check(events[0].Duration > 0, Equals, true)
+	c.Check(ErrorReason(events[1].Err), Equals, "token_replayed")
+	c.Check(events[1].Decision, Equals, Block)
+	c.Check(events[1].Duration, Equals, time.Duration(0))
+	c.Check(ErrorReason(events[2].Err), Equals, "score_too_low")
+	c.Check(events[2].Score, Equals, float32(0.6))
+}
+
+func (s *ReCaptchaSuite) TestMaxResponseSize(c *C) {
+	captcha := ReCAPTCHA{client: mockBodyClient(`{"success": true}`)}
+	c.Check(captcha.Verify("mycode"), IsNil)
+	captcha.MaxResponseSize = 17
+	c.Check(captcha.Verify("mycode"), IsNil)
+	captcha.MaxResponseSize = 16
+	err := captcha.Verify("mycode")
+	c.Check(err, ErrorMatches, "response body larger than '16' bytes")
+	c.Check(errors.Is(err, ErrRequestFailed), Equals, true)
+
+	captcha = ReCAPTCHA{client: mockBodyClient(`{"success": true, "hostname": "` + strings.Repeat("a", int(DefaultMaxResponseSize)) + `"}`)}
+	c.Check(captcha.Verify("mycode"), ErrorMatches, "response body larger than '65536' bytes")
+}
+
+func (s *ReCaptchaSuite) TestWithScoreObserver(c *C) {
+	var scores []string
+	cache, _ := NewReplayCache(NewMemoryStore())
+	captcha := ReCAPTCHA{
+		client:      mockBodyClient(loginResponseBody),
+		Version:     V3,
+		ReplayCache: cache,
+	}
+	c.Check(captcha.WithScoreObserver(func(action string, score float32, success bool) {
+		scores = append(scores, fmt.Sprintf("%s %.1f %t", action, score, success))
+	}), Equals, &captcha)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"}), IsNil)
+	c.Check(captcha.Verify("mycode"), NotNil)
+	c.Check(captcha.VerifyWithOptions("othercode", VerifyOption{Threshold: 0.7}), NotNil)
+	c.Check(scores, DeepEquals, []string{"login 0.6 true", "login 0.6 false"})
+}
+
+type tracerKey struct{}
+
+// mockTracer records the verifications ended, tagging their context.
+type mockTracer struct {
+	ended []string
+}
+
+func (t *mockTracer) Start(ctx context.Context, version VERSION, options VerifyOption) (context.Context, func(Response, error)) {
+	return context.WithValue(ctx, tracerKey{}, options.Action), func(response Response, err error) {
+		t.ended = append(t.ended, fmt.Sprintf("%s %s %v", options.Action, response.Action, err))
+	}
+}
+
+func (s *ReCaptchaSuite) TestTracer(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": true, "score": 0.6, "action": "login"}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("my secret", V3, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	tracer := &mockTracer{}
+	captcha.Tracer = tracer
+	var traced []interface{}
+	c.Assert(captcha.Decorate(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			traced = append(traced, req.Context().Value(tracerKey{}))
+			return next.RoundTrip(req)
+		})
+	}), IsNil)
+
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "login"}), IsNil)
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{Action: "signup"}), NotNil)
+	result := captcha.VerifyPooled("mycode", VerifyOption{Action: "login"})
+	result.Release()
+	c.Check(traced, DeepEquals, []interface{}{"login", "signup", "login"})
+	c.Check(tracer.ended, DeepEquals, []string{
+		"login login <nil>",
+		"signup login invalid response action 'login', while expecting 'signup'",
+		"login login <nil>",
+	})
+}
+
+func (s *ReCaptchaSuite) TestDryRun(c *C) {
+	var events []Event
+	tracer := &mockTracer{}
+	captcha := ReCAPTCHA{
+		client:    &mockV3FailClientWithThresholdOption{},
+		Version:   V3,
+		DryRun:    true,
+		Tracer:    tracer,
+		Observers: []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{}), IsNil)
+	result := captcha.VerifyPooled("mycode", VerifyOption{Threshold: 0.6})
+	c.Check(result.Err, IsNil)
+	c.Check(result.Response.Score, Equals, float32(0.23))
+	result.Release()
+	c.Assert(events, HasLen, 2)
+	c.Check(events[0].Err, ErrorMatches, "received score '0.230000', while expecting minimum '0.500000'")
+	c.Check(events[1].Decision, Equals, Block)
+	c.Check(tracer.ended, DeepEquals, []string{
+		"  received score '0.230000', while expecting minimum '0.500000'",
+		"  received score '0.230000', while expecting minimum '0.600000'",
+	})
+
+	captcha.DryRun = false
+	c.Check(captcha.VerifyWithOptions("mycode", VerifyOption{}), NotNil)
+}
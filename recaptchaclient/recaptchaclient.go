@@ -0,0 +1,134 @@
+// Package recaptchaclient is a typed client of the recaptchad verification service, implementing
+// recaptcha.Verifier so applications switch between in-process and remote verification without
+// other changes.
+package recaptchaclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// VerifyRequest body of recaptchad POST /verify requests, options left blank use the daemon defaults.
+type VerifyRequest struct {
+	Response       string            `json:"response"`
+	Action         string            `json:"action,omitempty"`
+	Threshold      float32           `json:"threshold,omitempty"`
+	Hostname       string            `json:"hostname,omitempty"`
+	ApkPackageName string            `json:"apk_package_name,omitempty"`
+	RemoteIP       string            `json:"remote_ip,omitempty"`
+	Context        map[string]string `json:"context,omitempty"`
+}
+
+// VerifyResponse body of recaptchad POST /verify replies, failed verifications are reported with a 200.
+type VerifyResponse struct {
+	Success  bool               `json:"success"`
+	Decision string             `json:"decision"`
+	Reasons  []string           `json:"reasons,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	Result   recaptcha.Response `json:"result"`
+}
+
+// Client recaptchad client. Options other than Action, Threshold, Hostname, ApkPackageName, RemoteIP
+// and Context are configured on the daemon.
+type Client struct {
+	// URL base URL of the daemon, e.g. http://recaptchad:8080.
+	URL        string
+	HTTPClient *http.Client
+}
+
+var (
+	_ recaptcha.Verifier        = (*Client)(nil)
+	_ recaptcha.ContextVerifier = (*Client)(nil)
+)
+
+// New new Client of the daemon at url, using a client with timeout
+func New(url string, timeout time.Duration) (*Client, error) {
+	if url == "" {
+		return nil, fmt.Errorf("recaptchad URL cannot be blank")
+	}
+	return &Client{URL: strings.TrimSuffix(url, "/"), HTTPClient: &http.Client{Timeout: timeout}}, nil
+}
+
+// Verify returns `nil` if the daemon verified the challenge response successfully
+func (c *Client) Verify(challengeResponse string) error {
+	_, err := c.VerifyWithOptionsResponse(challengeResponse, recaptcha.VerifyOption{})
+	return err
+}
+
+// VerifyWithOptions same as Verify, passing the options to the daemon
+func (c *Client) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := c.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the decoded verification response
+func (c *Client) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return c.VerifyWithOptionsResponseContext(context.Background(), challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as VerifyWithOptionsResponse but the request to the daemon is
+// canceled when the context is done
+func (c *Client) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	resp, err := c.VerifyRequest(ctx, VerifyRequest{
+		Response:       challengeResponse,
+		Action:         options.Action,
+		Threshold:      options.Threshold,
+		Hostname:       options.Hostname,
+		ApkPackageName: options.ApkPackageName,
+		RemoteIP:       options.RemoteIP,
+		Context:        options.Context,
+	})
+	if err != nil {
+		return recaptcha.Response{}, err
+	}
+	if !resp.Success {
+		return resp.Result, fmt.Errorf("%s", resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// VerifyRequest posts the request to the daemon and returns its reply, failed verifications are
+// reported in the reply, errors are reserved to failed requests.
+func (c *Client) VerifyRequest(ctx context.Context, req VerifyRequest) (VerifyResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return VerifyResponse{}, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, c.URL+"/verify", bytes.NewReader(body))
+	if err != nil {
+		return VerifyResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := c.client().Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return VerifyResponse{}, fmt.Errorf("error posting to recaptchad: '%s'", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return VerifyResponse{}, fmt.Errorf("couldn't read response body: '%s'", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return VerifyResponse{}, fmt.Errorf("recaptchad replied '%s': %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	var verifyResp VerifyResponse
+	if err := json.Unmarshal(respBody, &verifyResp); err != nil {
+		return VerifyResponse{}, fmt.Errorf("invalid response body json: '%s'", err)
+	}
+	return verifyResp, nil
+}
+
+func (c *Client) client() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
@@ -0,0 +1,81 @@
+package recaptchaclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ClientSuite struct {
+	server   *httptest.Server
+	requests []VerifyRequest
+}
+
+var _ = Suite(&ClientSuite{})
+
+func (s *ClientSuite) SetUpTest(c *C) {
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/verify")
+		var req VerifyRequest
+		c.Check(json.NewDecoder(r.Body).Decode(&req), IsNil)
+		s.requests = append(s.requests, req)
+		switch req.Response {
+		case "valid":
+			json.NewEncoder(w).Encode(VerifyResponse{Success: true, Decision: "allow", Result: recaptcha.Response{Success: true, Score: 0.9, Action: req.Action}})
+		case "":
+			http.Error(w, "challenge response cannot be blank", http.StatusBadRequest)
+		default:
+			json.NewEncoder(w).Encode(VerifyResponse{Decision: "block", Error: "remote error codes: [invalid-input-response]", Result: recaptcha.Response{ErrorCodes: []string{"invalid-input-response"}}})
+		}
+	}))
+}
+
+func (s *ClientSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *ClientSuite) TestNew(c *C) {
+	_, err := New("", time.Second)
+	c.Check(err, ErrorMatches, "recaptchad URL cannot be blank")
+	client, err := New("http://recaptchad:8080/", time.Second)
+	c.Assert(err, IsNil)
+	c.Check(client.URL, Equals, "http://recaptchad:8080")
+}
+
+func (s *ClientSuite) TestVerifier(c *C) {
+	client, _ := New(s.server.URL, time.Second)
+	response, err := client.VerifyWithOptionsResponse("valid", recaptcha.VerifyOption{Action: "login", RemoteIP: "203.0.113.1", Context: map[string]string{"form": "login"}})
+	c.Assert(err, IsNil)
+	c.Check(response.Score, Equals, float32(0.9))
+	c.Check(response.Action, Equals, "login")
+	c.Check(s.requests[0], DeepEquals, VerifyRequest{Response: "valid", Action: "login", RemoteIP: "203.0.113.1", Context: map[string]string{"form": "login"}})
+
+	response, err = client.VerifyWithOptionsResponse("invalid", recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, `remote error codes: \[invalid-input-response\]`)
+	c.Check(response.ErrorCodes, DeepEquals, []string{"invalid-input-response"})
+
+	c.Check(client.Verify(""), ErrorMatches, "recaptchad replied '400 Bad Request': challenge response cannot be blank")
+}
+
+func (s *ClientSuite) TestVerifyRequest(c *C) {
+	client, _ := New(s.server.URL, time.Second)
+	resp, err := client.VerifyRequest(context.Background(), VerifyRequest{Response: "invalid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Success, Equals, false)
+	c.Check(resp.Decision, Equals, "block")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.VerifyRequest(ctx, VerifyRequest{Response: "valid"})
+	c.Check(err, ErrorMatches, "error posting to recaptchad.*context canceled.*")
+}
@@ -0,0 +1,105 @@
+// Package recaptchagin adapts recaptcha verification to the Gin web framework, setting the
+// verification result and score in the gin.Context for the next handlers.
+package recaptchagin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+const (
+	// ResultKey gin.Context key of the Verification.
+	ResultKey = "recaptcha.result"
+	// ScoreKey gin.Context key of the V3 score, a float32.
+	ScoreKey = "recaptcha.score"
+)
+
+// Verification verification result set in the gin.Context of every verified request.
+type Verification struct {
+	Response recaptcha.Response
+	Err      error
+	Outcome  recaptcha.Outcome
+}
+
+// Middleware Gin middleware verifying the challenge response of the requests, aborting the rejected
+// ones and only calling the next handlers of allowed and quarantined requests.
+type Middleware struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the request when blank.
+	Options recaptcha.VerifyOption
+	// Header request header holding the challenge response, recaptcha.DefaultTokenHeader when blank.
+	// Checked before Field so API clients don't have to post forms.
+	Header string
+	// Field form field holding the challenge response, recaptcha.DefaultResponseField when blank.
+	Field string
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+	// Denied handles the rejected requests, which are aborted with a 403 JSON error when nil. The
+	// result is available through ResultFromContext, Denied is expected to abort the request.
+	Denied gin.HandlerFunc
+}
+
+// New new Middleware verifying requests with verifier using options
+func New(verifier recaptcha.Verifier, options recaptcha.VerifyOption) *Middleware {
+	return &Middleware{Verifier: verifier, Options: options}
+}
+
+// Handler returns the gin.HandlerFunc verifying the requests, to pass to router.Use or a route.
+func (m *Middleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		options := m.Options
+		if options.RemoteIP == "" {
+			options.RemoteIP = c.ClientIP()
+		}
+		ctx := c.Request.Context()
+		response, err := recaptcha.VerifyContext(ctx, m.Verifier, m.token(c), options)
+		outcome := recaptcha.DecideWith(ctx, m.Decider, m.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+		c.Set(ResultKey, Verification{Response: response, Err: err, Outcome: outcome})
+		c.Set(ScoreKey, response.Score)
+
+		if outcome.Decision != recaptcha.Allow && outcome.Decision != recaptcha.Quarantine {
+			if m.Denied != nil {
+				m.Denied(c)
+				c.Abort()
+				return
+			}
+			body := gin.H{"decision": outcome.Decision.String()}
+			if err != nil {
+				body["error"] = err.Error()
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, body)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ResultFromContext returns the verification result of a request verified by Middleware
+func ResultFromContext(c *gin.Context) (Verification, bool) {
+	value, ok := c.Get(ResultKey)
+	if !ok {
+		return Verification{}, false
+	}
+	result, ok := value.(Verification)
+	return result, ok
+}
+
+func (m *Middleware) token(c *gin.Context) string {
+	header := m.Header
+	if header == "" {
+		header = recaptcha.DefaultTokenHeader
+	}
+	if token := c.GetHeader(header); token != "" {
+		return token
+	}
+	field := m.Field
+	if field == "" {
+		field = recaptcha.DefaultResponseField
+	}
+	return c.PostForm(field)
+}
@@ -0,0 +1,81 @@
+package recaptchagin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type GinSuite struct{}
+
+var _ = Suite(&GinSuite{})
+
+// mockVerifier returns a score of 0.6 for the "valid" challenge response and fails otherwise.
+type mockVerifier struct{}
+
+func (mockVerifier) Verify(challengeResponse string) error {
+	return mockVerifier{}.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := mockVerifier{}.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	if challengeResponse != "valid" {
+		return recaptcha.Response{}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return recaptcha.Response{Success: true, Score: 0.6}, nil
+}
+
+func post(router *gin.Engine, challengeResponse, header string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{recaptcha.DefaultResponseField: {challengeResponse}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if header != "" {
+		r.Header.Set(recaptcha.DefaultTokenHeader, header)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func (s *GinSuite) TestMiddleware(c *C) {
+	gin.SetMode(gin.TestMode)
+	middleware := New(mockVerifier{}, recaptcha.VerifyOption{})
+	router := gin.New()
+	router.POST("/login", middleware.Handler(), func(ctx *gin.Context) {
+		result, ok := ResultFromContext(ctx)
+		c.Check(ok, Equals, true)
+		ctx.String(http.StatusOK, "%.1f %.1f %s", ctx.MustGet(ScoreKey).(float32), result.Response.Score, result.Outcome.Decision)
+	})
+
+	w := post(router, "valid", "")
+	c.Check(w.Code, Equals, http.StatusOK)
+	c.Check(w.Body.String(), Equals, "0.6 0.6 allow")
+	c.Check(post(router, "invalid", "valid").Code, Equals, http.StatusOK)
+
+	w = post(router, "invalid", "")
+	c.Check(w.Code, Equals, http.StatusForbidden)
+	c.Check(w.Body.String(), Equals, `{"decision":"block","error":"remote error codes: [invalid-input-response]"}`)
+	c.Check(post(router, "valid", "invalid").Code, Equals, http.StatusForbidden)
+
+	middleware.Policy = &recaptcha.Policy{ChallengeBelow: 0.7}
+	middleware.Denied = func(ctx *gin.Context) {
+		result, _ := ResultFromContext(ctx)
+		ctx.String(http.StatusUnauthorized, result.Outcome.Decision.String())
+	}
+	w = post(router, "valid", "")
+	c.Check(w.Code, Equals, http.StatusUnauthorized)
+	c.Check(w.Body.String(), Equals, "challenge")
+}
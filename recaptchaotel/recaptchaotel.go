@@ -0,0 +1,93 @@
+// Package recaptchaotel traces the verifications with OpenTelemetry, a client span per verification
+// carrying the version, expected and received action, hostname, score bucket and error codes, so the
+// captcha latency shows up in the traces of the login or signup flows.
+package recaptchaotel
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// SpanName name of the verification spans.
+const SpanName = "recaptcha.verify"
+
+const instrumentationName = "gopkg.in/ezzarghili/recaptcha-go.v4/recaptchaotel"
+
+// Span attribute keys.
+const (
+	VersionKey        = attribute.Key("recaptcha.version")
+	ExpectedActionKey = attribute.Key("recaptcha.expected_action")
+	ActionKey         = attribute.Key("recaptcha.action")
+	HostnameKey       = attribute.Key("recaptcha.hostname")
+	ScoreBucketKey    = attribute.Key("recaptcha.score_bucket")
+	ErrorCodesKey     = attribute.Key("recaptcha.error_codes")
+	ErrorReasonKey    = attribute.Key("recaptcha.error_reason")
+)
+
+// Tracer recaptcha.Tracer starting OpenTelemetry spans, set it as ReCAPTCHA.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ recaptcha.Tracer = (*Tracer)(nil)
+
+// New new Tracer using provider, the global tracer provider when nil
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+// Start starts the span of a verification, ended with its result.
+func (t *Tracer) Start(ctx context.Context, version recaptcha.VERSION, options recaptcha.VerifyOption) (context.Context, func(recaptcha.Response, error)) {
+	attributes := []attribute.KeyValue{VersionKey.String(versionName(version))}
+	if options.Action != "" {
+		attributes = append(attributes, ExpectedActionKey.String(options.Action))
+	}
+	ctx, span := t.tracer.Start(ctx, SpanName, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return ctx, func(response recaptcha.Response, err error) {
+		defer span.End()
+		if response.Action != "" {
+			span.SetAttributes(ActionKey.String(response.Action))
+		}
+		if response.Hostname != "" {
+			span.SetAttributes(HostnameKey.String(response.Hostname))
+		}
+		if version == recaptcha.V3 && response.Success {
+			span.SetAttributes(ScoreBucketKey.String(scoreBucket(response.Score)))
+		}
+		errorCodes := response.ErrorCodes
+		if verr, ok := err.(*recaptcha.Error); ok && len(verr.ErrorCodes) > 0 {
+			errorCodes = verr.ErrorCodes
+		}
+		if len(errorCodes) > 0 {
+			span.SetAttributes(ErrorCodesKey.StringSlice(errorCodes))
+		}
+		if err != nil {
+			span.SetAttributes(ErrorReasonKey.String(recaptcha.ErrorReason(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
+
+func versionName(version recaptcha.VERSION) string {
+	if version == recaptcha.V3 {
+		return "v3"
+	}
+	return "v2"
+}
+
+// scoreBucket returns the tenth of the score, e.g. "0.7" for scores from 0.7 included to 0.8 excluded.
+func scoreBucket(score float32) string {
+	return fmt.Sprintf("%.1f", math.Floor(float64(score)*10+1e-6)/10)
+}
@@ -0,0 +1,85 @@
+package recaptchaotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type OtelSuite struct{}
+
+var _ = Suite(&OtelSuite{})
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func (s *OtelSuite) TestTracer(c *C) {
+	body := `{"success": true, "score": 0.7, "action": "login", "hostname": "example.com"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	captcha, err := recaptcha.NewReCAPTCHA("secret", recaptcha.V3, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	captcha.Tracer = New(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "login")
+	c.Check(captcha.VerifyWithOptionsContext(ctx, "token", recaptcha.VerifyOption{Action: "login"}), IsNil)
+	parent.End()
+	body = `{"success": false, "error-codes": ["timeout-or-duplicate"]}`
+	captcha.Version = recaptcha.V2
+	c.Check(captcha.VerifyWithOptions("token", recaptcha.VerifyOption{}), NotNil)
+
+	spans := recorder.Ended()
+	c.Assert(spans, HasLen, 3)
+	span := spans[0]
+	c.Check(span.Name(), Equals, SpanName)
+	c.Check(span.SpanKind(), Equals, trace.SpanKindClient)
+	c.Check(span.Parent().SpanID(), Equals, parent.SpanContext().SpanID())
+	c.Check(span.Status().Code, Equals, codes.Unset)
+	c.Check(attributes(span), DeepEquals, map[attribute.Key]attribute.Value{
+		VersionKey:        attribute.StringValue("v3"),
+		ExpectedActionKey: attribute.StringValue("login"),
+		ActionKey:         attribute.StringValue("login"),
+		HostnameKey:       attribute.StringValue("example.com"),
+		ScoreBucketKey:    attribute.StringValue("0.7"),
+	})
+
+	span = spans[2]
+	c.Check(span.Parent().IsValid(), Equals, false)
+	c.Check(span.Status().Code, Equals, codes.Error)
+	c.Check(attributes(span), DeepEquals, map[attribute.Key]attribute.Value{
+		VersionKey:     attribute.StringValue("v2"),
+		ErrorCodesKey:  attribute.StringSliceValue([]string{"timeout-or-duplicate"}),
+		ErrorReasonKey: attribute.StringValue("remote_errors"),
+	})
+}
+
+func (s *OtelSuite) TestScoreBucket(c *C) {
+	c.Check(scoreBucket(0), Equals, "0.0")
+	c.Check(scoreBucket(0.3), Equals, "0.3")
+	c.Check(scoreBucket(0.69), Equals, "0.6")
+	c.Check(scoreBucket(1), Equals, "1.0")
+}
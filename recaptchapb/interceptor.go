@@ -0,0 +1,135 @@
+package recaptchapb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// DefaultTokenKey incoming metadata key holding the challenge response checked by Interceptor, the
+// X-Recaptcha-Token header of grpc-web requests.
+const DefaultTokenKey = "x-recaptcha-token"
+
+// ErrorReason reason of the errdetails.ErrorInfo attached to the PermissionDenied statuses of Interceptor.
+const ErrorReason = "RECAPTCHA_VERIFICATION_FAILED"
+
+type interceptorContextKey struct{}
+
+// Verification verification result of a call accepted by Interceptor.
+type Verification struct {
+	Response recaptcha.Response
+	Outcome  recaptcha.Outcome
+}
+
+// Interceptor gRPC server interceptor verifying the challenge response sent in the metadata of the
+// calls, e.g. by browsers through grpc-web. Rejected calls fail with codes.PermissionDenied, the
+// status details holding an errdetails.ErrorInfo with the error codes and the decision.
+type Interceptor struct {
+	Verifier recaptcha.Verifier
+	// Options used for every verification, RemoteIP is set from the peer address when blank.
+	Options recaptcha.VerifyOption
+	// Key metadata key holding the challenge response, DefaultTokenKey when blank.
+	Key string
+	// Skip when set reports the full method names (e.g. health checks) called without verification.
+	Skip func(fullMethod string) bool
+	// Policy when set maps verification results to decisions, otherwise failed verifications are blocked.
+	Policy *recaptcha.Policy
+	// Decider when set takes the decisions instead of Policy.
+	Decider recaptcha.Decider
+}
+
+// NewInterceptor new Interceptor verifying challenge responses with verifier using options
+func NewInterceptor(verifier recaptcha.Verifier, options recaptcha.VerifyOption) (*Interceptor, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("interceptor verifier cannot be nil")
+	}
+	return &Interceptor{Verifier: verifier, Options: options}, nil
+}
+
+// Unary grpc.UnaryServerInterceptor verifying the calls, to pass to grpc.UnaryInterceptor or
+// grpc.ChainUnaryInterceptor.
+func (i *Interceptor) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if i.Skip != nil && i.Skip(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := i.verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+var _ grpc.UnaryServerInterceptor = (*Interceptor)(nil).Unary
+
+// VerificationFromContext returns the verification result of a call accepted by Interceptor
+func VerificationFromContext(ctx context.Context) (Verification, bool) {
+	verification, ok := ctx.Value(interceptorContextKey{}).(Verification)
+	return verification, ok
+}
+
+func (i *Interceptor) verify(ctx context.Context) (context.Context, error) {
+	options := i.Options
+	if options.RemoteIP == "" {
+		options.RemoteIP = peerIP(ctx)
+	}
+	response, err := recaptcha.VerifyContext(ctx, i.Verifier, i.token(ctx), options)
+	outcome := recaptcha.DecideWith(ctx, i.Decider, i.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+	if outcome.Decision == recaptcha.Allow || outcome.Decision == recaptcha.Quarantine {
+		return context.WithValue(ctx, interceptorContextKey{}, Verification{Response: response, Outcome: outcome}), nil
+	}
+
+	errorCodes := response.ErrorCodes
+	if verr, ok := err.(*recaptcha.Error); ok && len(verr.ErrorCodes) > 0 {
+		errorCodes = verr.ErrorCodes
+	}
+	msg := "recaptcha verification failed"
+	if err != nil {
+		msg = err.Error()
+	}
+	st, derr := status.New(codes.PermissionDenied, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: ErrorReason,
+		Domain: "recaptcha",
+		Metadata: map[string]string{
+			"error_codes": strings.Join(errorCodes, ","),
+			"decision":    outcome.Decision.String(),
+		},
+	})
+	if derr != nil {
+		return nil, status.Error(codes.PermissionDenied, msg)
+	}
+	return nil, st.Err()
+}
+
+func (i *Interceptor) token(ctx context.Context) string {
+	key := i.Key
+	if key == "" {
+		key = DefaultTokenKey
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
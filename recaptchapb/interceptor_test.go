@@ -0,0 +1,78 @@
+package recaptchapb
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+type InterceptorSuite struct{}
+
+var _ = Suite(&InterceptorSuite{})
+
+func call(interceptor *Interceptor, method string, md metadata.MD) (interface{}, error) {
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242}})
+	return interceptor.Unary(ctx, "request", &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		verification, ok := VerificationFromContext(ctx)
+		if !ok {
+			return "unverified", nil
+		}
+		return verification.Outcome.Decision.String(), nil
+	})
+}
+
+func (s *InterceptorSuite) TestNewInterceptor(c *C) {
+	_, err := NewInterceptor(nil, recaptcha.VerifyOption{})
+	c.Check(err, ErrorMatches, "interceptor verifier cannot be nil")
+}
+
+func (s *InterceptorSuite) TestUnary(c *C) {
+	verifier := &mockVerifier{response: recaptcha.Response{Success: true, Score: 0.6}}
+	interceptor, err := NewInterceptor(verifier, recaptcha.VerifyOption{Action: "login"})
+	c.Assert(err, IsNil)
+
+	resp, err := call(interceptor, "/app.Auth/Login", metadata.Pairs(DefaultTokenKey, "valid"))
+	c.Check(err, IsNil)
+	c.Check(resp, Equals, "allow")
+	c.Check(verifier.options.Action, Equals, "login")
+	c.Check(verifier.options.RemoteIP, Equals, "10.0.0.1")
+
+	_, err = call(interceptor, "/app.Auth/Login", metadata.Pairs(DefaultTokenKey, "invalid"))
+	st := status.Convert(err)
+	c.Check(st.Code(), Equals, codes.PermissionDenied)
+	c.Check(st.Message(), Equals, "remote error codes: [invalid-input-response]")
+	c.Assert(st.Details(), HasLen, 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	c.Assert(ok, Equals, true)
+	c.Check(info.GetReason(), Equals, ErrorReason)
+	c.Check(info.GetMetadata(), DeepEquals, map[string]string{"error_codes": "invalid-input-response", "decision": "block"})
+
+	_, err = call(interceptor, "/app.Auth/Login", nil)
+	c.Check(status.Code(err), Equals, codes.PermissionDenied)
+
+	interceptor.Key = "captcha"
+	resp, err = call(interceptor, "/app.Auth/Login", metadata.Pairs("captcha", "valid"))
+	c.Check(err, IsNil)
+	c.Check(resp, Equals, "allow")
+
+	interceptor.Skip = func(method string) bool { return method == "/grpc.health.v1.Health/Check" }
+	resp, err = call(interceptor, "/grpc.health.v1.Health/Check", nil)
+	c.Check(err, IsNil)
+	c.Check(resp, Equals, "unverified")
+
+	interceptor.Policy = &recaptcha.Policy{ChallengeBelow: 0.7}
+	_, err = call(interceptor, "/app.Auth/Login", metadata.Pairs("captcha", "valid"))
+	st = status.Convert(err)
+	c.Check(st.Code(), Equals, codes.PermissionDenied)
+	c.Check(st.Message(), Equals, "recaptcha verification failed")
+}
@@ -0,0 +1,632 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: recaptcha.proto
+
+package recaptchapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Decision int32
+
+const (
+	Decision_DECISION_UNSPECIFIED Decision = 0
+	Decision_DECISION_ALLOW       Decision = 1
+	Decision_DECISION_CHALLENGE   Decision = 2
+	Decision_DECISION_BLOCK       Decision = 3
+	Decision_DECISION_QUARANTINE  Decision = 4
+)
+
+// Enum value maps for Decision.
+var (
+	Decision_name = map[int32]string{
+		0: "DECISION_UNSPECIFIED",
+		1: "DECISION_ALLOW",
+		2: "DECISION_CHALLENGE",
+		3: "DECISION_BLOCK",
+		4: "DECISION_QUARANTINE",
+	}
+	Decision_value = map[string]int32{
+		"DECISION_UNSPECIFIED": 0,
+		"DECISION_ALLOW":       1,
+		"DECISION_CHALLENGE":   2,
+		"DECISION_BLOCK":       3,
+		"DECISION_QUARANTINE":  4,
+	}
+)
+
+func (x Decision) Enum() *Decision {
+	p := new(Decision)
+	*p = x
+	return p
+}
+
+func (x Decision) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Decision) Descriptor() protoreflect.EnumDescriptor {
+	return file_recaptcha_proto_enumTypes[0].Descriptor()
+}
+
+func (Decision) Type() protoreflect.EnumType {
+	return &file_recaptcha_proto_enumTypes[0]
+}
+
+func (x Decision) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Decision.Descriptor instead.
+func (Decision) EnumDescriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{0}
+}
+
+// VerifyOptions mirrors recaptcha.VerifyOption.
+type VerifyOptions struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Threshold         float32                `protobuf:"fixed32,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Action            string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	Hostname          string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ApkPackageName    string                 `protobuf:"bytes,4,opt,name=apk_package_name,json=apkPackageName,proto3" json:"apk_package_name,omitempty"`
+	ResponseTimeMs    int64                  `protobuf:"varint,5,opt,name=response_time_ms,json=responseTimeMs,proto3" json:"response_time_ms,omitempty"`
+	RemoteIp          string                 `protobuf:"bytes,6,opt,name=remote_ip,json=remoteIp,proto3" json:"remote_ip,omitempty"`
+	MinChallengeAgeMs int64                  `protobuf:"varint,7,opt,name=min_challenge_age_ms,json=minChallengeAgeMs,proto3" json:"min_challenge_age_ms,omitempty"`
+	MaxChallengeAgeMs int64                  `protobuf:"varint,8,opt,name=max_challenge_age_ms,json=maxChallengeAgeMs,proto3" json:"max_challenge_age_ms,omitempty"`
+	Context           map[string]string      `protobuf:"bytes,9,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *VerifyOptions) Reset() {
+	*x = VerifyOptions{}
+	mi := &file_recaptcha_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyOptions) ProtoMessage() {}
+
+func (x *VerifyOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyOptions.ProtoReflect.Descriptor instead.
+func (*VerifyOptions) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *VerifyOptions) GetThreshold() float32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *VerifyOptions) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *VerifyOptions) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *VerifyOptions) GetApkPackageName() string {
+	if x != nil {
+		return x.ApkPackageName
+	}
+	return ""
+}
+
+func (x *VerifyOptions) GetResponseTimeMs() int64 {
+	if x != nil {
+		return x.ResponseTimeMs
+	}
+	return 0
+}
+
+func (x *VerifyOptions) GetRemoteIp() string {
+	if x != nil {
+		return x.RemoteIp
+	}
+	return ""
+}
+
+func (x *VerifyOptions) GetMinChallengeAgeMs() int64 {
+	if x != nil {
+		return x.MinChallengeAgeMs
+	}
+	return 0
+}
+
+func (x *VerifyOptions) GetMaxChallengeAgeMs() int64 {
+	if x != nil {
+		return x.MaxChallengeAgeMs
+	}
+	return 0
+}
+
+func (x *VerifyOptions) GetContext() map[string]string {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+// VerificationResult decoded verification response returned by recaptcha.
+type VerificationResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	ChallengeTsMs  int64                  `protobuf:"varint,2,opt,name=challenge_ts_ms,json=challengeTsMs,proto3" json:"challenge_ts_ms,omitempty"`
+	Hostname       string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ApkPackageName string                 `protobuf:"bytes,4,opt,name=apk_package_name,json=apkPackageName,proto3" json:"apk_package_name,omitempty"`
+	Action         string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
+	Score          float32                `protobuf:"fixed32,6,opt,name=score,proto3" json:"score,omitempty"`
+	ErrorCodes     []string               `protobuf:"bytes,7,rep,name=error_codes,json=errorCodes,proto3" json:"error_codes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VerificationResult) Reset() {
+	*x = VerificationResult{}
+	mi := &file_recaptcha_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerificationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerificationResult) ProtoMessage() {}
+
+func (x *VerificationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerificationResult.ProtoReflect.Descriptor instead.
+func (*VerificationResult) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VerificationResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *VerificationResult) GetChallengeTsMs() int64 {
+	if x != nil {
+		return x.ChallengeTsMs
+	}
+	return 0
+}
+
+func (x *VerificationResult) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetApkPackageName() string {
+	if x != nil {
+		return x.ApkPackageName
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *VerificationResult) GetErrorCodes() []string {
+	if x != nil {
+		return x.ErrorCodes
+	}
+	return nil
+}
+
+type VerifyRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeResponse string                 `protobuf:"bytes,1,opt,name=challenge_response,json=challengeResponse,proto3" json:"challenge_response,omitempty"`
+	Options           *VerifyOptions         `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_recaptcha_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VerifyRequest) GetChallengeResponse() string {
+	if x != nil {
+		return x.ChallengeResponse
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetOptions() *VerifyOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Success bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// error verification error, empty on success.
+	Error         string              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Result        *VerificationResult `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_recaptcha_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetResult() *VerificationResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type AssessRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeResponse string                 `protobuf:"bytes,1,opt,name=challenge_response,json=challengeResponse,proto3" json:"challenge_response,omitempty"`
+	Options           *VerifyOptions         `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AssessRequest) Reset() {
+	*x = AssessRequest{}
+	mi := &file_recaptcha_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssessRequest) ProtoMessage() {}
+
+func (x *AssessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssessRequest.ProtoReflect.Descriptor instead.
+func (*AssessRequest) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AssessRequest) GetChallengeResponse() string {
+	if x != nil {
+		return x.ChallengeResponse
+	}
+	return ""
+}
+
+func (x *AssessRequest) GetOptions() *VerifyOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type AssessResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Decision Decision               `protobuf:"varint,1,opt,name=decision,proto3,enum=recaptcha.v1.Decision" json:"decision,omitempty"`
+	Reasons  []string               `protobuf:"bytes,2,rep,name=reasons,proto3" json:"reasons,omitempty"`
+	// error verification error, empty on success.
+	Error         string              `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Result        *VerificationResult `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssessResponse) Reset() {
+	*x = AssessResponse{}
+	mi := &file_recaptcha_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssessResponse) ProtoMessage() {}
+
+func (x *AssessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recaptcha_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssessResponse.ProtoReflect.Descriptor instead.
+func (*AssessResponse) Descriptor() ([]byte, []int) {
+	return file_recaptcha_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AssessResponse) GetDecision() Decision {
+	if x != nil {
+		return x.Decision
+	}
+	return Decision_DECISION_UNSPECIFIED
+}
+
+func (x *AssessResponse) GetReasons() []string {
+	if x != nil {
+		return x.Reasons
+	}
+	return nil
+}
+
+func (x *AssessResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AssessResponse) GetResult() *VerificationResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+var File_recaptcha_proto protoreflect.FileDescriptor
+
+const file_recaptcha_proto_rawDesc = "" +
+	"\n" +
+	"\x0frecaptcha.proto\x12\frecaptcha.v1\"\xb4\x03\n" +
+	"\rVerifyOptions\x12\x1c\n" +
+	"\tthreshold\x18\x01 \x01(\x02R\tthreshold\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12(\n" +
+	"\x10apk_package_name\x18\x04 \x01(\tR\x0eapkPackageName\x12(\n" +
+	"\x10response_time_ms\x18\x05 \x01(\x03R\x0eresponseTimeMs\x12\x1b\n" +
+	"\tremote_ip\x18\x06 \x01(\tR\bremoteIp\x12/\n" +
+	"\x14min_challenge_age_ms\x18\a \x01(\x03R\x11minChallengeAgeMs\x12/\n" +
+	"\x14max_challenge_age_ms\x18\b \x01(\x03R\x11maxChallengeAgeMs\x12B\n" +
+	"\acontext\x18\t \x03(\v2(.recaptcha.v1.VerifyOptions.ContextEntryR\acontext\x1a:\n" +
+	"\fContextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xeb\x01\n" +
+	"\x12VerificationResult\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12&\n" +
+	"\x0fchallenge_ts_ms\x18\x02 \x01(\x03R\rchallengeTsMs\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12(\n" +
+	"\x10apk_package_name\x18\x04 \x01(\tR\x0eapkPackageName\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\x02R\x05score\x12\x1f\n" +
+	"\verror_codes\x18\a \x03(\tR\n" +
+	"errorCodes\"u\n" +
+	"\rVerifyRequest\x12-\n" +
+	"\x12challenge_response\x18\x01 \x01(\tR\x11challengeResponse\x125\n" +
+	"\aoptions\x18\x02 \x01(\v2\x1b.recaptcha.v1.VerifyOptionsR\aoptions\"z\n" +
+	"\x0eVerifyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x128\n" +
+	"\x06result\x18\x03 \x01(\v2 .recaptcha.v1.VerificationResultR\x06result\"u\n" +
+	"\rAssessRequest\x12-\n" +
+	"\x12challenge_response\x18\x01 \x01(\tR\x11challengeResponse\x125\n" +
+	"\aoptions\x18\x02 \x01(\v2\x1b.recaptcha.v1.VerifyOptionsR\aoptions\"\xae\x01\n" +
+	"\x0eAssessResponse\x122\n" +
+	"\bdecision\x18\x01 \x01(\x0e2\x16.recaptcha.v1.DecisionR\bdecision\x12\x18\n" +
+	"\areasons\x18\x02 \x03(\tR\areasons\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x128\n" +
+	"\x06result\x18\x04 \x01(\v2 .recaptcha.v1.VerificationResultR\x06result*}\n" +
+	"\bDecision\x12\x18\n" +
+	"\x14DECISION_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eDECISION_ALLOW\x10\x01\x12\x16\n" +
+	"\x12DECISION_CHALLENGE\x10\x02\x12\x12\n" +
+	"\x0eDECISION_BLOCK\x10\x03\x12\x17\n" +
+	"\x13DECISION_QUARANTINE\x10\x042\x9c\x01\n" +
+	"\x10RecaptchaService\x12C\n" +
+	"\x06Verify\x12\x1b.recaptcha.v1.VerifyRequest\x1a\x1c.recaptcha.v1.VerifyResponse\x12C\n" +
+	"\x06Assess\x12\x1b.recaptcha.v1.AssessRequest\x1a\x1c.recaptcha.v1.AssessResponseB1Z/gopkg.in/ezzarghili/recaptcha-go.v4/recaptchapbb\x06proto3"
+
+var (
+	file_recaptcha_proto_rawDescOnce sync.Once
+	file_recaptcha_proto_rawDescData []byte
+)
+
+func file_recaptcha_proto_rawDescGZIP() []byte {
+	file_recaptcha_proto_rawDescOnce.Do(func() {
+		file_recaptcha_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_recaptcha_proto_rawDesc), len(file_recaptcha_proto_rawDesc)))
+	})
+	return file_recaptcha_proto_rawDescData
+}
+
+var file_recaptcha_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_recaptcha_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_recaptcha_proto_goTypes = []any{
+	(Decision)(0),              // 0: recaptcha.v1.Decision
+	(*VerifyOptions)(nil),      // 1: recaptcha.v1.VerifyOptions
+	(*VerificationResult)(nil), // 2: recaptcha.v1.VerificationResult
+	(*VerifyRequest)(nil),      // 3: recaptcha.v1.VerifyRequest
+	(*VerifyResponse)(nil),     // 4: recaptcha.v1.VerifyResponse
+	(*AssessRequest)(nil),      // 5: recaptcha.v1.AssessRequest
+	(*AssessResponse)(nil),     // 6: recaptcha.v1.AssessResponse
+	nil,                        // 7: recaptcha.v1.VerifyOptions.ContextEntry
+}
+var file_recaptcha_proto_depIdxs = []int32{
+	7, // 0: recaptcha.v1.VerifyOptions.context:type_name -> recaptcha.v1.VerifyOptions.ContextEntry
+	1, // 1: recaptcha.v1.VerifyRequest.options:type_name -> recaptcha.v1.VerifyOptions
+	2, // 2: recaptcha.v1.VerifyResponse.result:type_name -> recaptcha.v1.VerificationResult
+	1, // 3: recaptcha.v1.AssessRequest.options:type_name -> recaptcha.v1.VerifyOptions
+	0, // 4: recaptcha.v1.AssessResponse.decision:type_name -> recaptcha.v1.Decision
+	2, // 5: recaptcha.v1.AssessResponse.result:type_name -> recaptcha.v1.VerificationResult
+	3, // 6: recaptcha.v1.RecaptchaService.Verify:input_type -> recaptcha.v1.VerifyRequest
+	5, // 7: recaptcha.v1.RecaptchaService.Assess:input_type -> recaptcha.v1.AssessRequest
+	4, // 8: recaptcha.v1.RecaptchaService.Verify:output_type -> recaptcha.v1.VerifyResponse
+	6, // 9: recaptcha.v1.RecaptchaService.Assess:output_type -> recaptcha.v1.AssessResponse
+	8, // [8:10] is the sub-list for method output_type
+	6, // [6:8] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_recaptcha_proto_init() }
+func file_recaptcha_proto_init() {
+	if File_recaptcha_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_recaptcha_proto_rawDesc), len(file_recaptcha_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recaptcha_proto_goTypes,
+		DependencyIndexes: file_recaptcha_proto_depIdxs,
+		EnumInfos:         file_recaptcha_proto_enumTypes,
+		MessageInfos:      file_recaptcha_proto_msgTypes,
+	}.Build()
+	File_recaptcha_proto = out.File
+	file_recaptcha_proto_goTypes = nil
+	file_recaptcha_proto_depIdxs = nil
+}
@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: recaptcha.proto
+
+package recaptchapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RecaptchaService_Verify_FullMethodName = "/recaptcha.v1.RecaptchaService/Verify"
+	RecaptchaService_Assess_FullMethodName = "/recaptcha.v1.RecaptchaService/Assess"
+)
+
+// RecaptchaServiceClient is the client API for RecaptchaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RecaptchaService verifies challenge responses on behalf of other services, so that a single
+// service holds the recaptcha secret.
+type RecaptchaServiceClient interface {
+	// Verify verifies a challenge response, failed verifications are reported in the response.
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	// Assess verifies a challenge response and maps the result to an allow, challenge, block or
+	// quarantine decision according to the server policy.
+	Assess(ctx context.Context, in *AssessRequest, opts ...grpc.CallOption) (*AssessResponse, error)
+}
+
+type recaptchaServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRecaptchaServiceClient(cc grpc.ClientConnInterface) RecaptchaServiceClient {
+	return &recaptchaServiceClient{cc}
+}
+
+func (c *recaptchaServiceClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, RecaptchaService_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recaptchaServiceClient) Assess(ctx context.Context, in *AssessRequest, opts ...grpc.CallOption) (*AssessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssessResponse)
+	err := c.cc.Invoke(ctx, RecaptchaService_Assess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecaptchaServiceServer is the server API for RecaptchaService service.
+// All implementations must embed UnimplementedRecaptchaServiceServer
+// for forward compatibility.
+//
+// RecaptchaService verifies challenge responses on behalf of other services, so that a single
+// service holds the recaptcha secret.
+type RecaptchaServiceServer interface {
+	// Verify verifies a challenge response, failed verifications are reported in the response.
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	// Assess verifies a challenge response and maps the result to an allow, challenge, block or
+	// quarantine decision according to the server policy.
+	Assess(context.Context, *AssessRequest) (*AssessResponse, error)
+	mustEmbedUnimplementedRecaptchaServiceServer()
+}
+
+// UnimplementedRecaptchaServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRecaptchaServiceServer struct{}
+
+func (UnimplementedRecaptchaServiceServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedRecaptchaServiceServer) Assess(context.Context, *AssessRequest) (*AssessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Assess not implemented")
+}
+func (UnimplementedRecaptchaServiceServer) mustEmbedUnimplementedRecaptchaServiceServer() {}
+func (UnimplementedRecaptchaServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeRecaptchaServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RecaptchaServiceServer will
+// result in compilation errors.
+type UnsafeRecaptchaServiceServer interface {
+	mustEmbedUnimplementedRecaptchaServiceServer()
+}
+
+func RegisterRecaptchaServiceServer(s grpc.ServiceRegistrar, srv RecaptchaServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRecaptchaServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RecaptchaService_ServiceDesc, srv)
+}
+
+func _RecaptchaService_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecaptchaServiceServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecaptchaService_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecaptchaServiceServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecaptchaService_Assess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecaptchaServiceServer).Assess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecaptchaService_Assess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecaptchaServiceServer).Assess(ctx, req.(*AssessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RecaptchaService_ServiceDesc is the grpc.ServiceDesc for RecaptchaService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RecaptchaService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "recaptcha.v1.RecaptchaService",
+	HandlerType: (*RecaptchaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Verify",
+			Handler:    _RecaptchaService_Verify_Handler,
+		},
+		{
+			MethodName: "Assess",
+			Handler:    _RecaptchaService_Assess_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "recaptcha.proto",
+}
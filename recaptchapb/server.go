@@ -0,0 +1,109 @@
+// Package recaptchapb exposes recaptcha verification as a gRPC service, so services written in any
+// language can verify challenge responses through a single Go service holding the secret.
+//
+// The Go code is generated from recaptcha.proto with protoc-gen-go and protoc-gen-go-grpc.
+package recaptchapb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative recaptcha.proto
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+var decisions = map[recaptcha.Decision]Decision{
+	recaptcha.Allow:      Decision_DECISION_ALLOW,
+	recaptcha.Challenge:  Decision_DECISION_CHALLENGE,
+	recaptcha.Block:      Decision_DECISION_BLOCK,
+	recaptcha.Quarantine: Decision_DECISION_QUARANTINE,
+}
+
+// Server RecaptchaServiceServer backed by a recaptcha.Verifier. Failed verifications are reported
+// in the responses, RPC errors are reserved to invalid requests.
+type Server struct {
+	UnimplementedRecaptchaServiceServer
+
+	Verifier recaptcha.Verifier
+	// Policy maps Assess results to decisions, failed verifications are blocked when nil.
+	Policy *recaptcha.Policy
+	// Decider takes Assess decisions instead of Policy when set.
+	Decider recaptcha.Decider
+}
+
+var _ RecaptchaServiceServer = (*Server)(nil)
+
+// NewServer new Server verifying challenge responses with verifier
+func NewServer(verifier recaptcha.Verifier) (*Server, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("server verifier cannot be nil")
+	}
+	return &Server{Verifier: verifier}, nil
+}
+
+// Verify verifies the challenge response of the request.
+func (s *Server) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	if req.GetChallengeResponse() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge response cannot be blank")
+	}
+	response, err := recaptcha.VerifyContext(ctx, s.Verifier, req.GetChallengeResponse(), verifyOption(req.GetOptions()))
+	return &VerifyResponse{Success: err == nil, Error: errorString(err), Result: verificationResult(response)}, nil
+}
+
+// Assess verifies the challenge response of the request and decides whether to allow, challenge,
+// block or quarantine it.
+func (s *Server) Assess(ctx context.Context, req *AssessRequest) (*AssessResponse, error) {
+	if req.GetChallengeResponse() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge response cannot be blank")
+	}
+	options := verifyOption(req.GetOptions())
+	response, err := recaptcha.VerifyContext(ctx, s.Verifier, req.GetChallengeResponse(), options)
+	outcome := recaptcha.DecideWith(ctx, s.Decider, s.Policy, recaptcha.VerifyResult{Response: response, Err: err}, options)
+	return &AssessResponse{
+		Decision: decisions[outcome.Decision],
+		Reasons:  outcome.Reasons,
+		Error:    errorString(err),
+		Result:   verificationResult(response),
+	}, nil
+}
+
+func verifyOption(options *VerifyOptions) recaptcha.VerifyOption {
+	return recaptcha.VerifyOption{
+		Threshold:       options.GetThreshold(),
+		Action:          options.GetAction(),
+		Hostname:        options.GetHostname(),
+		ApkPackageName:  options.GetApkPackageName(),
+		ResponseTime:    time.Duration(options.GetResponseTimeMs()) * time.Millisecond,
+		RemoteIP:        options.GetRemoteIp(),
+		MinChallengeAge: time.Duration(options.GetMinChallengeAgeMs()) * time.Millisecond,
+		MaxChallengeAge: time.Duration(options.GetMaxChallengeAgeMs()) * time.Millisecond,
+		Context:         options.GetContext(),
+	}
+}
+
+func verificationResult(response recaptcha.Response) *VerificationResult {
+	result := &VerificationResult{
+		Success:        response.Success,
+		Hostname:       response.Hostname,
+		ApkPackageName: response.ApkPackageName,
+		Action:         response.Action,
+		Score:          response.Score,
+		ErrorCodes:     response.ErrorCodes,
+	}
+	if !response.ChallengeTS.IsZero() {
+		result.ChallengeTsMs = response.ChallengeTS.UnixNano() / int64(time.Millisecond)
+	}
+	return result
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
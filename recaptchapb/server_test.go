@@ -0,0 +1,142 @@
+package recaptchapb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ServerSuite struct {
+	server   *Server
+	verifier *mockVerifier
+	grpc     *grpc.Server
+	conn     *grpc.ClientConn
+	client   RecaptchaServiceClient
+}
+
+var _ = Suite(&ServerSuite{})
+
+// mockVerifier returns its response for the "valid" challenge response and fails otherwise.
+type mockVerifier struct {
+	response recaptcha.Response
+	options  recaptcha.VerifyOption
+}
+
+func (m *mockVerifier) Verify(challengeResponse string) error {
+	return m.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+func (m *mockVerifier) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := m.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+func (m *mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	m.options = options
+	if challengeResponse != "valid" {
+		return recaptcha.Response{ErrorCodes: []string{"invalid-input-response"}}, fmt.Errorf("remote error codes: [invalid-input-response]")
+	}
+	return m.response, nil
+}
+
+type mockDecider struct {
+	outcome recaptcha.Outcome
+	err     error
+}
+
+func (m *mockDecider) Decide(ctx context.Context, result recaptcha.VerifyResult, options recaptcha.VerifyOption) (recaptcha.Outcome, error) {
+	return m.outcome, m.err
+}
+
+func (s *ServerSuite) SetUpTest(c *C) {
+	s.verifier = &mockVerifier{response: recaptcha.Response{Success: true, Score: 0.6, Action: "login", ChallengeTS: time.Unix(1500000000, 0)}}
+	var err error
+	s.server, err = NewServer(s.verifier)
+	c.Assert(err, IsNil)
+
+	listener := bufconn.Listen(1 << 20)
+	s.grpc = grpc.NewServer()
+	RegisterRecaptchaServiceServer(s.grpc, s.server)
+	go s.grpc.Serve(listener)
+	s.conn, err = grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	c.Assert(err, IsNil)
+	s.client = NewRecaptchaServiceClient(s.conn)
+}
+
+func (s *ServerSuite) TearDownTest(c *C) {
+	s.conn.Close()
+	s.grpc.Stop()
+}
+
+func (s *ServerSuite) TestNewServer(c *C) {
+	_, err := NewServer(nil)
+	c.Check(err, ErrorMatches, "server verifier cannot be nil")
+}
+
+func (s *ServerSuite) TestVerify(c *C) {
+	resp, err := s.client.Verify(context.Background(), &VerifyRequest{
+		ChallengeResponse: "valid",
+		Options:           &VerifyOptions{Action: "login", ResponseTimeMs: 1500, RemoteIp: "10.0.0.1", Context: map[string]string{"form": "login"}},
+	})
+	c.Assert(err, IsNil)
+	c.Check(resp.Success, Equals, true)
+	c.Check(resp.Error, Equals, "")
+	c.Check(resp.Result.Score, Equals, float32(0.6))
+	c.Check(resp.Result.ChallengeTsMs, Equals, int64(1500000000000))
+	c.Check(s.verifier.options.Action, Equals, "login")
+	c.Check(s.verifier.options.ResponseTime, Equals, 1500*time.Millisecond)
+	c.Check(s.verifier.options.RemoteIP, Equals, "10.0.0.1")
+	c.Check(s.verifier.options.Context, DeepEquals, map[string]string{"form": "login"})
+
+	resp, err = s.client.Verify(context.Background(), &VerifyRequest{ChallengeResponse: "invalid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Success, Equals, false)
+	c.Check(resp.Error, Equals, "remote error codes: [invalid-input-response]")
+	c.Check(resp.Result.ErrorCodes, DeepEquals, []string{"invalid-input-response"})
+
+	_, err = s.client.Verify(context.Background(), &VerifyRequest{})
+	c.Check(status.Code(err), Equals, codes.InvalidArgument)
+}
+
+func (s *ServerSuite) TestAssess(c *C) {
+	resp, err := s.client.Assess(context.Background(), &AssessRequest{ChallengeResponse: "valid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Decision, Equals, Decision_DECISION_ALLOW)
+
+	resp, err = s.client.Assess(context.Background(), &AssessRequest{ChallengeResponse: "invalid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Decision, Equals, Decision_DECISION_BLOCK)
+	c.Check(resp.Reasons, DeepEquals, []string{"remote error codes: [invalid-input-response]"})
+
+	s.server.Policy = &recaptcha.Policy{ChallengeBelow: 0.7, BlockBelow: 0.3}
+	resp, err = s.client.Assess(context.Background(), &AssessRequest{ChallengeResponse: "valid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Decision, Equals, Decision_DECISION_CHALLENGE)
+
+	s.server.Decider = &mockDecider{outcome: recaptcha.Outcome{Decision: recaptcha.Quarantine, Reasons: []string{"new account"}}}
+	resp, err = s.client.Assess(context.Background(), &AssessRequest{ChallengeResponse: "valid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Decision, Equals, Decision_DECISION_QUARANTINE)
+	c.Check(resp.Reasons, DeepEquals, []string{"new account"})
+
+	s.server.Decider = &mockDecider{err: fmt.Errorf("unavailable")}
+	resp, err = s.client.Assess(context.Background(), &AssessRequest{ChallengeResponse: "valid"})
+	c.Assert(err, IsNil)
+	c.Check(resp.Decision, Equals, Decision_DECISION_BLOCK)
+	c.Check(resp.Reasons, DeepEquals, []string{"decider failed: 'unavailable'"})
+}
@@ -0,0 +1,146 @@
+// Package recaptchaprom exposes Prometheus metrics of the verifications: attempts by result, V3
+// score distribution and verification latency. The Collector is a recaptcha.Observer fed by the
+// verification events of ReCAPTCHA.Observers.
+package recaptchaprom
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Collector verification metrics, register it with Register and add it to ReCAPTCHA.Observers.
+type Collector struct {
+	// Verifications attempts by action and result, "success", "bypass", "fail_open" for the request
+	// failures accepted by the fail open policy or the recaptcha.ErrorReason of the failure, and by
+	// the context labels given to NewWithContext.
+	Verifications *prometheus.CounterVec
+	// Scores distribution of the V3 scores by action.
+	Scores *prometheus.HistogramVec
+	// Duration verification latency in seconds, verification request included.
+	Duration prometheus.Histogram
+
+	actions map[string]bool
+	context []contextLabel
+}
+
+// ContextLabel label of Collector.Verifications set from the VerifyOption.Context value of Key, which
+// must be a valid label name. Only the listed Values are used as label values, keeping the series
+// bounded, the others are labelled "other" and a missing key "".
+type ContextLabel struct {
+	Key    string
+	Values []string
+}
+
+type contextLabel struct {
+	key    string
+	values map[string]bool
+}
+
+var _ recaptcha.Observer = (*Collector)(nil)
+
+// New new Collector whose metrics are prefixed by namespace, e.g. "myapp_recaptcha_verifications_total".
+// The action label is only set for the expected actions, response actions being chosen by the
+// clients, the others are labelled "other".
+func New(namespace string, actions ...string) *Collector {
+	return NewWithContext(namespace, nil, actions...)
+}
+
+// NewWithContext same as New with the verifications labelled by context too, e.g. the form name or
+// the tenant attached to the verifications with VerifyOption.Context.
+func NewWithContext(namespace string, labels []ContextLabel, actions ...string) *Collector {
+	names := []string{"action", "result"}
+	for _, label := range labels {
+		names = append(names, label.Key)
+	}
+	c := &Collector{
+		Verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "recaptcha",
+			Name:      "verifications_total",
+			Help:      "Verifications by action and result.",
+		}, names),
+		Scores: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "recaptcha",
+			Name:      "score",
+			Help:      "Scores of the V3 verification responses by action.",
+			Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+		}, []string{"action"}),
+		Duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "recaptcha",
+			Name:      "verification_duration_seconds",
+			Help:      "Time spent verifying challenge responses, verification request included.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		actions: make(map[string]bool, len(actions)),
+	}
+	for _, action := range actions {
+		c.actions[action] = true
+	}
+	for _, label := range labels {
+		values := make(map[string]bool, len(label.Values))
+		for _, value := range label.Values {
+			values[value] = true
+		}
+		c.context = append(c.context, contextLabel{key: label.Key, values: values})
+	}
+	return c
+}
+
+// Register registers the metrics with registerer, e.g. prometheus.DefaultRegisterer.
+func (c *Collector) Register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{c.Verifications, c.Scores, c.Duration} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Observe records the verification events, and the bypassed verifications with the "bypass" result.
+// Other events are ignored.
+func (c *Collector) Observe(event recaptcha.Event) {
+	if event.Kind != recaptcha.EventVerification && event.Kind != recaptcha.EventBypass {
+		return
+	}
+	action := "other"
+	if event.Action == "" || c.actions[event.Action] {
+		action = event.Action
+	}
+	if event.Kind == recaptcha.EventBypass {
+		c.Verifications.WithLabelValues(c.labelValues(event, action, "bypass")...).Inc()
+		return
+	}
+	result := "success"
+	if event.FailedOpen() {
+		result = recaptcha.FailOpenReason
+	} else if event.Err != nil {
+		result = recaptcha.ErrorReason(event.Err)
+	}
+	c.Verifications.WithLabelValues(c.labelValues(event, action, result)...).Inc()
+	// zero scores can't be told from responses without score unless rejected as too low
+	if event.Score > 0 || errors.Is(event.Err, recaptcha.ErrScoreTooLow) {
+		c.Scores.WithLabelValues(action).Observe(float64(event.Score))
+	}
+	if event.Duration > 0 {
+		c.Duration.Observe(event.Duration.Seconds())
+	}
+}
+
+// labelValues returns the Verifications label values of the event.
+func (c *Collector) labelValues(event recaptcha.Event, action, result string) []string {
+	values := make([]string, 0, 2+len(c.context))
+	values = append(values, action, result)
+	for _, label := range c.context {
+		value, ok := event.Context[label.key]
+		if ok && !label.values[value] {
+			value = "other"
+		}
+		values = append(values, value)
+	}
+	return values
+}
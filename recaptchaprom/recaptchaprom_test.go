@@ -0,0 +1,98 @@
+package recaptchaprom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type PromSuite struct{}
+
+var _ = Suite(&PromSuite{})
+
+func (s *PromSuite) TestCollector(c *C) {
+	collector := New("app", "login")
+	registry := prometheus.NewRegistry()
+	c.Assert(collector.Register(registry), IsNil)
+	c.Check(collector.Register(registry), NotNil)
+
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "login", Score: 0.9, Duration: 200 * time.Millisecond})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "login", Score: 0.3, Err: &recaptcha.Error{}, Duration: 100 * time.Millisecond})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "random", Score: 0.8, Duration: 100 * time.Millisecond})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Err: fmt.Errorf("down")})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "login", Err: fmt.Errorf("down"), Reasons: []string{recaptcha.FailOpenReason, "down"}})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVersionMismatch})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventBypass, Action: "login"})
+
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "success")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "other")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("other", "success")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("", "other")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "bypass")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "fail_open")), Equals, float64(1))
+	c.Check(testutil.CollectAndCount(collector.Scores), Equals, 2)
+	c.Check(testutil.CollectAndCompare(collector.Duration, strings.NewReader(`
+# HELP app_recaptcha_verification_duration_seconds Time spent verifying challenge responses, verification request included.
+# TYPE app_recaptcha_verification_duration_seconds histogram
+app_recaptcha_verification_duration_seconds_bucket{le="0.005"} 0
+app_recaptcha_verification_duration_seconds_bucket{le="0.01"} 0
+app_recaptcha_verification_duration_seconds_bucket{le="0.025"} 0
+app_recaptcha_verification_duration_seconds_bucket{le="0.05"} 0
+app_recaptcha_verification_duration_seconds_bucket{le="0.1"} 2
+app_recaptcha_verification_duration_seconds_bucket{le="0.25"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="0.5"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="1"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="2.5"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="5"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="10"} 3
+app_recaptcha_verification_duration_seconds_bucket{le="+Inf"} 3
+app_recaptcha_verification_duration_seconds_sum 0.4
+app_recaptcha_verification_duration_seconds_count 3
+`)), IsNil)
+}
+
+func (s *PromSuite) TestObserver(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": true, "score": 0.4, "action": "login", "hostname": "example.com"}`))
+	}))
+	defer server.Close()
+
+	collector := New("", "login")
+	captcha, err := recaptcha.NewReCAPTCHA("secret", recaptcha.V3, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	captcha.Observers = []recaptcha.Observer{collector}
+
+	c.Check(captcha.VerifyWithOptions("token", recaptcha.VerifyOption{Action: "login", Threshold: 0.3}), IsNil)
+	c.Check(captcha.VerifyWithOptions("token", recaptcha.VerifyOption{Action: "login", Threshold: 0.5}), NotNil)
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "success")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "score_too_low")), Equals, float64(1))
+	c.Check(testutil.CollectAndCount(collector.Duration), Equals, 1)
+}
+
+func (s *PromSuite) TestContextLabels(c *C) {
+	collector := NewWithContext("", []ContextLabel{{Key: "form", Values: []string{"signup", "contact"}}}, "login")
+	c.Assert(collector.Register(prometheus.NewRegistry()), IsNil)
+
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "login", Context: map[string]string{"form": "signup", "user": "42"}})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventVerification, Action: "login", Context: map[string]string{"form": "unknown"}})
+	collector.Observe(recaptcha.Event{Kind: recaptcha.EventBypass, Action: "login"})
+
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "success", "signup")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "success", "other")), Equals, float64(1))
+	c.Check(testutil.ToFloat64(collector.Verifications.WithLabelValues("login", "bypass", "")), Equals, float64(1))
+	c.Check(testutil.CollectAndCount(collector.Verifications), Equals, 3)
+
+	c.Check(NewWithContext("", []ContextLabel{{Key: "form-name"}}).Register(prometheus.NewRegistry()), NotNil)
+}
@@ -0,0 +1,230 @@
+// Package recaptchatest provides fake verifiers for the unit tests of code depending on
+// recaptcha.Verifier. The fakes serve canned siteverify responses to a real ReCAPTCHA, so the
+// verification errors are the ones returned in production, e.g. errors.Is(err, recaptcha.ErrRemoteErrors).
+package recaptchatest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Secret secret of the ReCAPTCHA of the fakes.
+const Secret = "recaptchatest-secret"
+
+type optionsKey struct{}
+
+// Fake verifier answering the verifications with scripted siteverify responses. The blank hostname,
+// APK package name and action of the responses are those expected by the verification options, a
+// zero challenge timestamp is the verification time.
+type Fake struct {
+	// ReCAPTCHA checks the scripted responses, a V2 one by default. Set its Version to recaptcha.V3,
+	// or its profiles, observers..., to test them.
+	ReCAPTCHA *recaptcha.ReCAPTCHA
+
+	mu        sync.Mutex
+	responses []func(version recaptcha.VERSION) recaptcha.Response
+	repeat    bool
+}
+
+var (
+	_ recaptcha.Verifier        = (*Fake)(nil)
+	_ recaptcha.ContextVerifier = (*Fake)(nil)
+)
+
+func newFake(repeat bool, responses ...func(version recaptcha.VERSION) recaptcha.Response) *Fake {
+	f := &Fake{responses: responses, repeat: repeat}
+	captcha, _ := recaptcha.NewReCAPTCHAWithClient(Secret, recaptcha.V2, &http.Client{Transport: recaptcha.RoundTripperFunc(f.roundTrip)})
+	f.ReCAPTCHA = &captcha
+	return f
+}
+
+// AlwaysPass fake whose challenges are all solved, with a score of 1 when its ReCAPTCHA is a V3 one.
+func AlwaysPass() *Fake {
+	return newFake(true, func(version recaptcha.VERSION) recaptcha.Response {
+		if version == recaptcha.V3 {
+			return recaptcha.Response{Success: true, Score: 1}
+		}
+		return recaptcha.Response{Success: true}
+	})
+}
+
+// AlwaysFail fake whose challenges all fail with errorCodes, invalid-input-response when none.
+func AlwaysFail(errorCodes ...string) *Fake {
+	if len(errorCodes) == 0 {
+		errorCodes = []string{"invalid-input-response"}
+	}
+	return newFake(true, func(recaptcha.VERSION) recaptcha.Response {
+		return recaptcha.Response{Success: false, ErrorCodes: errorCodes}
+	})
+}
+
+// ScriptedResponses fake answering the verifications with responses in order, the verifications
+// failing with a request error once they are all served.
+func ScriptedResponses(responses ...recaptcha.Response) *Fake {
+	scripted := make([]func(recaptcha.VERSION) recaptcha.Response, len(responses))
+	for i := range responses {
+		response := responses[i]
+		scripted[i] = func(recaptcha.VERSION) recaptcha.Response { return response }
+	}
+	return newFake(false, scripted...)
+}
+
+// Verify same as recaptcha.ReCAPTCHA.Verify
+func (f *Fake) Verify(challengeResponse string) error {
+	return f.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+// VerifyWithOptions same as recaptcha.ReCAPTCHA.VerifyWithOptions
+func (f *Fake) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := f.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as recaptcha.ReCAPTCHA.VerifyWithOptionsResponse
+func (f *Fake) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return f.VerifyWithOptionsResponseContext(context.Background(), challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as recaptcha.ReCAPTCHA.VerifyWithOptionsResponseContext
+func (f *Fake) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return f.ReCAPTCHA.VerifyWithOptionsResponseContext(context.WithValue(ctx, optionsKey{}, options), challengeResponse, options)
+}
+
+// next returns the next scripted response, false once they are all served.
+func (f *Fake) next() (recaptcha.Response, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.responses) == 0 {
+		return recaptcha.Response{}, false
+	}
+	response := f.responses[0](f.ReCAPTCHA.Version)
+	if !f.repeat {
+		f.responses = f.responses[1:]
+	}
+	return response, true
+}
+
+func (f *Fake) roundTrip(req *http.Request) (*http.Response, error) {
+	response, ok := f.next()
+	if !ok {
+		return nil, fmt.Errorf("no scripted response left")
+	}
+	options, _ := req.Context().Value(optionsKey{}).(recaptcha.VerifyOption)
+	if response.Hostname == "" {
+		response.Hostname = options.Hostname
+		if response.Hostname == "" && len(options.Hostnames) > 0 {
+			response.Hostname = options.Hostnames[0]
+		}
+		if strings.HasPrefix(response.Hostname, "*.") {
+			response.Hostname = "test" + response.Hostname[1:]
+		}
+	}
+	if response.ApkPackageName == "" {
+		response.ApkPackageName = options.ApkPackageName
+	}
+	if response.Action == "" && response.Score != 0 {
+		response.Action = options.Action
+	}
+	if response.ChallengeTS.IsZero() {
+		response.ChallengeTS = time.Now()
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// Call verification recorded by a Recorder.
+type Call struct {
+	ChallengeResponse string
+	Options           recaptcha.VerifyOption
+}
+
+// Recorder verifier recording the verifications before delegating them to Verifier.
+type Recorder struct {
+	Verifier recaptcha.Verifier
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var (
+	_ recaptcha.Verifier        = (*Recorder)(nil)
+	_ recaptcha.ContextVerifier = (*Recorder)(nil)
+)
+
+// Recording new Recorder of the verifications of verifier, AlwaysPass when nil.
+func Recording(verifier recaptcha.Verifier) *Recorder {
+	if verifier == nil {
+		verifier = AlwaysPass()
+	}
+	return &Recorder{Verifier: verifier}
+}
+
+// Verify same as recaptcha.ReCAPTCHA.Verify
+func (r *Recorder) Verify(challengeResponse string) error {
+	return r.VerifyWithOptions(challengeResponse, recaptcha.VerifyOption{})
+}
+
+// VerifyWithOptions same as recaptcha.ReCAPTCHA.VerifyWithOptions
+func (r *Recorder) VerifyWithOptions(challengeResponse string, options recaptcha.VerifyOption) error {
+	_, err := r.VerifyWithOptionsResponse(challengeResponse, options)
+	return err
+}
+
+// VerifyWithOptionsResponse same as recaptcha.ReCAPTCHA.VerifyWithOptionsResponse
+func (r *Recorder) VerifyWithOptionsResponse(challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	return r.VerifyWithOptionsResponseContext(context.Background(), challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as recaptcha.ReCAPTCHA.VerifyWithOptionsResponseContext, the
+// context being ignored when Verifier isn't a recaptcha.ContextVerifier.
+func (r *Recorder) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options recaptcha.VerifyOption) (recaptcha.Response, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, Call{ChallengeResponse: challengeResponse, Options: options})
+	r.mu.Unlock()
+	if verifier, ok := r.Verifier.(recaptcha.ContextVerifier); ok {
+		return verifier.VerifyWithOptionsResponseContext(ctx, challengeResponse, options)
+	}
+	return r.Verifier.VerifyWithOptionsResponse(challengeResponse, options)
+}
+
+// Calls returns the recorded verifications in order.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// ChallengeResponses returns the recorded challenge responses in order.
+func (r *Recorder) ChallengeResponses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challengeResponses := make([]string, len(r.calls))
+	for i, call := range r.calls {
+		challengeResponses[i] = call.ChallengeResponse
+	}
+	return challengeResponses
+}
+
+// Reset forgets the recorded verifications.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
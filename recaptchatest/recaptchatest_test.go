@@ -0,0 +1,75 @@
+package recaptchatest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type FakeSuite struct{}
+
+var _ = Suite(&FakeSuite{})
+
+func (s *FakeSuite) TestAlwaysPass(c *C) {
+	fake := AlwaysPass()
+	options := recaptcha.VerifyOption{Hostname: "example.com", Action: "login", ResponseTime: time.Minute}
+	response, err := fake.VerifyWithOptionsResponse("token", options)
+	c.Assert(err, IsNil)
+	c.Check(response.Success, Equals, true)
+	c.Check(response.Hostname, Equals, "example.com")
+	c.Check(response.Score, Equals, float32(0))
+	c.Check(fake.VerifyWithOptions("token", recaptcha.VerifyOption{Hostnames: []string{"*.example.com"}}), IsNil)
+
+	fake.ReCAPTCHA.Version = recaptcha.V3
+	response, err = fake.VerifyWithOptionsResponse("token", options)
+	c.Assert(err, IsNil)
+	c.Check(response.Score, Equals, float32(1))
+	c.Check(response.Action, Equals, "login")
+}
+
+func (s *FakeSuite) TestAlwaysFail(c *C) {
+	err := AlwaysFail().Verify("token")
+	c.Check(err, ErrorMatches, `remote error codes: \[invalid-input-response\]`)
+	c.Check(errors.Is(err, recaptcha.ErrRemoteErrors), Equals, true)
+
+	err = AlwaysFail("timeout-or-duplicate").Verify("token")
+	var recaptchaErr *recaptcha.Error
+	c.Assert(errors.As(err, &recaptchaErr), Equals, true)
+	c.Check(recaptchaErr.ErrorCodes, DeepEquals, []string{"timeout-or-duplicate"})
+}
+
+func (s *FakeSuite) TestScriptedResponses(c *C) {
+	fake := ScriptedResponses(
+		recaptcha.Response{Success: true, Score: 0.9},
+		recaptcha.Response{Success: true, Score: 0.2},
+		recaptcha.Response{Success: true, Score: 0.9, Action: "signup"},
+	)
+	fake.ReCAPTCHA.Version = recaptcha.V3
+	options := recaptcha.VerifyOption{Action: "login"}
+	c.Check(fake.VerifyWithOptions("token", options), IsNil)
+	c.Check(fake.VerifyWithOptions("token", options), ErrorMatches, "received score '0.200000', while expecting minimum '0.500000'")
+	c.Check(fake.VerifyWithOptions("token", options), ErrorMatches, "invalid response action 'signup', while expecting 'login'")
+	err := fake.VerifyWithOptions("token", options)
+	c.Check(err, ErrorMatches, "error posting to recaptcha endpoint: .*no scripted response left.*")
+	c.Check(errors.Is(err, recaptcha.ErrRequestFailed), Equals, true)
+}
+
+func (s *FakeSuite) TestRecording(c *C) {
+	recorder := Recording(AlwaysFail())
+	c.Check(recorder.Verify("first"), NotNil)
+	c.Check(recorder.VerifyWithOptions("second", recaptcha.VerifyOption{RemoteIP: "10.0.0.1"}), NotNil)
+	c.Check(recorder.ChallengeResponses(), DeepEquals, []string{"first", "second"})
+	calls := recorder.Calls()
+	c.Assert(calls, HasLen, 2)
+	c.Check(calls[1].Options.RemoteIP, Equals, "10.0.0.1")
+
+	recorder.Reset()
+	c.Check(recorder.Calls(), HasLen, 0)
+	c.Check(Recording(nil).Verify("token"), IsNil)
+}
@@ -0,0 +1,111 @@
+package recaptchatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+// Server httptest.Server emulating the siteverify endpoint for the integration tests of handlers.
+// Requests without Secret fail with invalid-input-secret and those without challenge response with
+// missing-input-response, the others get the response configured for their challenge response or
+// the default one, a zero challenge timestamp being the request time.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	response  recaptcha.Response
+	responses map[string]recaptcha.Response
+	latency   time.Duration
+	requests  []url.Values
+}
+
+// NewServer starts a Server whose challenges are all solved by default, close it when done.
+func NewServer() *Server {
+	s := &Server{
+		response:  recaptcha.Response{Success: true},
+		responses: map[string]recaptcha.Response{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetResponse sets the default response, e.g. recaptcha.Response{Success: true, Score: 0.3,
+// Action: "login", Hostname: "example.com"} or recaptcha.Response{ErrorCodes: []string{"timeout-or-duplicate"}}.
+func (s *Server) SetResponse(response recaptcha.Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = response
+}
+
+// SetTokenResponse sets the response to the challengeResponse verifications.
+func (s *Server) SetTokenResponse(challengeResponse string, response recaptcha.Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[challengeResponse] = response
+}
+
+// SetLatency delays the responses by latency, e.g. to test timeouts.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// Requests returns the forms of the received verification requests in order.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	challengeResponse := r.PostForm.Get("response")
+	s.mu.Lock()
+	s.requests = append(s.requests, r.PostForm)
+	response, ok := s.responses[challengeResponse]
+	if !ok {
+		response = s.response
+	}
+	latency := s.latency
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	switch {
+	case r.PostForm.Get("secret") != Secret:
+		response = recaptcha.Response{ErrorCodes: []string{"invalid-input-secret"}}
+	case challengeResponse == "":
+		response = recaptcha.Response{ErrorCodes: []string{"missing-input-response"}}
+	case response.ChallengeTS.IsZero():
+		response.ChallengeTS = time.Now()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// NewReCAPTCHA new ReCAPTCHA verifying the challenge responses against server, the requests timing
+// out after timeout.
+func NewReCAPTCHA(server *Server, version recaptcha.VERSION, timeout time.Duration) (recaptcha.ReCAPTCHA, error) {
+	client := &http.Client{Timeout: timeout, Transport: server.Client().Transport}
+	captcha, err := recaptcha.NewReCAPTCHAWithClient(Secret, version, client)
+	if err != nil {
+		return captcha, err
+	}
+	captcha.ReCAPTCHALink = server.URL
+	return captcha, nil
+}
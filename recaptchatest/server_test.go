@@ -0,0 +1,46 @@
+package recaptchatest
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+type ServerSuite struct{}
+
+var _ = Suite(&ServerSuite{})
+
+func (s *ServerSuite) TestServer(c *C) {
+	server := NewServer()
+	defer server.Close()
+	captcha, err := NewReCAPTCHA(server, recaptcha.V3, 100*time.Millisecond)
+	c.Assert(err, IsNil)
+
+	server.SetResponse(recaptcha.Response{Success: true, Score: 0.9, Action: "login", Hostname: "example.com"})
+	server.SetTokenResponse("bot", recaptcha.Response{Success: true, Score: 0.1, Action: "login", Hostname: "example.com"})
+	options := recaptcha.VerifyOption{Action: "login", Hostname: "example.com", RemoteIP: "10.0.0.1"}
+	response, err := captcha.VerifyWithOptionsResponse("token", options)
+	c.Assert(err, IsNil)
+	c.Check(response.Score, Equals, float32(0.9))
+	c.Check(captcha.VerifyWithOptions("bot", options), ErrorMatches, "received score '0.100000', while expecting minimum '0.500000'")
+
+	requests := server.Requests()
+	c.Assert(requests, HasLen, 2)
+	c.Check(requests[0].Get("response"), Equals, "token")
+	c.Check(requests[0].Get("remoteip"), Equals, "10.0.0.1")
+
+	captcha.Version = recaptcha.V2
+	server.SetResponse(recaptcha.Response{ErrorCodes: []string{"timeout-or-duplicate"}})
+	c.Check(captcha.Verify("token"), ErrorMatches, `remote error codes: \[timeout-or-duplicate\]`)
+	c.Check(captcha.Verify(""), ErrorMatches, `remote error codes: \[missing-input-response\]`)
+	captcha.Secret = "other"
+	c.Check(captcha.Verify("token"), ErrorMatches, `remote error codes: \[invalid-input-secret\]`)
+
+	captcha.Secret = Secret
+	server.SetLatency(time.Second)
+	err = captcha.Verify("token")
+	c.Check(errors.Is(err, recaptcha.ErrRequestFailed), Equals, true)
+}
@@ -0,0 +1,66 @@
+// Package redisstore implements recaptcha.Store on top of Redis, letting several instances share the
+// state of the stateful helpers such as recaptcha.TrustStore and recaptcha.ReplayCache.
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store recaptcha.Store backed by a Redis client.
+type Store struct {
+	client redis.UniversalClient
+	// Timeout bounds every Redis command, no timeout when zero.
+	Timeout time.Duration
+}
+
+// New new Store using the given Redis client
+func New(client redis.UniversalClient) *Store {
+	return &Store{client: client}
+}
+
+// Get returns the value stored for the key and whether it was found.
+func (s *Store) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	value, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// SetWithTTL stores the value for the key, expiring after ttl.
+func (s *Store) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Incr atomically increments the counter stored for the key, setting its expiration when created.
+func (s *Store) Incr(key string, ttl time.Duration) (int64, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	count, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := s.client.Expire(ctx, key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) context() (context.Context, context.CancelFunc) {
+	if s.Timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.Timeout)
+}
@@ -0,0 +1,82 @@
+package redisstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type RedisStoreSuite struct {
+	server *miniredis.Miniredis
+	store  *Store
+}
+
+var _ = Suite(&RedisStoreSuite{})
+
+var _ recaptcha.Store = (*Store)(nil)
+
+func (s *RedisStoreSuite) SetUpTest(c *C) {
+	server, err := miniredis.Run()
+	c.Assert(err, IsNil)
+	s.server = server
+	s.store = New(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+}
+
+func (s *RedisStoreSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *RedisStoreSuite) TestGetSet(c *C) {
+	_, ok, err := s.store.Get("key")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+
+	c.Assert(s.store.SetWithTTL("key", []byte("value"), time.Minute), IsNil)
+	value, ok, err := s.store.Get("key")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(string(value), Equals, "value")
+
+	s.server.FastForward(2 * time.Minute)
+	_, ok, err = s.store.Get("key")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+}
+
+func (s *RedisStoreSuite) TestIncr(c *C) {
+	count, err := s.store.Incr("counter", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, int64(1))
+	count, err = s.store.Incr("counter", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, int64(2))
+	value, _, _ := s.store.Get("counter")
+	c.Check(string(value), Equals, "2")
+
+	s.server.FastForward(2 * time.Minute)
+	count, err = s.store.Incr("counter", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, int64(1))
+}
+
+func (s *RedisStoreSuite) TestTrustStore(c *C) {
+	trust, err := recaptcha.NewTrustStore(s.store, time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(trust.Trust("session"), IsNil)
+	trusted, err := trust.Trusted("session")
+	c.Assert(err, IsNil)
+	c.Check(trusted, Equals, true)
+}
+
+func (s *RedisStoreSuite) TestUnavailableServer(c *C) {
+	s.server.Close()
+	_, _, err := s.store.Get("key")
+	c.Check(err, NotNil)
+}
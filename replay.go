@@ -0,0 +1,53 @@
+package recaptcha
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// TokenValidity duration during which recaptcha accepts a challenge response.
+const TokenValidity = 2 * time.Minute
+
+// ReplayCache remembers the hashes of the verified challenge responses so the reuse of a token is
+// rejected without consulting recaptcha again. Tokens are single use, a replay being either a buggy
+// client or an attacker.
+type ReplayCache struct {
+	Store Store
+	// TTL duration a token is remembered, at least its validity window.
+	TTL time.Duration
+	// Prefix prepended to the token hashes to build the store keys.
+	Prefix string
+}
+
+// NewReplayCache new ReplayCache remembering tokens in store for their validity window
+func NewReplayCache(store Store) (*ReplayCache, error) {
+	if store == nil {
+		return nil, fmt.Errorf("replay cache backend cannot be nil")
+	}
+	return &ReplayCache{Store: store, TTL: TokenValidity, Prefix: "recaptcha:replay:"}, nil
+}
+
+// Seen records the token and reports whether it was already recorded. Concurrent calls for the same
+// token report it seen only once, provided the store increments atomically.
+func (c *ReplayCache) Seen(token string) (bool, error) {
+	sum := sha256.Sum256([]byte(token))
+	count, err := c.Store.Incr(c.Prefix+hex.EncodeToString(sum[:]), c.TTL)
+	if err != nil {
+		return false, err
+	}
+	return count > 1, nil
+}
+
+// checkReplay fails when the challenge response was already verified, blank ones being left to recaptcha.
+func (r *ReCAPTCHA) checkReplay(challengeResponse string) error {
+	if r.ReplayCache == nil || challengeResponse == "" {
+		return nil
+	}
+	// the replay cache is best effort, a failing store shouldn't prevent verification
+	if seen, err := r.ReplayCache.Seen(challengeResponse); err == nil && seen {
+		return &Error{msg: "challenge response already used", kind: kindReplayedToken}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package recaptcha
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ReplayCacheSuite struct{}
+
+var _ = Suite(&ReplayCacheSuite{})
+
+// failingStore Store whose every operation fails.
+type failingStore struct{}
+
+func (failingStore) Get(key string) ([]byte, bool, error) {
+	return nil, false, fmt.Errorf("store down")
+}
+func (failingStore) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	return fmt.Errorf("store down")
+}
+func (failingStore) Incr(key string, ttl time.Duration) (int64, error) {
+	return 0, fmt.Errorf("store down")
+}
+
+func (s *ReplayCacheSuite) TestNewReplayCache(c *C) {
+	_, err := NewReplayCache(nil)
+	c.Check(err, ErrorMatches, "replay cache backend cannot be nil")
+}
+
+func (s *ReplayCacheSuite) TestSeen(c *C) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	cache, err := NewReplayCache(store)
+	c.Assert(err, IsNil)
+	c.Check(cache.TTL, Equals, TokenValidity)
+
+	seen, err := cache.Seen("token")
+	c.Assert(err, IsNil)
+	c.Check(seen, Equals, false)
+	seen, _ = cache.Seen("token")
+	c.Check(seen, Equals, true)
+	seen, _ = cache.Seen("other token")
+	c.Check(seen, Equals, false)
+	_, ok, _ := store.Get("recaptcha:replay:3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0")
+	c.Check(ok, Equals, true)
+
+	now = now.Add(TokenValidity)
+	seen, _ = cache.Seen("token")
+	c.Check(seen, Equals, false)
+}
+
+func (s *ReplayCacheSuite) TestVerify(c *C) {
+	cache, _ := NewReplayCache(NewMemoryStore())
+	captcha := ReCAPTCHA{client: &mockSuccessClientNoOptions{}, ReplayCache: cache}
+
+	c.Check(captcha.Verify("token"), IsNil)
+	err := captcha.Verify("token")
+	c.Check(err, ErrorMatches, "challenge response already used")
+	c.Check(errors.Is(err, ErrTokenReplayed), Equals, true)
+	_, err = captcha.VerifyWithOptionsResponse("token", VerifyOption{})
+	c.Check(errors.Is(err, ErrTokenReplayed), Equals, true)
+	result := captcha.VerifyPooled("token", VerifyOption{})
+	c.Check(errors.Is(result.Err, ErrTokenReplayed), Equals, true)
+	result.Release()
+	c.Check(captcha.Verify("other token"), IsNil)
+
+	captcha.ReplayCache = &ReplayCache{Store: failingStore{}}
+	c.Check(captcha.Verify("token"), IsNil)
+}
@@ -0,0 +1,120 @@
+package recaptcha
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+)
+
+// maxTokenBodySize JSON request bodies larger than this aren't searched by JSONToken.
+const maxTokenBodySize = 1 << 20
+
+// TokenSource reads the challenge response from a request, blank when the request doesn't hold one.
+type TokenSource func(r *http.Request) string
+
+// DefaultTokenSources token sources used when none are configured: the DefaultResponseField form field.
+var DefaultTokenSources = []TokenSource{FormToken(DefaultResponseField)}
+
+// FormToken TokenSource reading the form field name, from the request body or the URL query.
+func FormToken(name string) TokenSource {
+	return func(r *http.Request) string { return r.FormValue(name) }
+}
+
+// QueryToken TokenSource reading the URL query parameter name.
+func QueryToken(name string) TokenSource {
+	return func(r *http.Request) string { return r.URL.Query().Get(name) }
+}
+
+// HeaderToken TokenSource reading the request header name, e.g. DefaultTokenHeader.
+func HeaderToken(name string) TokenSource {
+	return func(r *http.Request) string { return r.Header.Get(name) }
+}
+
+// CookieToken TokenSource reading the cookie name.
+func CookieToken(name string) TokenSource {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// JSONToken TokenSource reading the string member key of the application/json request bodies. The
+// body is buffered and restored, so the handlers can decode it again.
+func JSONToken(key string) TokenSource {
+	return func(r *http.Request) string {
+		if r.Body == nil {
+			return ""
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			return ""
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxTokenBodySize+1))
+		r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		if err != nil || len(body) > maxTokenBodySize {
+			return ""
+		}
+		var members map[string]json.RawMessage
+		if json.Unmarshal(body, &members) != nil {
+			return ""
+		}
+		var token string
+		if json.Unmarshal(members[key], &token) != nil {
+			return ""
+		}
+		return token
+	}
+}
+
+// TokenFromRequest returns the challenge response of the first of sources finding one in the
+// request, DefaultTokenSources being used when sources is empty.
+func TokenFromRequest(r *http.Request, sources ...TokenSource) string {
+	if len(sources) == 0 {
+		sources = DefaultTokenSources
+	}
+	for _, source := range sources {
+		if token := source(r); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// checkHoneypot fails when the honeypot field of req is filled, never when field is blank.
+func checkHoneypot(req *http.Request, field string) error {
+	if field != "" && req.FormValue(field) != "" {
+		return &Error{msg: fmt.Sprintf("honeypot field '%s' filled", field)}
+	}
+	return nil
+}
+
+// VerifyFromRequest verifies the challenge response read from req by the TokenSources, canceling the
+// verification request with the req context. options.RemoteIP is set to the client IP of req when
+// blank, see ClientIPResolver.
+func (r *ReCAPTCHA) VerifyFromRequest(req *http.Request, options VerifyOption) error {
+	return r.VerifyFromRequestResult(req, options).Err
+}
+
+// VerifyFromRequestResult same as VerifyFromRequest but returns the decoded response, and whether
+// the verification was skipped, along with the error.
+func (r *ReCAPTCHA) VerifyFromRequestResult(req *http.Request, options VerifyOption) VerifyResult {
+	if options.RemoteIP == "" {
+		options.RemoteIP = r.ClientIPResolver.ClientIP(req)
+	}
+	if reason := skipReason(req, r.BypassNetworks, r.skip, options); reason != "" {
+		notify(r.Observers, bypassEvent(options, reason))
+		return VerifyResult{Skipped: true}
+	}
+	if err := checkHoneypot(req, r.Honeypot); err != nil {
+		notify(r.Observers, newEvent(EventHoneypot, options, Response{}, err, Outcome{Decision: Block, Reasons: []string{err.Error()}}))
+		return VerifyResult{Err: err}
+	}
+	response, err := r.VerifyWithOptionsResponseContext(req.Context(), TokenFromRequest(req, r.TokenSources...), options)
+	return VerifyResult{Response: response, Err: err}
+}
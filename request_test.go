@@ -0,0 +1,141 @@
+package recaptcha
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RequestSuite struct{}
+
+var _ = Suite(&RequestSuite{})
+
+func (s *RequestSuite) TestVerifyFromRequest(c *C) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		if r.FormValue("response") != "valid" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("g-recaptcha-response=valid"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "10.0.0.1:4321"
+	c.Check(captcha.VerifyFromRequest(req, VerifyOption{}), IsNil)
+	c.Check(form.Get("remoteip"), Equals, "10.0.0.1")
+
+	req = httptest.NewRequest("GET", "/login?g-recaptcha-response=invalid", nil)
+	c.Check(captcha.VerifyFromRequest(req, VerifyOption{RemoteIP: "10.0.0.2"}), ErrorMatches, `remote error codes: \[invalid-input-response\]`)
+	c.Check(form.Get("remoteip"), Equals, "10.0.0.2")
+}
+
+func (s *RequestSuite) TestTokenSources(c *C) {
+	sources := []TokenSource{JSONToken("captcha"), HeaderToken(DefaultTokenHeader), CookieToken("captcha"), QueryToken("token"), FormToken(DefaultResponseField)}
+
+	req := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"user": "jane", "captcha": "from-json"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(DefaultTokenHeader, "from-header")
+	c.Check(TokenFromRequest(req, sources...), Equals, "from-json")
+	// the body is left for the handler
+	body, err := ioutil.ReadAll(req.Body)
+	c.Assert(err, IsNil)
+	c.Check(string(body), Equals, `{"user": "jane", "captcha": "from-json"}`)
+
+	req = httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"captcha": 42}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DefaultTokenHeader, "from-header")
+	c.Check(TokenFromRequest(req, sources...), Equals, "from-header")
+
+	req = httptest.NewRequest("GET", "/login?token=from-query", nil)
+	req.AddCookie(&http.Cookie{Name: "captcha", Value: "from-cookie"})
+	c.Check(TokenFromRequest(req, sources...), Equals, "from-cookie")
+	req = httptest.NewRequest("GET", "/login?token=from-query", nil)
+	c.Check(TokenFromRequest(req, sources...), Equals, "from-query")
+	req = httptest.NewRequest("GET", "/login?g-recaptcha-response=from-form", nil)
+	c.Check(TokenFromRequest(req, sources...), Equals, "from-form")
+	c.Check(TokenFromRequest(req), Equals, "from-form")
+	c.Check(TokenFromRequest(req, QueryToken("token")), Equals, "")
+
+	var verified []string
+	middleware := NewMiddleware(mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		verified = append(verified, challengeResponse)
+		return nil
+	}), VerifyOption{})
+	middleware.TokenSources = []TokenSource{HeaderToken(DefaultTokenHeader)}
+	req = httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set(DefaultTokenHeader, "from-header")
+	middleware.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+	c.Check(verified, DeepEquals, []string{"from-header"})
+}
+
+func (s *RequestSuite) TestVerifyFromRequestBypass(c *C) {
+	var events []Event
+	captcha := ReCAPTCHA{
+		client:    &mockUnavailableClient{},
+		Observers: []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+	captcha.BypassNetworks, _ = ParseNetworks("10.0.0.0/8")
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	c.Check(captcha.VerifyFromRequest(req, VerifyOption{}), IsNil)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, EventBypass)
+	c.Check(events[0].RemoteIP, Equals, "10.0.0.1")
+
+	c.Check(captcha.VerifyFromRequest(req, VerifyOption{RemoteIP: "203.0.113.1"}), ErrorMatches, "error posting to recaptcha endpoint:.*")
+}
+
+func (s *RequestSuite) TestVerifyFromRequestHoneypot(c *C) {
+	var events []Event
+	captcha := ReCAPTCHA{
+		client:    &mockUnavailableClient{},
+		Honeypot:  "website",
+		Observers: []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("website=spam&g-recaptcha-response=mycode"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	result := captcha.VerifyFromRequestResult(req, VerifyOption{})
+	c.Check(result.Err, ErrorMatches, "honeypot field 'website' filled")
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, EventHoneypot)
+	c.Check(events[0].Decision, Equals, Block)
+
+	req = httptest.NewRequest("POST", "/login", strings.NewReader("website=&g-recaptcha-response=mycode"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Check(captcha.VerifyFromRequest(req, VerifyOption{}), ErrorMatches, "error posting to recaptcha endpoint:.*")
+}
+
+func (s *RequestSuite) TestWithSkipFunc(c *C) {
+	var events []Event
+	captcha := ReCAPTCHA{
+		client:    &mockUnavailableClient{},
+		Observers: []Observer{ObserverFunc(func(event Event) { events = append(events, event) })},
+	}
+	captcha.WithSkipFunc(func(r *http.Request) bool { return r.Header.Get("X-Admin") == "true" })
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set("X-Admin", "true")
+	result := captcha.VerifyFromRequestResult(req, VerifyOption{Action: "login"})
+	c.Check(result.Err, IsNil)
+	c.Check(result.Skipped, Equals, true)
+	c.Check(Decide(result, Policy{BlockBelow: 0.5}).Decision, Equals, Allow)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Reasons, DeepEquals, []string{"verification skipped by the skip func"})
+
+	req.Header.Del("X-Admin")
+	result = captcha.VerifyFromRequestResult(req, VerifyOption{})
+	c.Check(result.Skipped, Equals, false)
+	c.Check(result.Err, ErrorMatches, "error posting to recaptcha endpoint:.*")
+}
@@ -0,0 +1,63 @@
+package recaptcha
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ResultCache remembers the verification response bodies, as sent by the provider, so repeated verifications of a token, e.g. by
+// several middlewares or services of one request chain, reuse the prior response instead of failing
+// with the timeout-or-duplicate error code. Each verification still checks the cached response
+// against its own options.
+type ResultCache struct {
+	Store Store
+	// TTL duration a response is remembered, the token validity window by default.
+	TTL time.Duration
+	// Prefix prepended to the token hashes to build the store keys.
+	Prefix string
+}
+
+// NewResultCache new ResultCache remembering responses in store for the token validity window
+func NewResultCache(store Store) (*ResultCache, error) {
+	if store == nil {
+		return nil, fmt.Errorf("result cache backend cannot be nil")
+	}
+	return &ResultCache{Store: store, TTL: TokenValidity, Prefix: "recaptcha:result:"}, nil
+}
+
+// key hashes the request fields the response depends on.
+func (c *ResultCache) key(recaptcha reCHAPTCHARequest) string {
+	sum := sha256.Sum256([]byte(recaptcha.Secret + "\x00" + recaptcha.Response + "\x00" + recaptcha.RemoteIP))
+	return c.Prefix + hex.EncodeToString(sum[:])
+}
+
+// fetchCached same as fetch but serves the responses cached by ResultCache, blank challenge responses
+// being left to recaptcha. The cache is best effort, a failing store doesn't prevent verification.
+func (r *ReCAPTCHA) fetchCached(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption) (Response, []byte, error) {
+	if r.ResultCache == nil || recaptcha.Response == "" {
+		return r.fetch(ctx, recaptcha, options)
+	}
+	key := r.ResultCache.key(recaptcha)
+	if body, ok, err := r.ResultCache.Store.Get(key); err == nil && ok {
+		var result Response
+		if err := r.provider().ParseResponse(body, &result); err == nil {
+			if r.logger != nil {
+				r.log(ctx, slog.LevelDebug, "recaptcha response served from the result cache", "action", options.Action)
+			}
+			return result, body, nil
+		}
+	}
+	result, resultBody, err := r.fetch(ctx, recaptcha, options)
+	if err != nil {
+		return result, resultBody, err
+	}
+	if resultBody != nil {
+		// the raw body keeps the fields omitted from an encoded Response, e.g. a zero score
+		r.ResultCache.Store.SetWithTTL(key, append([]byte(nil), resultBody...), r.ResultCache.TTL)
+	}
+	return result, resultBody, nil
+}
@@ -0,0 +1,64 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ResultCacheSuite struct{}
+
+var _ = Suite(&ResultCacheSuite{})
+
+func (s *ResultCacheSuite) TestResultCache(c *C) {
+	_, err := NewResultCache(nil)
+	c.Check(err, ErrorMatches, "result cache backend cannot be nil")
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 && r.FormValue("response") == "token" {
+			w.Write([]byte(`{"success": false, "error-codes": ["timeout-or-duplicate"]}`))
+			return
+		}
+		if r.FormValue("response") == "zero" {
+			w.Write([]byte(`{"success": true, "score": 0, "action": "login", "hostname": "example.com"}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "score": 0.6, "action": "login", "hostname": "example.com"}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V3, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	captcha.ResultCache, err = NewResultCache(NewMemoryStore())
+	c.Assert(err, IsNil)
+
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Action: "login"}), IsNil)
+	response, err := captcha.VerifyWithOptionsResponse("token", VerifyOption{Action: "login", Hostname: "example.com"})
+	c.Check(err, IsNil)
+	c.Check(response.Score, Equals, float32(0.6))
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Action: "login", Threshold: 0.7}), ErrorMatches, "received score '0.600000', while expecting minimum '0.700000'")
+	c.Check(requests, Equals, 1)
+
+	// the remote IP is part of the request
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{RemoteIP: "10.0.0.1"}), ErrorMatches, `received score '0.000000'.*`)
+	c.Check(requests, Equals, 2)
+
+	// the provider body is cached as is
+	c.Check(captcha.VerifyWithOptions("zero", VerifyOption{Threshold: 0.1}), ErrorMatches, "received score '0.000000', while expecting minimum '0.100000'")
+	c.Check(requests, Equals, 3)
+	captcha.StrictResponse = true
+	err = captcha.VerifyWithOptions("zero", VerifyOption{Threshold: 0.1})
+	c.Check(err, ErrorMatches, "received score '0.000000', while expecting minimum '0.100000'")
+	c.Check(err.(*Error).ResponseBody, Equals, `{"success": true, "score": 0, "action": "login", "hostname": "example.com"}`)
+	c.Check(requests, Equals, 3)
+	captcha.StrictResponse = false
+
+	captcha.ResultCache.Store = failingStore{}
+	c.Check(captcha.VerifyWithOptions("other", VerifyOption{Action: "login"}), IsNil)
+	c.Check(requests, Equals, 4)
+}
@@ -0,0 +1,44 @@
+package recaptcha
+
+import "fmt"
+
+// Rollout enforces the verification failures of a deterministic percentage of the challenges, the
+// same challenge response, or remote IP, being consistently enforced or not. Raise Percent
+// gradually while watching the failures reported by the Observers.
+type Rollout struct {
+	// Percent percentage of the challenges whose verification failures are enforced, between 0 and 100.
+	Percent float64
+	// ByRemoteIP select the enforced challenges by VerifyOption.RemoteIP instead of challenge response,
+	// so every request of a client gets the same treatment. The challenge response is used when the
+	// remote IP is blank.
+	ByRemoteIP bool
+}
+
+// NewRollout new Rollout enforcing the verification failures of percent (between 0 and 100) of the
+// challenges.
+func NewRollout(percent float64) (*Rollout, error) {
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("rollout percentage must be between 0 and 100, got '%f'", percent)
+	}
+	return &Rollout{Percent: percent}, nil
+}
+
+func (r *Rollout) enforced(challengeResponse string, options VerifyOption) bool {
+	return float64(bucket(challengeResponse, options, r.ByRemoteIP)) < r.Percent*100
+}
+
+// bucket deterministic bucket between 0 and 9999 of the challenge response, or of the remote IP when
+// byRemoteIP and not blank.
+func bucket(challengeResponse string, options VerifyOption, byRemoteIP bool) uint64 {
+	key := challengeResponse
+	if byRemoteIP && options.RemoteIP != "" {
+		key = options.RemoteIP
+	}
+	// FNV-1a, inlined so the pooled verifications don't allocate
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= 1099511628211
+	}
+	return hash % 10000
+}
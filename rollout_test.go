@@ -0,0 +1,43 @@
+package recaptcha
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type RolloutSuite struct{}
+
+var _ = Suite(&RolloutSuite{})
+
+func (s *RolloutSuite) TestRollout(c *C) {
+	_, err := NewRollout(101)
+	c.Check(err, ErrorMatches, "rollout percentage must be between 0 and 100, got '101.000000'")
+
+	rollout, err := NewRollout(25)
+	c.Assert(err, IsNil)
+	enforced := 0
+	for i := 0; i < 10000; i++ {
+		token := fmt.Sprintf("token-%d", i)
+		if rollout.enforced(token, VerifyOption{}) {
+			enforced++
+		}
+		c.Assert(rollout.enforced(token, VerifyOption{}), Equals, rollout.enforced(token, VerifyOption{}))
+	}
+	c.Check(enforced > 2300 && enforced < 2700, Equals, true, Commentf("%d enforced", enforced))
+
+	rollout.ByRemoteIP = true
+	options := VerifyOption{RemoteIP: "10.0.0.1"}
+	first := rollout.enforced("token-1", options)
+	for i := 0; i < 100; i++ {
+		c.Assert(rollout.enforced(fmt.Sprintf("token-%d", i), options), Equals, first)
+	}
+
+	captcha := ReCAPTCHA{client: &mockV3FailClientWithThresholdOption{}, Version: V3, Rollout: &Rollout{Percent: 0}}
+	c.Check(captcha.Verify("mycode"), IsNil)
+	result := captcha.VerifyPooled("mycode", VerifyOption{})
+	c.Check(result.Err, IsNil)
+	result.Release()
+	captcha.Rollout.Percent = 100
+	c.Check(captcha.Verify("mycode"), NotNil)
+}
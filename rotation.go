@@ -0,0 +1,27 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+// invalidSecretCode error code of the responses to requests sent with an unknown secret.
+const invalidSecretCode = "invalid-input-secret"
+
+// sendRotating same as send but sends the request again with SecondarySecret when recaptcha
+// rejected the secret.
+func (r *ReCAPTCHA) sendRotating(ctx context.Context, recaptcha reCHAPTCHARequest, options VerifyOption, formValues url.Values, body *bytes.Buffer, result *Response) ([]byte, error) {
+	resultBody, err := r.send(ctx, recaptcha, options, formValues, body, result)
+	if err != nil || r.SecondarySecret == "" || recaptcha.Secret == r.SecondarySecret || !containsString(result.ErrorCodes, invalidSecretCode) {
+		return resultBody, err
+	}
+	if r.logger != nil {
+		r.log(ctx, slog.LevelInfo, "recaptcha secret rejected, retrying with the secondary secret")
+	}
+	recaptcha.Secret = r.SecondarySecret
+	*result = Response{}
+	body.Reset()
+	return r.send(ctx, recaptcha, options, formValues, body, result)
+}
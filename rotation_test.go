@@ -0,0 +1,53 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RotationSuite struct{}
+
+var _ = Suite(&RotationSuite{})
+
+func (s *RotationSuite) TestSecondarySecret(c *C) {
+	var secrets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secrets = append(secrets, r.FormValue("secret"))
+		if r.FormValue("secret") != "new-secret" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-secret"]}`))
+			return
+		}
+		if r.FormValue("response") != "token" {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("old-secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Verify("token"), ErrorMatches, `remote error codes: \[invalid-input-secret\]`)
+
+	captcha.SecondarySecret = "new-secret"
+	secrets = nil
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(secrets, DeepEquals, []string{"old-secret", "new-secret"})
+	p := captcha.VerifyPooled("token", VerifyOption{})
+	c.Check(p.Err, IsNil)
+	p.Release()
+
+	// the errors of the secondary secret are returned
+	secrets = nil
+	c.Check(captcha.Verify("other"), ErrorMatches, `remote error codes: \[invalid-input-response\]`)
+	c.Check(secrets, DeepEquals, []string{"old-secret", "new-secret"})
+
+	captcha.Secret = "new-secret"
+	secrets = nil
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(secrets, DeepEquals, []string{"new-secret"})
+}
@@ -0,0 +1,100 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SamplerStats counters of sampled and skipped verifications.
+type SamplerStats struct {
+	Verified int64
+	Skipped  int64
+}
+
+// Sampler verifies only a fraction of the challenges it receives, for very high-traffic endpoints
+// that want a bot signal and quota control without verifying every request. Skipped challenges
+// are accepted, flagged challenges and blank challenge responses are always verified. Like Rollout
+// the sampled challenges are selected deterministically, so retrying the same challenge response,
+// or remote IP, can't be used to draw an unsampled verification.
+type Sampler struct {
+	Verifier Verifier
+	// Rate fraction of challenges to verify, between 0 and 1.
+	Rate float64
+	// ByRemoteIP select the sampled challenges by VerifyOption.RemoteIP instead of challenge response,
+	// so every request of a client gets the same treatment. The challenge response is used when the
+	// remote IP is blank.
+	ByRemoteIP bool
+	// Flagged when set and returning true forces the verification of the challenge.
+	Flagged func(challengeResponse string, options VerifyOption) bool
+
+	mu    sync.Mutex
+	stats SamplerStats
+}
+
+var _ ContextVerifier = (*Sampler)(nil)
+
+// NewSampler new Sampler verifying about rate (between 0 and 1) of the challenges using verifier.
+func NewSampler(verifier Verifier, rate float64) (*Sampler, error) {
+	if rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("sampling rate must be between 0 and 1, got '%f'", rate)
+	}
+	return &Sampler{Verifier: verifier, Rate: rate}, nil
+}
+
+// Verify returns `nil` if the challenge was not sampled or was solved correctly
+func (s *Sampler) Verify(challengeResponse string) error {
+	if !s.sample(challengeResponse, VerifyOption{}) {
+		return nil
+	}
+	return s.Verifier.Verify(challengeResponse)
+}
+
+// VerifyWithOptions returns `nil` if the challenge was not sampled or was solved correctly and all
+// options are matching
+func (s *Sampler) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	if !s.sample(challengeResponse, options) {
+		return nil
+	}
+	return s.Verifier.VerifyWithOptions(challengeResponse, options)
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the decoded response, which is
+// zero for challenges that were not sampled
+func (s *Sampler) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	if !s.sample(challengeResponse, options) {
+		return Response{}, nil
+	}
+	return s.Verifier.VerifyWithOptionsResponse(challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as VerifyWithOptionsResponse, ctx canceling the sampled
+// verifications
+func (s *Sampler) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options VerifyOption) (Response, error) {
+	if !s.sample(challengeResponse, options) {
+		return Response{}, nil
+	}
+	return VerifyContext(ctx, s.Verifier, challengeResponse, options)
+}
+
+// Stats returns a snapshot of the sampling counters.
+func (s *Sampler) Stats() SamplerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *Sampler) sample(challengeResponse string, options VerifyOption) bool {
+	sampled := challengeResponse == "" ||
+		(s.Flagged != nil && s.Flagged(challengeResponse, options)) ||
+		float64(bucket(challengeResponse, options, s.ByRemoteIP)) < s.Rate*10000
+
+	s.mu.Lock()
+	if sampled {
+		s.stats.Verified++
+	} else {
+		s.stats.Skipped++
+	}
+	s.mu.Unlock()
+	return sampled
+}
@@ -0,0 +1,74 @@
+package recaptcha
+
+import (
+	"context"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type SamplerSuite struct{}
+
+var _ = Suite(&SamplerSuite{})
+
+func (s *SamplerSuite) TestNewSampler(c *C) {
+	_, err := NewSampler(passVerifier(), 1.5)
+	c.Check(err, ErrorMatches, "sampling rate must be between 0 and 1.*")
+
+	sampler, err := NewSampler(passVerifier(), 0.1)
+	c.Assert(err, IsNil)
+	c.Check(sampler.Rate, Equals, 0.1)
+}
+
+func (s *SamplerSuite) TestSamplerSkipsUnsampled(c *C) {
+	sampler, err := NewSampler(failVerifier("invalid challenge solution"), 0)
+	c.Assert(err, IsNil)
+	c.Check(sampler.Verify("mycode"), IsNil)
+
+	sampler.Rate = 1
+	c.Check(sampler.VerifyWithOptions("mycode", VerifyOption{}), ErrorMatches, "invalid challenge solution")
+
+	c.Check(sampler.Stats(), DeepEquals, SamplerStats{Verified: 1, Skipped: 1})
+}
+
+func (s *SamplerSuite) TestSamplerIsDeterministic(c *C) {
+	sampler, err := NewSampler(failVerifier("invalid challenge solution"), 0.25)
+	c.Assert(err, IsNil)
+	for i := 0; i < 1000; i++ {
+		token := fmt.Sprintf("code%d", i)
+		verified := sampler.Verify(token) != nil
+		// retrying the same token can't draw another decision
+		c.Assert(sampler.Verify(token) != nil, Equals, verified)
+	}
+	stats := sampler.Stats()
+	c.Check(stats.Verified > 2*200 && stats.Verified < 2*300, Equals, true, Commentf("%+v", stats))
+
+	sampler.ByRemoteIP = true
+	verified := sampler.VerifyWithOptions("code0", VerifyOption{RemoteIP: "10.0.0.1"}) != nil
+	for i := 1; i < 100; i++ {
+		c.Check(sampler.VerifyWithOptions(fmt.Sprintf("code%d", i), VerifyOption{RemoteIP: "10.0.0.1"}) != nil, Equals, verified)
+	}
+}
+
+func (s *SamplerSuite) TestSamplerContext(c *C) {
+	sampler, err := NewSampler(contextMockVerifier(func(ctx context.Context, challengeResponse string, options VerifyOption) error {
+		return ctx.Err()
+	}), 1)
+	c.Assert(err, IsNil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sampler.VerifyWithOptionsResponseContext(ctx, "mycode", VerifyOption{})
+	c.Check(err, Equals, context.Canceled)
+}
+
+func (s *SamplerSuite) TestSamplerAlwaysVerifiesFlagged(c *C) {
+	sampler, err := NewSampler(failVerifier("invalid challenge solution"), 0)
+	c.Assert(err, IsNil)
+	sampler.Flagged = func(challengeResponse string, options VerifyOption) bool {
+		return options.RemoteIP == "10.0.0.1"
+	}
+
+	c.Check(sampler.VerifyWithOptions("mycode", VerifyOption{RemoteIP: "10.0.0.2"}), IsNil)
+	c.Check(sampler.VerifyWithOptions("mycode", VerifyOption{RemoteIP: "10.0.0.1"}), NotNil)
+	c.Check(sampler.Verify(""), NotNil)
+}
@@ -0,0 +1,154 @@
+package recaptcha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Verifier is implemented by anything able to verify a challenge response, ReCAPTCHA being the
+// canonical implementation. It lets helpers such as Shadow wrap one or more providers.
+type Verifier interface {
+	Verify(challengeResponse string) error
+	VerifyWithOptions(challengeResponse string, options VerifyOption) error
+	VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error)
+}
+
+var _ Verifier = (*ReCAPTCHA)(nil)
+
+// ContextVerifier is implemented by verifiers whose verification requests can be canceled with a
+// context, handlers use it through VerifyContext to propagate the deadline of the requests they serve.
+type ContextVerifier interface {
+	VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options VerifyOption) (Response, error)
+}
+
+var _ ContextVerifier = (*ReCAPTCHA)(nil)
+
+// VerifyContext verifies the challenge response with verifier, passing it the context when it is a
+// ContextVerifier.
+func VerifyContext(ctx context.Context, verifier Verifier, challengeResponse string, options VerifyOption) (Response, error) {
+	if verifier, ok := verifier.(ContextVerifier); ok {
+		return verifier.VerifyWithOptionsResponseContext(ctx, challengeResponse, options)
+	}
+	return verifier.VerifyWithOptionsResponse(challengeResponse, options)
+}
+
+// DefaultShadowTimeout time budget of the shadow verifications when Shadow.Timeout is zero.
+const DefaultShadowTimeout = 5 * time.Second
+
+// ShadowStats counters describing how often the primary and shadow providers agreed.
+type ShadowStats struct {
+	Total         int64
+	Agreements    int64
+	Disagreements int64
+	// PrimaryOnly counts challenges accepted by the primary but rejected by the shadow.
+	PrimaryOnly int64
+	// ShadowOnly counts challenges rejected by the primary but accepted by the shadow.
+	ShadowOnly int64
+}
+
+// Shadow verifies every challenge against two providers but only enforces the primary one,
+// recording how often both outcomes disagree. Use it while migrating between providers
+// (e.g. reCAPTCHA v3 to Enterprise or Turnstile) to measure the impact before switching. The
+// shadow verification runs in the background once the primary one is done, so it neither delays
+// the primary outcome nor consumes the single use token before the primary provider.
+type Shadow struct {
+	Primary   Verifier
+	Secondary Verifier
+	// OnDisagreement when set is called every time only one of the providers accepted the challenge,
+	// from the goroutine of the shadow verification.
+	OnDisagreement func(primaryErr, shadowErr error)
+	// Timeout time budget of each shadow verification, DefaultShadowTimeout when zero.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	stats   ShadowStats
+	pending sync.WaitGroup
+}
+
+var _ ContextVerifier = (*Shadow)(nil)
+
+// NewShadow new Shadow enforcing primary while comparing it with secondary.
+func NewShadow(primary, secondary Verifier) *Shadow {
+	return &Shadow{Primary: primary, Secondary: secondary}
+}
+
+// Verify verifies the challenge against both providers and returns the primary outcome.
+func (s *Shadow) Verify(challengeResponse string) error {
+	return s.VerifyWithOptions(challengeResponse, VerifyOption{})
+}
+
+// VerifyWithOptions verifies the challenge against both providers using the same options and
+// returns the primary outcome. Tokens are single use, so both providers must accept the same token
+// (e.g. v3 and Enterprise); use VerifyPair when the frontend renders one widget per provider.
+func (s *Shadow) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	return s.VerifyPair(challengeResponse, challengeResponse, options)
+}
+
+// VerifyWithOptionsResponse same as VerifyWithOptions but also returns the primary decoded response.
+func (s *Shadow) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	return s.verifyPair(context.Background(), challengeResponse, challengeResponse, options)
+}
+
+// VerifyWithOptionsResponseContext same as VerifyWithOptionsResponse, ctx canceling the primary
+// verification only: the shadow one outlives the request with its own Timeout.
+func (s *Shadow) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options VerifyOption) (Response, error) {
+	return s.verifyPair(ctx, challengeResponse, challengeResponse, options)
+}
+
+// VerifyPair verifies primaryResponse against the primary provider and returns its outcome, then
+// verifies shadowResponse against the secondary one in the background.
+func (s *Shadow) VerifyPair(primaryResponse, shadowResponse string, options VerifyOption) error {
+	_, err := s.verifyPair(context.Background(), primaryResponse, shadowResponse, options)
+	return err
+}
+
+// Wait waits for the shadow verifications in progress, e.g. before shutting down or reading Stats.
+func (s *Shadow) Wait() {
+	s.pending.Wait()
+}
+
+func (s *Shadow) verifyPair(ctx context.Context, primaryResponse, shadowResponse string, options VerifyOption) (Response, error) {
+	response, primaryErr := VerifyContext(ctx, s.Primary, primaryResponse, options)
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultShadowTimeout
+	}
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		shadowCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, shadowErr := VerifyContext(shadowCtx, s.Secondary, shadowResponse, options)
+		s.record(primaryErr, shadowErr)
+	}()
+	return response, primaryErr
+}
+
+// Stats returns a snapshot of the comparison counters.
+func (s *Shadow) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *Shadow) record(primaryErr, shadowErr error) {
+	s.mu.Lock()
+	s.stats.Total++
+	agree := (primaryErr == nil) == (shadowErr == nil)
+	if agree {
+		s.stats.Agreements++
+	} else {
+		s.stats.Disagreements++
+		if primaryErr == nil {
+			s.stats.PrimaryOnly++
+		} else {
+			s.stats.ShadowOnly++
+		}
+	}
+	s.mu.Unlock()
+
+	if !agree && s.OnDisagreement != nil {
+		s.OnDisagreement(primaryErr, shadowErr)
+	}
+}
@@ -0,0 +1,134 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ShadowSuite struct{}
+
+var _ = Suite(&ShadowSuite{})
+
+type mockVerifier func(challengeResponse string, options VerifyOption) error
+
+func (m mockVerifier) Verify(challengeResponse string) error {
+	return m(challengeResponse, VerifyOption{})
+}
+
+func (m mockVerifier) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	return m(challengeResponse, options)
+}
+
+func (m mockVerifier) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	return Response{}, m(challengeResponse, options)
+}
+
+func passVerifier() mockVerifier {
+	return func(string, VerifyOption) error { return nil }
+}
+
+func failVerifier(msg string) mockVerifier {
+	return func(string, VerifyOption) error { return &Error{msg: msg} }
+}
+
+func (s *ShadowSuite) TestShadowEnforcesPrimary(c *C) {
+	shadow := NewShadow(passVerifier(), failVerifier("shadow failed"))
+	var disagreements int
+	shadow.OnDisagreement = func(primaryErr, shadowErr error) {
+		disagreements++
+		c.Check(primaryErr, IsNil)
+		c.Check(shadowErr, ErrorMatches, "shadow failed")
+	}
+
+	c.Check(shadow.Verify("mycode"), IsNil)
+	shadow.Wait()
+	c.Check(disagreements, Equals, 1)
+
+	shadow.Primary = failVerifier("primary failed")
+	c.Check(shadow.VerifyWithOptions("mycode", VerifyOption{}), ErrorMatches, "primary failed")
+	shadow.Wait()
+	c.Check(disagreements, Equals, 1)
+
+	c.Check(shadow.Stats(), DeepEquals, ShadowStats{Total: 2, Agreements: 1, Disagreements: 1, PrimaryOnly: 1})
+}
+
+func (s *ShadowSuite) TestShadowVerifyPair(c *C) {
+	var primaryToken, shadowToken string
+	var shadowOptions VerifyOption
+	primary := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		primaryToken = challengeResponse
+		return errors.New("primary failed")
+	})
+	secondary := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		shadowToken = challengeResponse
+		shadowOptions = options
+		return nil
+	})
+	shadow := NewShadow(primary, secondary)
+
+	err := shadow.VerifyPair("recaptcha-token", "turnstile-token", VerifyOption{Hostname: "test.com"})
+	c.Check(err, ErrorMatches, "primary failed")
+	shadow.Wait()
+	c.Check(primaryToken, Equals, "recaptcha-token")
+	c.Check(shadowToken, Equals, "turnstile-token")
+	c.Check(shadowOptions.Hostname, Equals, "test.com")
+	c.Check(shadow.Stats().ShadowOnly, Equals, int64(1))
+}
+
+func (s *ShadowSuite) TestShadowDoesNotDelayPrimary(c *C) {
+	var order []string
+	var mu sync.Mutex
+	release := make(chan struct{})
+	primary := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		mu.Lock()
+		order = append(order, "primary")
+		mu.Unlock()
+		return nil
+	})
+	hung := contextMockVerifier(func(ctx context.Context, challengeResponse string, options VerifyOption) error {
+		mu.Lock()
+		order = append(order, "shadow")
+		mu.Unlock()
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	shadow := NewShadow(primary, hung)
+	shadow.Timeout = 10 * time.Millisecond
+
+	// the primary outcome doesn't wait for the hung shadow, which verifies the token afterwards
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := shadow.VerifyWithOptionsResponseContext(ctx, "mycode", VerifyOption{})
+	c.Check(err, IsNil)
+	cancel()
+	shadow.Wait()
+	c.Check(order, DeepEquals, []string{"primary", "shadow"})
+	c.Check(shadow.Stats(), DeepEquals, ShadowStats{Total: 1, Disagreements: 1, PrimaryOnly: 1})
+	close(release)
+}
+
+// contextMockVerifier mockVerifier receiving the verification context.
+type contextMockVerifier func(ctx context.Context, challengeResponse string, options VerifyOption) error
+
+func (m contextMockVerifier) Verify(challengeResponse string) error {
+	return m(context.Background(), challengeResponse, VerifyOption{})
+}
+
+func (m contextMockVerifier) VerifyWithOptions(challengeResponse string, options VerifyOption) error {
+	return m(context.Background(), challengeResponse, options)
+}
+
+func (m contextMockVerifier) VerifyWithOptionsResponse(challengeResponse string, options VerifyOption) (Response, error) {
+	return Response{}, m(context.Background(), challengeResponse, options)
+}
+
+func (m contextMockVerifier) VerifyWithOptionsResponseContext(ctx context.Context, challengeResponse string, options VerifyOption) (Response, error) {
+	return Response{}, m(ctx, challengeResponse, options)
+}
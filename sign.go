@@ -0,0 +1,44 @@
+package recaptcha
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Purposes of the sealed tokens, signed along the payloads so a token of one helper is never accepted
+// by another sharing its key, e.g. a form stamp given to every visitor as a verified session cookie.
+const (
+	purposeFormStamp    = "form-stamp"
+	purposeStepUpTicket = "stepup-ticket"
+	purposeProof        = "exchange-proof"
+	purposeCookieMemory = "cookie-memory"
+	purposePassToken    = "pass-token"
+)
+
+// seal returns the payload and its HMAC-SHA256 signature covering purpose too, both base64 url
+// encoded and joined by a dot.
+func seal(key []byte, purpose, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signature(key, purpose+"\x00"+payload)
+}
+
+// unseal returns the payload of a token made by seal for purpose, ok is false when the token is
+// malformed or its signature doesn't match.
+func unseal(key []byte, purpose, token string) (payload string, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", false
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || !hmac.Equal([]byte(signature(key, purpose+"\x00"+string(decoded))), []byte(parts[1])) {
+		return "", false
+	}
+	return string(decoded), true
+}
+
+func signature(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package recaptcha
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTicketTTL default validity of the tickets issued by StepUp
+const DefaultTicketTTL = 5 * time.Minute
+
+// ChallengeRequiredError returned by StepUp.Verify when the V3 score is too low, the client must solve
+// a V2 challenge and send its response back along with the Ticket.
+type ChallengeRequiredError struct {
+	// Ticket signed short-lived ticket to pass to StepUp.Escalate.
+	Ticket string
+	// Err the V3 verification error.
+	Err error
+}
+
+func (e *ChallengeRequiredError) Error() string { return "challenge required: " + e.Err.Error() }
+
+// StepUp packages the common V3 to V2 escalation: a low V3 score issues a signed ticket requiring
+// the client to solve a V2 challenge, only a V2 response presented with a valid ticket then passes.
+type StepUp struct {
+	V3 Verifier
+	V2 Verifier
+	// Key secret used to sign the tickets.
+	Key []byte
+	// TTL validity of the tickets, DefaultTicketTTL when zero.
+	TTL time.Duration
+	// Store when set makes the tickets single use, their nonces being remembered until they expire.
+	// Without it a ticket can be escalated again with new V2 responses until it expires. A failing
+	// store doesn't prevent escalation.
+	Store Store
+
+	now func() time.Time
+}
+
+// NewStepUp new StepUp escalating low v3 scores to v2 challenges, tickets are signed with key.
+func NewStepUp(v3, v2 Verifier, key []byte) (*StepUp, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("step-up ticket key cannot be blank")
+	}
+	return &StepUp{V3: v3, V2: v2, Key: key, now: time.Now}, nil
+}
+
+// Verify verifies the V3 challenge response, returns a *ChallengeRequiredError holding a ticket when
+// the score is too low or the V3 verification error for any other failure.
+func (s *StepUp) Verify(challengeResponse string, options VerifyOption) error {
+	err := s.V3.VerifyWithOptions(challengeResponse, options)
+	var recaptchaErr *Error
+	if !errors.As(err, &recaptchaErr) || recaptchaErr.kind != kindLowScore {
+		return err
+	}
+	ticket, ticketErr := s.issue(options)
+	if ticketErr != nil {
+		return ticketErr
+	}
+	return &ChallengeRequiredError{Ticket: ticket, Err: err}
+}
+
+// Escalate verifies the V2 challenge response solved after a ChallengeRequiredError, the ticket must
+// be valid, issued for the same action and remote IP and, with a Store, not already escalated.
+func (s *StepUp) Escalate(ticket, challengeResponse string, options VerifyOption) error {
+	if err := s.check(ticket, options); err != nil {
+		return err
+	}
+	return s.V2.VerifyWithOptions(challengeResponse, options)
+}
+
+// issue returns a ticket made of "expiry|action|remoteip|nonce" and its signature.
+func (s *StepUp) issue(options VerifyOption) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("couldn't generate ticket nonce: '%s'", err)
+	}
+	expiry := s.clock().Add(s.ttl()).Unix()
+	payload := strings.Join([]string{strconv.FormatInt(expiry, 10), options.Action, options.RemoteIP, hex.EncodeToString(nonce)}, "|")
+	return seal(s.Key, purposeStepUpTicket, payload), nil
+}
+
+func (s *StepUp) check(ticket string, options VerifyOption) error {
+	payload, ok := unseal(s.Key, purposeStepUpTicket, ticket)
+	if !ok {
+		return &Error{msg: "invalid challenge ticket"}
+	}
+	fields := strings.Split(payload, "|")
+	if len(fields) != 4 {
+		return &Error{msg: "invalid challenge ticket"}
+	}
+	expiry, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return &Error{msg: "invalid challenge ticket"}
+	}
+	if s.clock().Unix() > expiry {
+		return &Error{msg: "expired challenge ticket"}
+	}
+	if fields[1] != options.Action || fields[2] != options.RemoteIP {
+		return &Error{msg: fmt.Sprintf("challenge ticket issued for action '%s' and remote IP '%s'", fields[1], fields[2])}
+	}
+	if s.Store != nil {
+		// the ticket outlives the store entry by at most a second, the expiry being truncated
+		if count, err := s.Store.Incr("recaptcha:stepup:"+fields[3], time.Unix(expiry+1, 0).Sub(s.clock())); err == nil && count > 1 {
+			return &Error{msg: "challenge ticket already used", kind: kindReplayedToken}
+		}
+	}
+	return nil
+}
+
+func (s *StepUp) ttl() time.Duration {
+	if s.TTL == 0 {
+		return DefaultTicketTTL
+	}
+	return s.TTL
+}
+
+func (s *StepUp) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
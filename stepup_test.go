@@ -0,0 +1,85 @@
+package recaptcha
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type StepUpSuite struct{}
+
+var _ = Suite(&StepUpSuite{})
+
+func lowScoreVerifier() mockVerifier {
+	return func(string, VerifyOption) error {
+		return &Error{msg: "received score '0.200000', while expecting minimum '0.500000'", kind: kindLowScore}
+	}
+}
+
+func (s *StepUpSuite) TestNewStepUp(c *C) {
+	_, err := NewStepUp(passVerifier(), passVerifier(), nil)
+	c.Check(err, ErrorMatches, "step-up ticket key cannot be blank")
+}
+
+func (s *StepUpSuite) TestStepUpPassesHighScores(c *C) {
+	stepUp, err := NewStepUp(passVerifier(), failVerifier("v2 should not be called"), []byte("key"))
+	c.Assert(err, IsNil)
+	c.Check(stepUp.Verify("v3code", VerifyOption{Action: "login"}), IsNil)
+
+	stepUp.V3 = failVerifier("invalid challenge solution")
+	c.Check(stepUp.Verify("v3code", VerifyOption{Action: "login"}), ErrorMatches, "invalid challenge solution")
+}
+
+func (s *StepUpSuite) TestStepUpEscalation(c *C) {
+	var v2Token string
+	v2 := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		v2Token = challengeResponse
+		return nil
+	})
+	stepUp, err := NewStepUp(lowScoreVerifier(), v2, []byte("key"))
+	c.Assert(err, IsNil)
+	options := VerifyOption{Action: "login", RemoteIP: "123.123.123.123"}
+
+	err = stepUp.Verify("v3code", options)
+	c.Assert(err, FitsTypeOf, &ChallengeRequiredError{})
+	c.Check(err, ErrorMatches, "challenge required: received score.*")
+	ticket := err.(*ChallengeRequiredError).Ticket
+
+	c.Check(stepUp.Escalate(ticket, "v2code", options), IsNil)
+	c.Check(v2Token, Equals, "v2code")
+
+	c.Check(stepUp.Escalate(ticket+"x", "v2code", options), ErrorMatches, "invalid challenge ticket")
+	c.Check(stepUp.Escalate("bogus", "v2code", options), ErrorMatches, "invalid challenge ticket")
+	c.Check(stepUp.Escalate(ticket, "v2code", VerifyOption{Action: "login", RemoteIP: "1.1.1.1"}), ErrorMatches,
+		"challenge ticket issued for action 'login' and remote IP '123.123.123.123'")
+
+	other, _ := NewStepUp(lowScoreVerifier(), v2, []byte("other key"))
+	c.Check(other.Escalate(ticket, "v2code", options), ErrorMatches, "invalid challenge ticket")
+
+	stepUp.now = func() time.Time { return time.Now().Add(DefaultTicketTTL + time.Minute) }
+	c.Check(stepUp.Escalate(ticket, "v2code", options), ErrorMatches, "expired challenge ticket")
+}
+
+func (s *StepUpSuite) TestStepUpSingleUseTickets(c *C) {
+	stepUp, _ := NewStepUp(lowScoreVerifier(), passVerifier(), []byte("key"))
+	stepUp.Store = NewMemoryStore()
+	options := VerifyOption{Action: "login", RemoteIP: "123.123.123.123"}
+	first := stepUp.Verify("v3code", options).(*ChallengeRequiredError).Ticket
+	second := stepUp.Verify("v3code", options).(*ChallengeRequiredError).Ticket
+
+	c.Check(stepUp.Escalate(first, "v2code", options), IsNil)
+	err := stepUp.Escalate(first, "v2code", options)
+	c.Check(err, ErrorMatches, "challenge ticket already used")
+	c.Check(errors.Is(err, ErrTokenReplayed), Equals, true)
+	c.Check(stepUp.Escalate(second, "v2code", options), IsNil)
+}
+
+func (s *StepUpSuite) TestStepUpWrappedLowScore(c *C) {
+	v3 := mockVerifier(func(challengeResponse string, options VerifyOption) error {
+		return fmt.Errorf("v3: %w", lowScoreVerifier()(challengeResponse, options))
+	})
+	stepUp, _ := NewStepUp(v3, passVerifier(), []byte("key"))
+	c.Check(stepUp.Verify("v3code", VerifyOption{}), FitsTypeOf, &ChallengeRequiredError{})
+}
@@ -0,0 +1,96 @@
+package recaptcha
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store key/value storage with expiration backing the stateful helpers (e.g. TrustStore, ReplayCache),
+// implement it to share state between several instances, see the redisstore package for a Redis
+// implementation.
+type Store interface {
+	// Get returns the value stored for the key and whether it was found and not expired.
+	Get(key string) ([]byte, bool, error)
+	// SetWithTTL stores the value for the key, expiring after ttl.
+	SetWithTTL(key string, value []byte, ttl time.Duration) error
+	// Incr atomically increments the decimal counter stored for the key and returns its new value,
+	// a missing counter starts at zero and expires after ttl.
+	Incr(key string, ttl time.Duration) (int64, error)
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore in-memory Store, only suitable for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemoryStore new empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get returns the value stored for the key and whether it was found and not expired.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.now().Before(entry.expires) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// SetWithTTL stores the value for the key, expiring after ttl. Expired entries are swept on write at
+// most once per minute.
+func (s *MemoryStore) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	s.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	return nil
+}
+
+// Incr atomically increments the counter stored for the key, keeping its expiration.
+func (s *MemoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = memoryEntry{value: []byte("0"), expires: now.Add(ttl)}
+	}
+	count, err := strconv.ParseInt(string(entry.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	s.entries[key] = entry
+	return count, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	for k, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.swept = now
+}
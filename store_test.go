@@ -0,0 +1,65 @@
+package recaptcha
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MemoryStoreSuite struct{}
+
+var _ = Suite(&MemoryStoreSuite{})
+
+func (s *MemoryStoreSuite) TestGetSet(c *C) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	_, ok, err := store.Get("key")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+
+	c.Assert(store.SetWithTTL("key", []byte("value"), time.Minute), IsNil)
+	value, ok, err := store.Get("key")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(string(value), Equals, "value")
+
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get("key")
+	c.Check(ok, Equals, false)
+}
+
+func (s *MemoryStoreSuite) TestSweep(c *C) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	store.SetWithTTL("first", []byte("value"), time.Second)
+	now = now.Add(2 * time.Minute)
+	store.SetWithTTL("second", []byte("value"), time.Second)
+	c.Check(store.entries, HasLen, 1)
+}
+
+func (s *MemoryStoreSuite) TestIncr(c *C) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	count, err := store.Incr("counter", time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, int64(1))
+	now = now.Add(30 * time.Second)
+	count, _ = store.Incr("counter", time.Minute)
+	c.Check(count, Equals, int64(2))
+	value, _, _ := store.Get("counter")
+	c.Check(string(value), Equals, "2")
+
+	now = now.Add(30 * time.Second)
+	count, _ = store.Incr("counter", time.Minute)
+	c.Check(count, Equals, int64(1))
+
+	store.SetWithTTL("text", []byte("text"), time.Minute)
+	_, err = store.Incr("text", time.Minute)
+	c.Check(err, NotNil)
+}
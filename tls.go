@@ -0,0 +1,50 @@
+package recaptcha
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// SetTLSConfig replaces the TLS configuration of the verification requests, e.g. for gateways
+// intercepting TLS or internal verification proxies. Set it before decorating the transport.
+func (r *ReCAPTCHA) SetTLSConfig(config *tls.Config) error {
+	if config == nil {
+		return fmt.Errorf("tls config cannot be nil")
+	}
+	return r.configureTransport(func(transport *http.Transport) {
+		transport.TLSClientConfig = config.Clone()
+	})
+}
+
+// SetRootCAs verifies the certificate of the verification endpoint against pool instead of the
+// system roots, e.g. the private CA of an internal verification proxy.
+func (r *ReCAPTCHA) SetRootCAs(pool *x509.CertPool) error {
+	if pool == nil {
+		return fmt.Errorf("root CA pool cannot be nil")
+	}
+	return r.configureTransport(func(transport *http.Transport) {
+		transport.TLSClientConfig = tlsConfig(transport)
+		transport.TLSClientConfig.RootCAs = pool
+	})
+}
+
+// SetClientCertificate presents certificate to verification endpoints requiring mutual TLS.
+func (r *ReCAPTCHA) SetClientCertificate(certificate tls.Certificate) error {
+	if len(certificate.Certificate) == 0 {
+		return fmt.Errorf("client certificate cannot be empty")
+	}
+	return r.configureTransport(func(transport *http.Transport) {
+		transport.TLSClientConfig = tlsConfig(transport)
+		transport.TLSClientConfig.Certificates = []tls.Certificate{certificate}
+	})
+}
+
+// tlsConfig returns a copy of the TLS configuration of transport, an empty one when not set.
+func tlsConfig(transport *http.Transport) *tls.Config {
+	if transport.TLSClientConfig == nil {
+		return &tls.Config{}
+	}
+	return transport.TLSClientConfig.Clone()
+}
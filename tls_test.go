@@ -0,0 +1,72 @@
+package recaptcha
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TLSSuite struct{}
+
+var _ = Suite(&TLSSuite{})
+
+// clientCertificate self-signed client certificate for commonName.
+func clientCertificate(c *C, commonName string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (s *TLSSuite) TestTLS(c *C) {
+	var clients []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.Write([]byte(`{"success": true}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Verify("token"), ErrorMatches, ".*certificate signed by unknown authority.*")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	c.Check(captcha.SetRootCAs(nil), ErrorMatches, "root CA pool cannot be nil")
+	c.Assert(captcha.SetRootCAs(pool), IsNil)
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json.*")
+
+	c.Check(captcha.SetClientCertificate(tls.Certificate{}), ErrorMatches, "client certificate cannot be empty")
+	c.Assert(captcha.SetClientCertificate(clientCertificate(c, "recaptcha-client")), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(clients, DeepEquals, []string{"recaptcha-client"})
+
+	c.Check(captcha.SetTLSConfig(nil), ErrorMatches, "tls config cannot be nil")
+	c.Assert(captcha.SetTLSConfig(&tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCertificate(c, "other-client")}}), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(clients, DeepEquals, []string{"recaptcha-client", "other-client"})
+}
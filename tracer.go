@@ -0,0 +1,11 @@
+package recaptcha
+
+import "context"
+
+// Tracer instruments the verifications, e.g. with distributed tracing spans, see the recaptchaotel
+// package for OpenTelemetry.
+type Tracer interface {
+	// Start is called before every verification with the API version and the options, the returned
+	// context is used for the verification request and end is called with the verification result.
+	Start(ctx context.Context, version VERSION, options VerifyOption) (traced context.Context, end func(response Response, err error))
+}
@@ -0,0 +1,277 @@
+package recaptcha
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Decorator wraps the transport of the verification requests, e.g. to log, measure or retry them.
+type Decorator func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapter allowing the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Chain returns base wrapped by the decorators, the first decorator being the outermost one, i.e.
+// the first to see the requests and the last to see the responses. http.DefaultTransport is used
+// when base is nil.
+func Chain(base http.RoundTripper, decorators ...Decorator) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(decorators) - 1; i >= 0; i-- {
+		base = decorators[i](base)
+	}
+	return base
+}
+
+// Decorate wraps the transport of the verification requests with the decorators, in the Chain order.
+// Decorators added by later calls wrap the earlier ones. The client is copied so that clients shared
+// with the rest of the application, see SetHTTPClient, are left untouched.
+func (r *ReCAPTCHA) Decorate(decorators ...Decorator) error {
+	client, ok := r.client.(*http.Client)
+	if !ok {
+		return fmt.Errorf("cannot decorate the transport of a custom client")
+	}
+	decorated := *client
+	decorated.Transport = Chain(client.Transport, decorators...)
+	r.client = &decorated
+	return nil
+}
+
+// WithHeaders Decorator adding the headers to every request, e.g. a User-Agent or the credentials
+// of a proxy in front of the verification endpoint.
+func WithHeaders(header http.Header) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// round trippers must not modify the request
+			clone := *req
+			clone.Header = make(http.Header, len(req.Header)+len(header))
+			for name, values := range req.Header {
+				clone.Header[name] = values
+			}
+			for name, values := range header {
+				clone.Header[name] = values
+			}
+			return next.RoundTrip(&clone)
+		})
+	}
+}
+
+// WithUserAgent Decorator setting the User-Agent of every request, e.g. "my-app/1.0" for egress
+// proxies filtering on it.
+func WithUserAgent(userAgent string) Decorator {
+	return WithHeaders(http.Header{"User-Agent": {userAgent}})
+}
+
+// RoundTripInfo describes a verification request passed to WithLogging and WithMetrics callbacks.
+type RoundTripInfo struct {
+	Method string
+	URL    string
+	// StatusCode of the response, zero when the request failed.
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// WithMetrics Decorator calling observe once every request completed.
+func WithMetrics(observe func(info RoundTripInfo)) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			info := RoundTripInfo{Method: req.Method, URL: req.URL.String(), Duration: time.Since(start), Err: err}
+			if resp != nil {
+				info.StatusCode = resp.StatusCode
+			}
+			observe(info)
+			return resp, err
+		})
+	}
+}
+
+// WithLogging Decorator logging every request with logf, e.g. log.Printf. The request body holding
+// the secret is never logged.
+func WithLogging(logf func(format string, args ...interface{})) Decorator {
+	return WithMetrics(func(info RoundTripInfo) {
+		if info.Err != nil {
+			logf("recaptcha: %s %s failed after %s: %s", info.Method, info.URL, info.Duration, info.Err)
+			return
+		}
+		logf("recaptcha: %s %s replied %d in %s", info.Method, info.URL, info.StatusCode, info.Duration)
+	})
+}
+
+// WithRetries Decorator retrying the requests failing or answered with a 5xx status up to attempts
+// times in total, waiting backoff after the first failure and doubling it after every other one.
+func WithRetries(attempts int, backoff time.Duration) Decorator {
+	return WithRetryPolicy(RetryPolicy{Attempts: attempts, Backoff: backoff})
+}
+
+// RetryPolicy retries of the transient verification request failures: connection errors and 5xx
+// statuses. Responses with error codes, such as timeout-or-duplicate, come with a 200 status and are
+// never retried, retrying a duplicate only getting it again.
+type RetryPolicy struct {
+	// Attempts maximum number of attempts, including the first one.
+	Attempts int
+	// Backoff wait after the first failure.
+	Backoff time.Duration
+	// Multiplier growth of the wait after every other failure, 2 when zero.
+	Multiplier float64
+	// MaxBackoff upper bound of the wait, unbounded when zero.
+	MaxBackoff time.Duration
+	// Jitter fraction of the wait randomly removed, between 0 and 1, spreading the retries of
+	// concurrent requests.
+	Jitter float64
+}
+
+// Retrier decides the retries of the verification requests, implement it for custom logic such as
+// retrying only connection errors. RetryPolicy is the default implementation.
+type Retrier interface {
+	// ShouldRetry reports whether the attempt, numbered from 1, should be retried given its transport
+	// error or, when nil, its response. Response bodies read to decide must be restored.
+	ShouldRetry(attempt int, err error, resp *http.Response) bool
+	// NextDelay returns the wait after the failed attempt.
+	NextDelay(attempt int) time.Duration
+}
+
+// WithRetryPolicy Decorator retrying the transient failures according to policy.
+func WithRetryPolicy(policy RetryPolicy) Decorator {
+	return WithRetrier(policy)
+}
+
+// WithRetrier Decorator retrying the failures selected by retrier. Retries whose wait would end
+// after the request deadline aren't attempted, the last failure being returned right away.
+func WithRetrier(retrier Retrier) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(req.Body); err != nil {
+					return nil, err
+				}
+				req.Body.Close()
+			}
+
+			for attempt := 1; ; attempt++ {
+				clone := *req
+				if body != nil {
+					clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
+				resp, err := next.RoundTrip(&clone)
+				if !retrier.ShouldRetry(attempt, err, resp) {
+					return resp, err
+				}
+				wait := retrier.NextDelay(attempt)
+				if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+					return resp, err
+				}
+				if resp != nil {
+					resp.Body.Close()
+				}
+				select {
+				case <-time.After(wait):
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+		})
+	}
+}
+
+// ShouldRetry retries the connection errors and 5xx statuses until Attempts is reached.
+func (p RetryPolicy) ShouldRetry(attempt int, err error, resp *http.Response) bool {
+	return attempt < p.Attempts && (err != nil || resp.StatusCode >= http.StatusInternalServerError)
+}
+
+// NextDelay returns Backoff grown by Multiplier for every other failure, bounded by MaxBackoff and
+// reduced by the jitter.
+func (p RetryPolicy) NextDelay(attempt int) time.Duration {
+	return p.wait(attempt, rand.Float64())
+}
+
+// wait returns the wait after the failed attempt, random in [0, 1) drawing the jitter.
+func (p RetryPolicy) wait(attempt int, random float64) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	wait := float64(p.Backoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	return time.Duration(wait * (1 - p.Jitter*random))
+}
+
+const (
+	// SignatureHeader request header holding the signature set by WithSigning
+	SignatureHeader = "X-Recaptcha-Signature"
+	// SignatureTimestampHeader request header holding the unix time at which WithSigning signed the request
+	SignatureTimestampHeader = "X-Recaptcha-Timestamp"
+)
+
+// WithSigning Decorator signing every request for gateways which must authenticate their callers
+// before forwarding the verification requests. The SignatureHeader holds the base64 url encoded
+// HMAC-SHA256 of the SignatureTimestampHeader value, a dot and the body, computed with key which
+// should differ from the recaptcha secret. Gateways written in Go can use CheckSignature.
+func WithSigning(key []byte) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(req.Body); err != nil {
+					return nil, err
+				}
+				req.Body.Close()
+			}
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+			clone := *req
+			clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+			clone.Header = make(http.Header, len(req.Header)+2)
+			for name, values := range req.Header {
+				clone.Header[name] = values
+			}
+			clone.Header.Set(SignatureTimestampHeader, timestamp)
+			clone.Header.Set(SignatureHeader, signature(key, timestamp+"."+string(body)))
+			return next.RoundTrip(&clone)
+		})
+	}
+}
+
+// CheckSignature returns `nil` if the request was signed by WithSigning with key less than maxAge
+// ago, allowing the same clock skew in the future. The body is read and restored.
+func CheckSignature(r *http.Request, key []byte, maxAge time.Duration) error {
+	timestamp := r.Header.Get(SignatureTimestampHeader)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid signature timestamp '%s'", timestamp)
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("request signed '%fs' ago, while expecting maximum '%fs'", age.Seconds(), maxAge.Seconds())
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("couldn't read request body: '%s'", err)
+		}
+		r.Body.Close()
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if !hmac.Equal([]byte(signature(key, timestamp+"."+string(body))), []byte(r.Header.Get(SignatureHeader))) {
+		return fmt.Errorf("invalid request signature")
+	}
+	return nil
+}
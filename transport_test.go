@@ -0,0 +1,273 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TransportSuite struct{}
+
+var _ = Suite(&TransportSuite{})
+
+// tracing Decorator appending its name to the trace before and after the request.
+func tracing(name string, trace *[]string) Decorator {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*trace = append(*trace, ">"+name)
+			resp, err := next.RoundTrip(req)
+			*trace = append(*trace, "<"+name)
+			return resp, err
+		})
+	}
+}
+
+func (s *TransportSuite) TestDecorate(c *C) {
+	var failures int
+	var secrets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("User-Agent"), Equals, "my-app/1.0")
+		secrets = append(secrets, r.FormValue("secret"))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+
+	var trace, logs []string
+	var infos []RoundTripInfo
+	c.Assert(captcha.Decorate(tracing("a", &trace), WithHeaders(http.Header{"User-Agent": {"my-app/1.0"}})), IsNil)
+	c.Assert(captcha.Decorate(tracing("b", &trace), WithRetries(3, time.Millisecond), WithMetrics(func(info RoundTripInfo) { infos = append(infos, info) })), IsNil)
+	c.Assert(captcha.Decorate(WithLogging(func(format string, args ...interface{}) { logs = append(logs, fmt.Sprintf(format, args...)) })), IsNil)
+
+	failures = 2
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(trace, DeepEquals, []string{">b", ">a", "<a", ">a", "<a", ">a", "<a", "<b"})
+	c.Check(secrets, DeepEquals, []string{"secret", "secret", "secret"})
+	c.Assert(infos, HasLen, 3)
+	c.Check(infos[0].StatusCode, Equals, http.StatusServiceUnavailable)
+	c.Check(infos[2].StatusCode, Equals, http.StatusOK)
+	c.Assert(logs, HasLen, 1)
+	c.Check(logs[0], Matches, "recaptcha: POST http://.* replied 200 in .*")
+
+	failures = 3
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json.*")
+
+	captcha.client = &mockInvalidClient{}
+	c.Check(captcha.Decorate(WithRetries(3, time.Millisecond)), ErrorMatches, "cannot decorate the transport of a custom client")
+}
+
+func (s *TransportSuite) TestHeaders(c *C) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithUserAgent("my-app/1.0"), WithHeaders(http.Header{"Traceparent": {"00-trace-span-01"}})), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(header.Get("User-Agent"), Equals, "my-app/1.0")
+	c.Check(header.Get("Traceparent"), Equals, "00-trace-span-01")
+	c.Check(header.Get("Content-Type"), Equals, "application/x-www-form-urlencoded")
+}
+
+func (s *TransportSuite) TestRetryPolicy(c *C) {
+	policy := RetryPolicy{Attempts: 5, Backoff: 100 * time.Millisecond}
+	c.Check(policy.wait(1, 0.5), Equals, 100*time.Millisecond)
+	c.Check(policy.wait(3, 0.5), Equals, 400*time.Millisecond)
+
+	policy = RetryPolicy{Attempts: 5, Backoff: 100 * time.Millisecond, Multiplier: 3, MaxBackoff: time.Second, Jitter: 0.5}
+	c.Check(policy.wait(2, 0), Equals, 300*time.Millisecond)
+	c.Check(policy.wait(2, 1), Equals, 150*time.Millisecond)
+	c.Check(policy.wait(4, 0), Equals, time.Second)
+
+	var requests int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Millisecond, Jitter: 1})), IsNil)
+
+	body = `{"success": true}`
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(requests, Equals, 2)
+
+	// error codes aren't transient
+	requests = 1
+	body = `{"success": false, "error-codes": ["timeout-or-duplicate"]}`
+	c.Check(captcha.Verify("token"), ErrorMatches, `remote error codes: \[timeout-or-duplicate\]`)
+	c.Check(requests, Equals, 2)
+}
+
+// networkRetrier retries the connection errors only, without waiting.
+type networkRetrier struct{ attempts []int }
+
+func (r *networkRetrier) ShouldRetry(attempt int, err error, resp *http.Response) bool {
+	r.attempts = append(r.attempts, attempt)
+	return err != nil && attempt < 3
+}
+
+func (r *networkRetrier) NextDelay(attempt int) time.Duration { return 0 }
+
+func (s *TransportSuite) TestRetrier(c *C) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	retrier := &networkRetrier{}
+	c.Assert(captcha.Decorate(WithRetrier(retrier)), IsNil)
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid response body json.*")
+	c.Check(requests, Equals, 1)
+	c.Check(retrier.attempts, DeepEquals, []int{1})
+
+	captcha.ReCAPTCHALink = "http://127.0.0.1:1"
+	c.Check(captcha.Verify("token"), ErrorMatches, "error posting to recaptcha endpoint: .*")
+	c.Check(retrier.attempts, DeepEquals, []int{1, 1, 2, 3})
+
+	// waits ending after the deadline aren't attempted
+	captcha, _ = NewReCAPTCHA("secret", V2, time.Second)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Second})), IsNil)
+	requests = 0
+	start := time.Now()
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Timeout: 200 * time.Millisecond}), ErrorMatches, "invalid response body json.*")
+	c.Check(requests, Equals, 1)
+	c.Check(time.Since(start) < 200*time.Millisecond, Equals, true)
+}
+
+func (s *TransportSuite) TestSigning(c *C) {
+	key := []byte("signing key")
+	var checkErr error
+	var secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkErr = CheckSignature(r, key, time.Minute)
+		secret = r.FormValue("secret")
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, time.Second)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithSigning(key)), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(checkErr, IsNil)
+	c.Check(secret, Equals, "secret")
+
+	// headers altered after signing
+	captcha, _ = NewReCAPTCHA("secret", V2, time.Second)
+	captcha.ReCAPTCHALink = server.URL
+	c.Assert(captcha.Decorate(WithSigning(key), WithHeaders(http.Header{SignatureTimestampHeader: {"0"}})), IsNil)
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(checkErr, ErrorMatches, "request signed '.*s' ago, while expecting maximum '60.000000s'")
+}
+
+func (s *TransportSuite) TestCheckSignature(c *C) {
+	key := []byte("signing key")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request := func(body, signed string) *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set(SignatureTimestampHeader, timestamp)
+		r.Header.Set(SignatureHeader, signature(key, timestamp+"."+signed))
+		return r
+	}
+
+	r := request("secret=s&response=t", "secret=s&response=t")
+	c.Check(CheckSignature(r, key, time.Minute), IsNil)
+	c.Check(r.FormValue("response"), Equals, "t")
+	c.Check(CheckSignature(request("secret=s&response=x", "secret=s&response=t"), key, time.Minute), ErrorMatches, "invalid request signature")
+	c.Check(CheckSignature(request("", ""), []byte("other key"), time.Minute), ErrorMatches, "invalid request signature")
+
+	r = request("", "")
+	r.Header.Del(SignatureTimestampHeader)
+	c.Check(CheckSignature(r, key, time.Minute), ErrorMatches, "invalid signature timestamp ''")
+}
+
+func (s *TransportSuite) TestHTTPClient(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "my-app/1.0" {
+			w.Write([]byte(`{"success": false}`))
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	_, err := NewReCAPTCHAWithClient("secret", V2, nil)
+	c.Check(err, ErrorMatches, "recaptcha http client cannot be nil")
+
+	var trace []string
+	client := &http.Client{Timeout: 3 * time.Second, Transport: Chain(nil, tracing("app", &trace), WithHeaders(http.Header{"User-Agent": {"my-app/1.0"}}))}
+	captcha, err := NewReCAPTCHAWithClient("secret", V2, client)
+	c.Assert(err, IsNil)
+	c.Check(captcha.Timeout, Equals, 3*time.Second)
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(trace, DeepEquals, []string{">app", "<app"})
+
+	// the shared client isn't modified by Decorate
+	c.Assert(captcha.Decorate(tracing("recaptcha", &trace)), IsNil)
+	trace = nil
+	c.Check(captcha.Verify("token"), IsNil)
+	c.Check(trace, DeepEquals, []string{">recaptcha", ">app", "<app", "<recaptcha"})
+	trace = nil
+	resp, err := client.Get(server.URL)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Check(trace, DeepEquals, []string{">app", "<app"})
+
+	c.Check(captcha.SetHTTPClient(nil), ErrorMatches, "recaptcha http client cannot be nil")
+	c.Assert(captcha.SetHTTPClient(&http.Client{Timeout: time.Second}), IsNil)
+	c.Check(captcha.Timeout, Equals, time.Second)
+	c.Check(captcha.Verify("token"), ErrorMatches, "invalid challenge solution")
+}
+
+func (s *TransportSuite) TestPerCallTimeout(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	captcha, err := NewReCAPTCHA("secret", V2, 20*time.Millisecond)
+	c.Assert(err, IsNil)
+	captcha.ReCAPTCHALink = server.URL
+	c.Check(captcha.Verify("token"), ErrorMatches, "error posting to recaptcha endpoint: .*")
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Timeout: time.Second}), IsNil)
+
+	c.Assert(captcha.SetHTTPClient(&http.Client{Timeout: time.Second}), IsNil)
+	c.Check(captcha.VerifyWithOptions("token", VerifyOption{Timeout: 20 * time.Millisecond}), ErrorMatches, "error posting to recaptcha endpoint: .*context deadline exceeded.*")
+}
@@ -0,0 +1,38 @@
+package recaptcha
+
+import (
+	"fmt"
+	"time"
+)
+
+// TrustStore remembers sessions or devices which recently passed a verification, letting Middleware
+// skip the verification of their subsequent requests until the TTL expires.
+type TrustStore struct {
+	Store Store
+	// TTL duration a session stays trusted after a successful verification.
+	TTL time.Duration
+	// Prefix prepended to the session identifiers to build the store keys.
+	Prefix string
+}
+
+// NewTrustStore new TrustStore keeping sessions trusted for ttl in store
+func NewTrustStore(store Store, ttl time.Duration) (*TrustStore, error) {
+	if store == nil {
+		return nil, fmt.Errorf("trust store backend cannot be nil")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("trust store ttl must be positive")
+	}
+	return &TrustStore{Store: store, TTL: ttl, Prefix: "recaptcha:trust:"}, nil
+}
+
+// Trust marks the session as trusted for the TTL.
+func (t *TrustStore) Trust(session string) error {
+	return t.Store.SetWithTTL(t.Prefix+session, []byte{1}, t.TTL)
+}
+
+// Trusted reports whether the session is currently trusted.
+func (t *TrustStore) Trusted(session string) (bool, error) {
+	_, ok, err := t.Store.Get(t.Prefix + session)
+	return ok, err
+}
@@ -0,0 +1,35 @@
+package recaptcha
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TrustStoreSuite struct{}
+
+var _ = Suite(&TrustStoreSuite{})
+
+func (s *TrustStoreSuite) TestNewTrustStore(c *C) {
+	_, err := NewTrustStore(nil, time.Minute)
+	c.Check(err, ErrorMatches, "trust store backend cannot be nil")
+	_, err = NewTrustStore(NewMemoryStore(), 0)
+	c.Check(err, ErrorMatches, "trust store ttl must be positive")
+}
+
+func (s *TrustStoreSuite) TestTrust(c *C) {
+	store := NewMemoryStore()
+	trust, err := NewTrustStore(store, time.Minute)
+	c.Assert(err, IsNil)
+
+	trusted, err := trust.Trusted("session")
+	c.Assert(err, IsNil)
+	c.Check(trusted, Equals, false)
+
+	c.Assert(trust.Trust("session"), IsNil)
+	trusted, err = trust.Trusted("session")
+	c.Assert(err, IsNil)
+	c.Check(trusted, Equals, true)
+	_, ok, _ := store.Get("recaptcha:trust:session")
+	c.Check(ok, Equals, true)
+}
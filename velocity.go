@@ -0,0 +1,115 @@
+package recaptcha
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// VelocityTracker counts verification failures per client IP over a sliding window and locks out the
+// IPs exceeding the allowed number of failures, damping credential stuffing cycling through tokens.
+// The sliding window is approximated from the counts of the current and previous fixed windows.
+type VelocityTracker struct {
+	Store Store
+	// Window duration over which failures are counted.
+	Window time.Duration
+	// MaxFailures number of failures within Window after which the IP is locked out.
+	MaxFailures int64
+	// Prefix prepended to the IPs to build the store keys.
+	Prefix string
+
+	now func() time.Time
+}
+
+// NewVelocityTracker new VelocityTracker locking out IPs failing maxFailures times within window
+func NewVelocityTracker(store Store, window time.Duration, maxFailures int64) (*VelocityTracker, error) {
+	if store == nil {
+		return nil, fmt.Errorf("velocity tracker store cannot be nil")
+	}
+	if window <= 0 || maxFailures <= 0 {
+		return nil, fmt.Errorf("velocity tracker window and maximum failures must be positive")
+	}
+	return &VelocityTracker{Store: store, Window: window, MaxFailures: maxFailures, Prefix: "recaptcha:velocity:", now: time.Now}, nil
+}
+
+// clientErrorCodes recaptcha error codes caused by the client token, the other ones by the server
+// configuration or recaptcha itself.
+var clientErrorCodes = []string{"missing-input-response", "invalid-input-response", "timeout-or-duplicate"}
+
+// clientFailure reports whether the verification failure is caused by the client (invalid or reused
+// token, low score, action or hostname mismatch...), only these failures counting towards lockouts.
+// Request failures, open circuits, misconfigurations and unknown errors never do, so an outage of
+// the verification endpoint doesn't lock every client out.
+func clientFailure(err error) bool {
+	var verr *Error
+	if !errors.As(err, &verr) || verr.RequestError {
+		return false
+	}
+	switch verr.kind {
+	case kindRequestFailed, kindCircuitOpen, kindVersionMismatch, kindInvalidAction, kindFutureChallenge,
+		kindMissingChallengeTS, kindUnexpectedResponse:
+		return false
+	case kindRemoteErrors:
+		for _, code := range verr.ErrorCodes {
+			if !containsString(clientErrorCodes, code) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Fail records a verification failure for the IP.
+func (v *VelocityTracker) Fail(ip string) error {
+	_, err := v.Store.Incr(v.key(ip, v.bucket(v.clock())), 2*v.Window)
+	return err
+}
+
+// Locked reports whether the IP failed at least MaxFailures times within the last Window.
+func (v *VelocityTracker) Locked(ip string) (bool, error) {
+	failures, err := v.Failures(ip)
+	if err != nil {
+		return false, err
+	}
+	return failures >= float64(v.MaxFailures), nil
+}
+
+// Failures returns the estimated number of failures of the IP within the last Window.
+func (v *VelocityTracker) Failures(ip string) (float64, error) {
+	now := v.clock()
+	bucket := v.bucket(now)
+	current, err := v.count(v.key(ip, bucket))
+	if err != nil {
+		return 0, err
+	}
+	previous, err := v.count(v.key(ip, bucket-1))
+	if err != nil {
+		return 0, err
+	}
+	elapsed := float64(now.UnixNano()%int64(v.Window)) / float64(v.Window)
+	return float64(previous)*(1-elapsed) + float64(current), nil
+}
+
+func (v *VelocityTracker) count(key string) (int64, error) {
+	value, ok, err := v.Store.Get(key)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+func (v *VelocityTracker) bucket(t time.Time) int64 {
+	return t.UnixNano() / int64(v.Window)
+}
+
+func (v *VelocityTracker) key(ip string, bucket int64) string {
+	return v.Prefix + ip + ":" + strconv.FormatInt(bucket, 10)
+}
+
+func (v *VelocityTracker) clock() time.Time {
+	if v.now == nil {
+		return time.Now()
+	}
+	return v.now()
+}
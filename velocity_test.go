@@ -0,0 +1,54 @@
+package recaptcha
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type VelocitySuite struct{}
+
+var _ = Suite(&VelocitySuite{})
+
+func (s *VelocitySuite) TestNewVelocityTracker(c *C) {
+	_, err := NewVelocityTracker(nil, time.Minute, 5)
+	c.Check(err, ErrorMatches, "velocity tracker store cannot be nil")
+	_, err = NewVelocityTracker(NewMemoryStore(), time.Minute, 0)
+	c.Check(err, ErrorMatches, "velocity tracker window and maximum failures must be positive")
+}
+
+func (s *VelocitySuite) TestSlidingWindow(c *C) {
+	now := time.Unix(600, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	velocity, err := NewVelocityTracker(store, time.Minute, 3)
+	c.Assert(err, IsNil)
+	velocity.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		c.Assert(velocity.Fail("1.2.3.4"), IsNil)
+	}
+	locked, err := velocity.Locked("1.2.3.4")
+	c.Assert(err, IsNil)
+	c.Check(locked, Equals, false)
+
+	velocity.Fail("1.2.3.4")
+	locked, _ = velocity.Locked("1.2.3.4")
+	c.Check(locked, Equals, true)
+	locked, _ = velocity.Locked("5.6.7.8")
+	c.Check(locked, Equals, false)
+
+	// half way through the next window half of the previous failures still count
+	now = now.Add(90 * time.Second)
+	failures, err := velocity.Failures("1.2.3.4")
+	c.Assert(err, IsNil)
+	c.Check(failures, Equals, 1.5)
+	velocity.Fail("1.2.3.4")
+	velocity.Fail("1.2.3.4")
+	locked, _ = velocity.Locked("1.2.3.4")
+	c.Check(locked, Equals, true)
+
+	now = now.Add(2 * time.Minute)
+	locked, _ = velocity.Locked("1.2.3.4")
+	c.Check(locked, Equals, false)
+}
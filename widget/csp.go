@@ -0,0 +1,72 @@
+package widget
+
+import (
+	"strings"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+const (
+	// gstatic host serving the recaptcha scripts loaded by the JavaScript API
+	gstatic = "https://www.gstatic.com/recaptcha/"
+	// turnstile host serving the Turnstile script and challenge frames
+	turnstile = "https://challenges.cloudflare.com"
+)
+
+// hcaptcha hosts serving the hCaptcha script, challenge frames and assets
+var hcaptcha = []string{"https://hcaptcha.com", "https://*.hcaptcha.com"}
+
+// CSP Content-Security-Policy sources required by the widgets, to add to the application policy.
+type CSP struct {
+	ScriptSrc  []string
+	FrameSrc   []string
+	ConnectSrc []string
+}
+
+// NewCSP returns the sources required to load the widgets from the endpoint, Google when blank.
+func NewCSP(endpoint Endpoint) CSP {
+	if endpoint == "" {
+		endpoint = Google
+	}
+	recaptcha := string(endpoint) + "/recaptcha/"
+	csp := CSP{
+		ScriptSrc:  []string{recaptcha, gstatic},
+		FrameSrc:   []string{recaptcha},
+		ConnectSrc: []string{recaptcha},
+	}
+	if endpoint == Google {
+		// challenges may be served from the recaptcha.google.com frame
+		csp.FrameSrc = append(csp.FrameSrc, "https://recaptcha.google.com/recaptcha/")
+	}
+	return csp
+}
+
+// NewProviderCSP returns the sources required to load the widgets of the provider configured in
+// ReCAPTCHA.Provider, endpoint only applying to recaptcha (nil provider or ReCAPTCHAProvider). The
+// Turnstile and hCaptcha providers are recognized by their Link.
+func NewProviderCSP(provider recaptcha.Provider, endpoint Endpoint) CSP {
+	if provider == nil {
+		return NewCSP(endpoint)
+	}
+	switch provider.Link() {
+	case recaptcha.TurnstileLink:
+		return CSP{ScriptSrc: []string{turnstile}, FrameSrc: []string{turnstile}}
+	case recaptcha.HCaptchaLink:
+		return CSP{ScriptSrc: hcaptcha, FrameSrc: hcaptcha, ConnectSrc: hcaptcha}
+	}
+	return NewCSP(endpoint)
+}
+
+// String formats the sources as policy directives, e.g. "script-src https://www.google.com/recaptcha/ ...".
+func (c CSP) String() string {
+	directives := make([]string, 0, 3)
+	for _, d := range []struct {
+		name    string
+		sources []string
+	}{{"script-src", c.ScriptSrc}, {"frame-src", c.FrameSrc}, {"connect-src", c.ConnectSrc}} {
+		if len(d.sources) > 0 {
+			directives = append(directives, d.name+" "+strings.Join(d.sources, " "))
+		}
+	}
+	return strings.Join(directives, "; ")
+}
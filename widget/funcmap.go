@@ -0,0 +1,66 @@
+package widget
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+)
+
+// FuncMap returns template functions rendering the snippets for siteKey, loaded from endpoint:
+//
+//	recaptchaSiteKey                    the site key
+//	recaptchaScript                     the V2 JavaScript API include, see Script
+//	recaptchaWidget [name value]...     a V2 widget, attributes given as pairs, e.g. "theme" "dark"
+//	recaptchaV3Script                   the V3 JavaScript API include, see V3.Script
+//	recaptchaExecute action formID      the V3 execute snippet, see V3.Execute
+//
+// Widget attribute names are theme, size, badge, tabindex, callback, expired-callback and error-callback.
+func FuncMap(siteKey string, endpoint Endpoint) template.FuncMap {
+	v3 := &V3{SiteKey: siteKey, Endpoint: endpoint}
+	return template.FuncMap{
+		"recaptchaSiteKey": func() string { return siteKey },
+		"recaptchaScript":  func() template.HTML { return Script(endpoint) },
+		"recaptchaWidget": func(attributes ...string) (template.HTML, error) {
+			widget, err := widgetWithAttributes(siteKey, attributes)
+			if err != nil {
+				return "", err
+			}
+			return widget.HTML()
+		},
+		"recaptchaV3Script": v3.Script,
+		"recaptchaExecute":  v3.Execute,
+	}
+}
+
+func widgetWithAttributes(siteKey string, attributes []string) (*V2, error) {
+	if len(attributes)%2 != 0 {
+		return nil, fmt.Errorf("widget attributes must be name and value pairs")
+	}
+	widget := &V2{SiteKey: siteKey}
+	for i := 0; i < len(attributes); i += 2 {
+		name, value := attributes[i], attributes[i+1]
+		switch name {
+		case "theme":
+			widget.Theme = value
+		case "size":
+			widget.Size = value
+		case "badge":
+			widget.Badge = value
+		case "tabindex":
+			tabIndex, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid widget tabindex '%s'", value)
+			}
+			widget.TabIndex = tabIndex
+		case "callback":
+			widget.Callback = value
+		case "expired-callback":
+			widget.ExpiredCallback = value
+		case "error-callback":
+			widget.ErrorCallback = value
+		default:
+			return nil, fmt.Errorf("unknown widget attribute '%s'", name)
+		}
+	}
+	return widget, nil
+}
@@ -0,0 +1,84 @@
+package widget
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+var v3ScriptTemplate = template.Must(template.New("v3script").Parse(`<script src="{{.URL}}?render={{.SiteKey}}"></script>`))
+
+// executeTemplate intercepts the form submission to fetch a fresh token, tokens expiring after two minutes.
+var executeTemplate = template.Must(template.New("execute").Parse(`<script>
+grecaptcha.ready(function() {
+  document.getElementById({{.FormID}}).addEventListener("submit", function(event) {
+    event.preventDefault();
+    var form = this;
+    grecaptcha.execute({{.SiteKey}}, {action: {{.Action}}}).then(function(token) {
+      var input = form.querySelector("input[name='" + {{.Field}} + "']");
+      if (!input) {
+        input = document.createElement("input");
+        input.type = "hidden";
+        input.name = {{.Field}};
+        form.appendChild(input);
+      }
+      input.value = token;
+      form.submit();
+    });
+  });
+});
+</script>`))
+
+// V3 generates the recaptcha v3 snippets for server-rendered pages.
+type V3 struct {
+	SiteKey string
+	// Endpoint serving the JavaScript API, Google when blank.
+	Endpoint Endpoint
+	// Field form field receiving the token, recaptcha.DefaultResponseField when blank.
+	Field string
+}
+
+// NewV3 new V3 for siteKey loading the JavaScript API from endpoint
+func NewV3(siteKey string, endpoint Endpoint) (*V3, error) {
+	if siteKey == "" {
+		return nil, fmt.Errorf("recaptcha site key cannot be blank")
+	}
+	return &V3{SiteKey: siteKey, Endpoint: endpoint}, nil
+}
+
+// Script returns the script element loading the JavaScript API bound to the site key, to include once per page.
+func (v *V3) Script() template.HTML {
+	var buf bytes.Buffer
+	v3ScriptTemplate.Execute(&buf, struct{ URL, SiteKey string }{v.Endpoint.ScriptURL(), v.SiteKey})
+	return template.HTML(buf.String())
+}
+
+// Execute returns a script element executing the action when the form with the given ID is submitted,
+// the token being set in a hidden field before the form is actually submitted.
+func (v *V3) Execute(action, formID string) (template.HTML, error) {
+	if v.SiteKey == "" {
+		return "", fmt.Errorf("recaptcha site key cannot be blank")
+	}
+	if action == "" {
+		return "", fmt.Errorf("action cannot be blank")
+	}
+	if err := recaptcha.ValidateAction(action); err != nil {
+		return "", err
+	}
+	if formID == "" {
+		return "", fmt.Errorf("form ID cannot be blank")
+	}
+	field := v.Field
+	if field == "" {
+		field = recaptcha.DefaultResponseField
+	}
+
+	var buf bytes.Buffer
+	err := executeTemplate.Execute(&buf, struct{ SiteKey, Action, FormID, Field string }{v.SiteKey, action, formID, field})
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,129 @@
+// Package widget renders the recaptcha frontend snippets with html/template, keeping them consistent
+// with the backend configuration.
+package widget
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+)
+
+// Endpoint origin serving the recaptcha JavaScript API
+type Endpoint string
+
+const (
+	// Google default endpoint
+	Google Endpoint = "https://www.google.com"
+	// RecaptchaNet endpoint for clients that can't reach google.com
+	RecaptchaNet Endpoint = "https://www.recaptcha.net"
+)
+
+// ScriptURL returns the URL of the recaptcha JavaScript API, served by Google when the endpoint is blank.
+func (e Endpoint) ScriptURL() string {
+	if e == "" {
+		e = Google
+	}
+	return string(e) + "/recaptcha/api.js"
+}
+
+// callbackName matches global JavaScript function names, optionally namespaced (app.onVerified).
+var callbackName = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+var widgetTemplate = template.Must(template.New("widget").Parse(
+	`<div class="g-recaptcha" data-sitekey="{{.SiteKey}}"` +
+		`{{with .Theme}} data-theme="{{.}}"{{end}}` +
+		`{{with .Size}} data-size="{{.}}"{{end}}` +
+		`{{with .Badge}} data-badge="{{.}}"{{end}}` +
+		`{{with .TabIndex}} data-tabindex="{{.}}"{{end}}` +
+		`{{with .Callback}} data-callback="{{.}}"{{end}}` +
+		`{{with .ExpiredCallback}} data-expired-callback="{{.}}"{{end}}` +
+		`{{with .ErrorCallback}} data-error-callback="{{.}}"{{end}}` +
+		`></div>`))
+
+var scriptTemplate = template.Must(template.New("script").Parse(`<script src="{{.}}" async defer></script>`))
+
+// V2 recaptcha v2 checkbox or invisible widget.
+type V2 struct {
+	SiteKey string
+	// Theme "light" or "dark", light when blank.
+	Theme string
+	// Size "normal", "compact" or "invisible", normal when blank.
+	Size string
+	// Badge position of the invisible widget badge: "bottomright", "bottomleft" or "inline".
+	Badge    string
+	TabIndex int
+	// Callback, ExpiredCallback and ErrorCallback names of global JavaScript functions called when
+	// the challenge is solved, expires or fails. Callback is required by invisible widgets.
+	Callback        string
+	ExpiredCallback string
+	ErrorCallback   string
+}
+
+// NewV2 new V2 checkbox widget for siteKey
+func NewV2(siteKey string) (*V2, error) {
+	if siteKey == "" {
+		return nil, fmt.Errorf("recaptcha site key cannot be blank")
+	}
+	return &V2{SiteKey: siteKey}, nil
+}
+
+// Validate checks the widget attributes against the values accepted by recaptcha.
+func (w *V2) Validate() error {
+	if w.SiteKey == "" {
+		return fmt.Errorf("recaptcha site key cannot be blank")
+	}
+	if !oneOf(w.Theme, "", "light", "dark") {
+		return fmt.Errorf("invalid widget theme '%s', while expecting 'light' or 'dark'", w.Theme)
+	}
+	if !oneOf(w.Size, "", "normal", "compact", "invisible") {
+		return fmt.Errorf("invalid widget size '%s', while expecting 'normal', 'compact' or 'invisible'", w.Size)
+	}
+	if !oneOf(w.Badge, "", "bottomright", "bottomleft", "inline") {
+		return fmt.Errorf("invalid widget badge '%s', while expecting 'bottomright', 'bottomleft' or 'inline'", w.Badge)
+	}
+	if w.Size == "invisible" && w.Callback == "" {
+		return fmt.Errorf("invisible widgets require a callback")
+	}
+	for _, name := range []string{w.Callback, w.ExpiredCallback, w.ErrorCallback} {
+		if name != "" && !callbackName.MatchString(name) {
+			return fmt.Errorf("invalid callback name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// Render writes the widget element to out.
+func (w *V2) Render(out io.Writer) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	return widgetTemplate.Execute(out, w)
+}
+
+// HTML returns the widget element, for use in html/template templates.
+func (w *V2) HTML() (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := w.Render(&buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// Script returns the script element loading the recaptcha JavaScript API from the endpoint, Google
+// when blank, to include once per page along with V2 widgets.
+func Script(endpoint Endpoint) template.HTML {
+	var buf bytes.Buffer
+	scriptTemplate.Execute(&buf, endpoint.ScriptURL())
+	return template.HTML(buf.String())
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package widget
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	recaptcha "gopkg.in/ezzarghili/recaptcha-go.v4"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type WidgetSuite struct{}
+
+var _ = Suite(&WidgetSuite{})
+
+func (s *WidgetSuite) TestNewV2(c *C) {
+	_, err := NewV2("")
+	c.Check(err, ErrorMatches, "recaptcha site key cannot be blank")
+
+	widget, err := NewV2("site-key")
+	c.Assert(err, IsNil)
+	html, err := widget.HTML()
+	c.Assert(err, IsNil)
+	c.Check(html, Equals, template.HTML(`<div class="g-recaptcha" data-sitekey="site-key"></div>`))
+}
+
+func (s *WidgetSuite) TestV2Attributes(c *C) {
+	widget := &V2{
+		SiteKey:         "site-key",
+		Theme:           "dark",
+		Size:            "compact",
+		TabIndex:        3,
+		Callback:        "app.onVerified",
+		ExpiredCallback: "onExpired",
+		ErrorCallback:   "onError",
+	}
+	var buf bytes.Buffer
+	c.Assert(widget.Render(&buf), IsNil)
+	c.Check(buf.String(), Equals, `<div class="g-recaptcha" data-sitekey="site-key" data-theme="dark" data-size="compact" data-tabindex="3" `+
+		`data-callback="app.onVerified" data-expired-callback="onExpired" data-error-callback="onError"></div>`)
+}
+
+func (s *WidgetSuite) TestV2Escaping(c *C) {
+	html, err := (&V2{SiteKey: `"><script>alert(1)</script>`}).HTML()
+	c.Assert(err, IsNil)
+	c.Check(html, Equals, template.HTML(`<div class="g-recaptcha" data-sitekey="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;"></div>`))
+}
+
+func (s *WidgetSuite) TestV2Validate(c *C) {
+	c.Check((&V2{SiteKey: "k", Theme: "blue"}).Validate(), ErrorMatches, "invalid widget theme 'blue', while expecting 'light' or 'dark'")
+	c.Check((&V2{SiteKey: "k", Size: "huge"}).Validate(), ErrorMatches, "invalid widget size 'huge'.*")
+	c.Check((&V2{SiteKey: "k", Badge: "top"}).Validate(), ErrorMatches, "invalid widget badge 'top'.*")
+	c.Check((&V2{SiteKey: "k", Size: "invisible"}).Validate(), ErrorMatches, "invisible widgets require a callback")
+	c.Check((&V2{SiteKey: "k", Size: "invisible", Badge: "inline", Callback: "onSubmit"}).Validate(), IsNil)
+	c.Check((&V2{SiteKey: "k", Callback: "alert(1)"}).Validate(), ErrorMatches, `invalid callback name 'alert\(1\)'`)
+
+	_, err := (&V2{}).HTML()
+	c.Check(err, ErrorMatches, "recaptcha site key cannot be blank")
+}
+
+func (s *WidgetSuite) TestScript(c *C) {
+	c.Check(Script(""), Equals, template.HTML(`<script src="https://www.google.com/recaptcha/api.js" async defer></script>`))
+	c.Check(Script(RecaptchaNet), Equals, template.HTML(`<script src="https://www.recaptcha.net/recaptcha/api.js" async defer></script>`))
+}
+
+func (s *WidgetSuite) TestV3Script(c *C) {
+	_, err := NewV3("", Google)
+	c.Check(err, ErrorMatches, "recaptcha site key cannot be blank")
+
+	v3, err := NewV3("site-key", RecaptchaNet)
+	c.Assert(err, IsNil)
+	c.Check(v3.Script(), Equals, template.HTML(`<script src="https://www.recaptcha.net/recaptcha/api.js?render=site-key"></script>`))
+}
+
+func (s *WidgetSuite) TestV3Execute(c *C) {
+	v3, _ := NewV3("site-key", "")
+	snippet, err := v3.Execute("login", "login-form")
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), Matches, `(?s)<script>.*document.getElementById\("login-form"\).*`+
+		`grecaptcha.execute\("site-key", {action: "login"}\).*input.name = "g-recaptcha-response";.*</script>`)
+
+	v3.Field = "token"
+	snippet, _ = v3.Execute("login", `"></script><script>alert(1)//`)
+	c.Check(string(snippet), Matches, `(?s).*input.name = "token";.*`)
+	c.Check(string(snippet), Not(Matches), `(?s).*</script><script>alert.*`)
+
+	_, err = v3.Execute("log-in", "login-form")
+	c.Check(err, ErrorMatches, "invalid action name 'log-in'.*")
+	_, err = v3.Execute("", "login-form")
+	c.Check(err, ErrorMatches, "action cannot be blank")
+	_, err = v3.Execute("login", "")
+	c.Check(err, ErrorMatches, "form ID cannot be blank")
+}
+
+func (s *WidgetSuite) TestCSP(c *C) {
+	c.Check(NewCSP("").String(), Equals, "script-src https://www.google.com/recaptcha/ https://www.gstatic.com/recaptcha/; "+
+		"frame-src https://www.google.com/recaptcha/ https://recaptcha.google.com/recaptcha/; connect-src https://www.google.com/recaptcha/")
+	c.Check(NewCSP(RecaptchaNet), DeepEquals, CSP{
+		ScriptSrc:  []string{"https://www.recaptcha.net/recaptcha/", "https://www.gstatic.com/recaptcha/"},
+		FrameSrc:   []string{"https://www.recaptcha.net/recaptcha/"},
+		ConnectSrc: []string{"https://www.recaptcha.net/recaptcha/"},
+	})
+	c.Check(CSP{FrameSrc: []string{"https://www.recaptcha.net/recaptcha/"}}.String(), Equals, "frame-src https://www.recaptcha.net/recaptcha/")
+}
+
+func (s *WidgetSuite) TestProviderCSP(c *C) {
+	c.Check(NewProviderCSP(nil, RecaptchaNet), DeepEquals, NewCSP(RecaptchaNet))
+	c.Check(NewProviderCSP(recaptcha.ReCAPTCHAProvider, ""), DeepEquals, NewCSP(Google))
+	c.Check(NewProviderCSP(recaptcha.TurnstileProvider, "").String(), Equals,
+		"script-src https://challenges.cloudflare.com; frame-src https://challenges.cloudflare.com")
+	c.Check(NewProviderCSP(recaptcha.HCaptcha{SiteKey: "sitekey"}, "").String(), Equals,
+		"script-src https://hcaptcha.com https://*.hcaptcha.com; frame-src https://hcaptcha.com https://*.hcaptcha.com; "+
+			"connect-src https://hcaptcha.com https://*.hcaptcha.com")
+}
+
+func (s *WidgetSuite) TestFuncMap(c *C) {
+	page := template.Must(template.New("page").Funcs(FuncMap("site-key", "")).Parse(
+		`{{recaptchaScript}}<form>{{recaptchaWidget "theme" "dark" "tabindex" "2"}}</form><p>{{recaptchaSiteKey}}</p>`))
+	var buf bytes.Buffer
+	c.Assert(page.Execute(&buf, nil), IsNil)
+	c.Check(buf.String(), Equals, `<script src="https://www.google.com/recaptcha/api.js" async defer></script>`+
+		`<form><div class="g-recaptcha" data-sitekey="site-key" data-theme="dark" data-tabindex="2"></div></form><p>site-key</p>`)
+
+	page = template.Must(template.New("page").Funcs(FuncMap("site-key", RecaptchaNet)).Parse(
+		`{{recaptchaV3Script}}{{recaptchaExecute "login" "login-form"}}`))
+	buf.Reset()
+	c.Assert(page.Execute(&buf, nil), IsNil)
+	c.Check(strings.HasPrefix(buf.String(), `<script src="https://www.recaptcha.net/recaptcha/api.js?render=site-key"></script><script>`), Equals, true)
+
+	for _, text := range []string{`{{recaptchaWidget "theme"}}`, `{{recaptchaWidget "color" "red"}}`, `{{recaptchaWidget "size" "huge"}}`} {
+		page = template.Must(template.New("page").Funcs(FuncMap("site-key", "")).Parse(text))
+		c.Check(page.Execute(&buf, nil), NotNil, Commentf(text))
+	}
+}